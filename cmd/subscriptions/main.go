@@ -1,13 +1,37 @@
 package main
 
-import "github.com/ent1k1377/subscriptions/internal/app"
-
-// @title			Subscription API
-// @version		1.0
-// @description	API для управления подписками пользователей
-// @host			localhost:8080
-// @BasePath		/api
-// @schemes		http
+import (
+	"log/slog"
+	"os"
+
+	"github.com/ent1k1377/subscriptions/internal/app"
+)
+
+// @title						Subscription API
+// @version					1.0
+// @description				API для управления подписками пользователей
+// @host						localhost:8080
+// @BasePath					/api
+// @schemes					http
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				JWT в формате "Bearer <token>". Subject токена — UUID пользователя.
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						Authorization
+// @description				API-ключ сервиса в формате "ApiKey <key>", права ключа проверяются для каждого маршрута.
 func main() {
-	app.New().Run()
+	application, err := app.New()
+	if err != nil {
+		slog.Error("Failed to initialize application", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// причину ошибки Run уже записал в лог
+	if err := application.Run(); err != nil {
+		os.Exit(1)
+	}
 }
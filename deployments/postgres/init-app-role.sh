@@ -0,0 +1,17 @@
+#!/bin/sh
+# Роль приложения: без суперпользователя и BYPASSRLS, иначе политики Row-Level Security
+# не действуют. Таблицы создают миграции от имени владельца ($POSTGRES_USER), права на них
+# роль приложения получает через привилегии по умолчанию.
+set -e
+
+psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" \
+	-v app_user="$APP_DATABASE_USERNAME" -v app_password="$APP_DATABASE_PASSWORD" \
+	-v owner="$POSTGRES_USER" -v dbname="$POSTGRES_DB" <<'SQL'
+CREATE ROLE :"app_user" LOGIN NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE PASSWORD :'app_password';
+GRANT CONNECT ON DATABASE :"dbname" TO :"app_user";
+GRANT USAGE ON SCHEMA public TO :"app_user";
+ALTER DEFAULT PRIVILEGES FOR ROLE :"owner" IN SCHEMA public
+	GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO :"app_user";
+ALTER DEFAULT PRIVILEGES FOR ROLE :"owner" IN SCHEMA public
+	GRANT USAGE, SELECT ON SEQUENCES TO :"app_user";
+SQL
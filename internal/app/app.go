@@ -3,70 +3,323 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/ent1k1377/subscriptions/internal/auth"
 	"github.com/ent1k1377/subscriptions/internal/config"
 	"github.com/ent1k1377/subscriptions/internal/database/postgres"
 	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
 	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/tracing"
+	mygraphql "github.com/ent1k1377/subscriptions/internal/transport/graphql"
+	mygrpc "github.com/ent1k1377/subscriptions/internal/transport/grpc"
+	grpcsubscription "github.com/ent1k1377/subscriptions/internal/transport/grpc/handler/subscription"
 	myhttp "github.com/ent1k1377/subscriptions/internal/transport/http"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/apikey"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/audit"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/calendar"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/events"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/graphql"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/health"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/role"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/statement"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/webhook"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/ent1k1377/subscriptions/internal/transport/sink"
+	"github.com/ent1k1377/subscriptions/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type App struct {
-	server *myhttp.Server
-	db     *postgres.DB
-	logger *slog.Logger
+	cfg           config.ShutdownConfig
+	lifecycle     *lifecycle
+	healthService *service.Health
+	logger        *slog.Logger
 }
 
-func New() *App {
-	cfg := config.MustLoadConfig()
+// New собирает приложение. Если сборка не удалась, уже открытые ресурсы освобождаются.
+func New() (_ *App, err error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
 
 	baseLogger := setupLogger(cfg.LoggerConfig.Level)
 	slog.SetDefault(baseLogger)
 	baseLogger.Info("Application configuration initialized", slog.Any("cfg", cfg))
 	baseLogger.Info("Initialized application")
 
+	lc := newLifecycle(baseLogger.WithGroup("lifecycle"))
+	defer func() {
+		if err != nil {
+			_ = lc.stop(context.Background())
+		}
+	}()
+
+	tracerProvider, err := tracing.Setup(context.Background(), cfg.TracingConfig, baseLogger)
+	if err != nil {
+		return nil, err
+	}
+	if tracerProvider != nil {
+		// накопленные спаны отправляются последними, когда запросы и фоновые задачи уже завершены
+		lc.resource("tracer provider", tracerProvider.Shutdown)
+	}
+
 	pool, err := postgres.GetConnection(cfg.DatabaseConfig)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	baseLogger.Info("Successful connection to the database")
 
 	db := postgres.NewDB(pool)
+	lc.resource("database", func(context.Context) error {
+		db.Close()
+		return nil
+	})
+
+	migrationVersion, err := migrations.LatestVersion()
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+	healthService := service.NewHealth(baseLogger, cfg.HealthConfig, db, migrationVersion)
+	healthHandler := health.NewHandler(baseLogger, healthService)
+
+	roleRepo := repository.NewRole(pool, baseLogger)
+	roleService := service.NewRole(baseLogger, roleRepo)
+	roleHandler := role.NewHandler(baseLogger, roleService)
+
+	authenticator, err := newAuthenticator(cfg.AuthConfig, baseLogger)
+	if err != nil {
+		return nil, err
+	}
+	if authenticator != nil {
+		authenticator = roleService.WithAssignedRoles(authenticator)
+	}
+
 	subscriptionRepo := repository.NewSubscription(pool, baseLogger)
+	webhookRepo := repository.NewWebhook(pool, baseLogger)
+	webhookService := service.NewWebhook(baseLogger, cfg.WebhookConfig, webhookRepo)
+	webhookHandler := webhook.NewHandler(baseLogger, webhookService)
+
+	sinks, sinkClosers, err := newEventSinks(cfg.OutboxConfig, webhookService)
+	if err != nil {
+		return nil, err
+	}
+	for _, closer := range sinkClosers {
+		lc.resource("event sink", func(context.Context) error {
+			return closer.Close()
+		})
+	}
+	outboxRepo := repository.NewOutbox(pool, baseLogger)
+	outboxService := service.NewOutbox(baseLogger, cfg.OutboxConfig, outboxRepo, subscriptionRepo, sinks...)
+
 	subscriptionService := service.NewSubscription(baseLogger, subscriptionRepo)
 	subscriptionHandler := subscription.NewHandler(baseLogger, subscriptionService)
 
-	server := myhttp.NewServer(cfg.ServerConfig, baseLogger, subscriptionHandler)
+	candidateRepo := repository.NewSubscriptionCandidate(pool, baseLogger)
 
-	return &App{
-		server: server,
-		db:     db,
-		logger: baseLogger,
+	calendarTokenRepo := repository.NewCalendarToken(pool, baseLogger)
+	calendarService := service.NewCalendar(baseLogger, calendarTokenRepo, subscriptionRepo, candidateRepo)
+	calendarHandler := calendar.NewHandler(baseLogger, calendarService)
+
+	statementService := service.NewStatement(baseLogger, candidateRepo, subscriptionService)
+	statementHandler := statement.NewHandler(baseLogger, statementService)
+
+	changeRepo := repository.NewSubscriptionChange(pool, baseLogger)
+	changeFeed := service.NewChangeFeed(baseLogger, cfg.EventsConfig, changeRepo)
+	eventsHandler := events.NewHandler(baseLogger, cfg.EventsConfig, changeFeed)
+
+	graphqlSchema, err := mygraphql.NewSchema(cfg.GraphQLConfig, baseLogger, subscriptionService)
+	if err != nil {
+		return nil, err
+	}
+	graphqlHandler := graphql.NewHandler(baseLogger, cfg.GraphQLConfig, graphqlSchema)
+
+	apiKeyRepo := repository.NewAPIKey(pool, baseLogger)
+	apiKeyService := service.NewAPIKey(baseLogger, apiKeyRepo)
+	apiKeyHandler := apikey.NewHandler(baseLogger, apiKeyService)
+
+	auditRepo := repository.NewAudit(pool, baseLogger)
+	auditService := service.NewAudit(baseLogger, auditRepo)
+	auditHandler := audit.NewHandler(baseLogger, auditService)
+
+	rateLimiter, err := newRateLimiter(cfg.RateLimitConfig, pool, baseLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := metrics.NewRegistry()
+	if err := errors.Join(
+		registry.Register(metrics.NewPoolCollector(pool)),
+		registry.Register(metrics.NewBusinessCollector(baseLogger, subscriptionRepo)),
+	); err != nil {
+		return nil, fmt.Errorf("error registering metrics: %w", err)
 	}
+
+	server := myhttp.NewServer(cfg.ServerConfig, cfg.TenantConfig, cfg.RateLimitConfig, baseLogger, rateLimiter, metrics.Handler(registry), healthHandler, authenticator, apiKeyService, apiKeyHandler, roleHandler, auditHandler, subscriptionHandler, calendarHandler, statementHandler, webhookHandler, eventsHandler, graphqlHandler)
+
+	grpcSubscriptionHandler := grpcsubscription.NewHandler(baseLogger, subscriptionService)
+	grpcServer := mygrpc.NewServer(cfg.GRPCConfig, cfg.TenantConfig, baseLogger, authenticator, grpcSubscriptionHandler)
+
+	// фоновые задачи запускаются раньше серверов и останавливаются после них, когда
+	// новых запросов уже нет
+	lc.worker("webhooks", webhookService.Run)
+	lc.worker("outbox", outboxService.Run)
+	lc.server("grpc server", grpcServer.Start, grpcServer.Close)
+	lc.server("http server", func() error {
+		if err := server.Start(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}, server.Close)
+	// лента изменений останавливается раньше HTTP-сервера: открытые SSE-потоки завершаются
+	// только вместе с ней, иначе Shutdown ждал бы их до таймаута
+	lc.worker("change feed", changeFeed.Run)
+
+	return &App{
+		cfg:           cfg.ShutdownConfig,
+		lifecycle:     lc,
+		healthService: healthService,
+		logger:        baseLogger,
+	}, nil
 }
 
-func (a *App) Run() {
-	a.logger.Info("Starting server")
+// Run запускает компоненты и ждет SIGINT, SIGTERM или сбоя одного из них, после чего
+// останавливает приложение. Возвращает ошибку сбоя или остановки для ненулевого кода выхода.
+func (a *App) Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	ctx, cancel := context.WithCancel(ctx)
+	failed := a.lifecycle.start()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("Shutdown signal received")
+		// /readyz начинает отвечать 503, но запросы обслуживаются, пока балансировщики
+		// не уберут экземпляр из ротации
+		a.healthService.StartShutdown()
+		a.drain()
+	case runErr = <-failed:
+		a.logger.Error("Component failed, shutting down", slog.String("error", runErr.Error()))
+		a.healthService.StartShutdown()
+	}
+
+	a.logger.Info("Stopping application", slog.Duration("timeout", a.cfg.Timeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.Timeout)
+	defer cancel()
+
+	if err := a.lifecycle.stop(shutdownCtx); err != nil {
+		return errors.Join(runErr, err)
+	}
+	a.logger.Info("Application stopped")
+
+	return runErr
+}
+
+// drain ждет shutdown.drain_delay; повторный сигнал прерывает ожидание
+func (a *App) drain() {
+	if a.cfg.DrainDelay <= 0 {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	a.logger.Info("Draining traffic", slog.Duration("delay", a.cfg.DrainDelay))
+	select {
+	case <-time.After(a.cfg.DrainDelay):
+	case <-signals:
+		a.logger.Warn("Second shutdown signal received, skipping drain")
+	}
+}
+
+// newAuthenticator возвращает nil, если аутентификация выключена: тогда серверы
+// не проверяют токены, а сервисы не ограничивают доступ
+func newAuthenticator(cfg config.AuthConfig, baseLogger *slog.Logger) (domain.Authenticator, error) {
+	if !cfg.Enabled {
+		baseLogger.Warn("Authentication is disabled, all subscriptions are accessible to any caller")
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating auth config: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	go func() {
-		if err := a.server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			cancel()
+	jwt, err := auth.NewJWT(ctx, cfg, baseLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt, nil
+}
+
+// newRateLimiter возвращает nil, если ограничение частоты запросов выключено
+func newRateLimiter(cfg config.RateLimitConfig, pool *pgxpool.Pool, baseLogger *slog.Logger) (domain.RateLimiter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating rate limit config: %w", err)
+	}
+
+	if cfg.Backend == config.RateLimitPostgres {
+		return repository.NewRateLimit(pool, baseLogger), nil
+	}
+
+	return middleware.NewMemoryRateLimiter(), nil
+}
+
+// newEventSinks создает получателей событий outbox в порядке, указанном в конфигурации.
+// Если какой-то получатель создать не удалось, уже открытые закрываются.
+func newEventSinks(cfg config.OutboxConfig, webhookService *service.Webhook) ([]domain.EventSink, []io.Closer, error) {
+	sinks := make([]domain.EventSink, 0, len(cfg.Sinks))
+	var closers []io.Closer
+
+	for _, name := range cfg.Sinks {
+		switch name {
+		case config.SinkWebhook:
+			sinks = append(sinks, webhookService)
+		case config.SinkStdout:
+			sinks = append(sinks, sink.NewStdout(os.Stdout))
+		case config.SinkNATS:
+			natsSink, err := sink.NewNATS(cfg.NATS)
+			if err != nil {
+				return nil, nil, errors.Join(fmt.Errorf("connect to nats: %w", err), closeAll(closers))
+			}
+			sinks = append(sinks, natsSink)
+			closers = append(closers, natsSink)
+		case config.SinkKafka:
+			kafkaSink := sink.NewKafka(cfg.Kafka)
+			sinks = append(sinks, kafkaSink)
+			closers = append(closers, kafkaSink)
 		}
-	}()
+	}
 
-	<-ctx.Done()
+	return sinks, closers, nil
+}
+
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-	_ = a.server.Close(context.Background())
-	// TODO лог ошибки, да и вообще надо получше сделать shutdown
-	a.db.Close()
+	return errors.Join(errs...)
 }
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// component часть приложения с собственным жизненным циклом. run блокируется, пока
+// компонент работает, и возвращает ошибку сбоя; stop просит компонент завершиться и
+// ждет этого не дольше ctx. У ресурсов вроде пула соединений run нет, только stop.
+type component struct {
+	name    string
+	run     func() error
+	stop    func(ctx context.Context) error
+	started bool
+}
+
+// lifecycle запускает компоненты в порядке добавления и останавливает в обратном:
+// компонент останавливается раньше тех, от которых зависит
+type lifecycle struct {
+	logger     *slog.Logger
+	components []*component
+}
+
+func newLifecycle(logger *slog.Logger) *lifecycle {
+	return &lifecycle{logger: logger}
+}
+
+// resource добавляет ресурс, который нужно только освободить при остановке
+func (l *lifecycle) resource(name string, stop func(ctx context.Context) error) {
+	l.components = append(l.components, &component{name: name, stop: stop})
+}
+
+// server добавляет сервер: start слушает порт до вызова stop
+func (l *lifecycle) server(name string, start func() error, stop func(ctx context.Context) error) {
+	l.components = append(l.components, &component{name: name, run: start, stop: stop})
+}
+
+// worker добавляет фоновую задачу, которая работает до отмены своего контекста
+func (l *lifecycle) worker(name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	l.components = append(l.components, &component{
+		name: name,
+		run: func() error {
+			defer close(done)
+			run(ctx)
+			return nil
+		},
+		stop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// start запускает компоненты и возвращает канал, в который попадает первый сбой
+func (l *lifecycle) start() <-chan error {
+	failed := make(chan error, 1)
+
+	for _, c := range l.components {
+		c.started = true
+		if c.run == nil {
+			continue
+		}
+
+		l.logger.Info("Starting component", slog.String("component", c.name))
+		go func() {
+			if err := c.run(); err != nil {
+				select {
+				case failed <- fmt.Errorf("%s: %w", c.name, err):
+				default:
+				}
+			}
+		}()
+	}
+
+	return failed
+}
+
+// stop останавливает запущенные компоненты в обратном порядке. Все остановки делят
+// один дедлайн ctx; ошибка одной не прерывает остальные, чтобы ресурсы были освобождены.
+func (l *lifecycle) stop(ctx context.Context) error {
+	var errs []error
+
+	for i := len(l.components) - 1; i >= 0; i-- {
+		c := l.components[i]
+		if !c.started && c.run != nil {
+			continue
+		}
+
+		start := time.Now()
+		if err := c.stop(ctx); err != nil {
+			l.logger.Error("Failed to stop component",
+				slog.String("component", c.name),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("error", err.Error()),
+			)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+			continue
+		}
+		l.logger.Info("Component stopped", slog.String("component", c.name), slog.Duration("duration", time.Since(start)))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/service"
+)
+
+// journal порядок событий жизненного цикла из нескольких горутин
+type journal struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (j *journal) add(event string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, event)
+}
+
+func (j *journal) list() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Clone(j.events)
+}
+
+// testServer сервер, который работает до вызова stop и перед выходом дожидается
+// запроса, обслуживаемого в момент остановки
+func testServer(j *journal, name string, inFlight time.Duration) (func() error, func(context.Context) error) {
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+
+	run := func() error {
+		defer close(done)
+		<-stopped
+		time.Sleep(inFlight)
+		j.add(name + " drained")
+		return nil
+	}
+	stop := func(ctx context.Context) error {
+		close(stopped)
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return run, stop
+}
+
+// TestLifecycleStopOrder компоненты останавливаются в обратном порядке: серверы
+// дослуживают запросы до остановки фоновых задач и закрытия базы
+func TestLifecycleStopOrder(t *testing.T) {
+	j := &journal{}
+	lc := newLifecycle(slog.New(slog.DiscardHandler))
+
+	lc.resource("database", func(context.Context) error {
+		j.add("database closed")
+		return nil
+	})
+	lc.worker("outbox", func(ctx context.Context) {
+		<-ctx.Done()
+		j.add("outbox stopped")
+	})
+	run, stop := testServer(j, "http server", 20*time.Millisecond)
+	lc.server("http server", run, stop)
+
+	failed := lc.start()
+	if err := lc.stop(context.Background()); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	want := []string{"http server drained", "outbox stopped", "database closed"}
+	if got := j.list(); !slices.Equal(got, want) {
+		t.Errorf("stop order = %v, want %v", got, want)
+	}
+	select {
+	case err := <-failed:
+		t.Errorf("graceful stop reported failure %v", err)
+	default:
+	}
+}
+
+// TestLifecycleStopNotStarted если сборка приложения прервалась до start, освобождаются
+// только ресурсы: незапущенные серверы и задачи не останавливаются
+func TestLifecycleStopNotStarted(t *testing.T) {
+	j := &journal{}
+	lc := newLifecycle(slog.New(slog.DiscardHandler))
+
+	lc.resource("database", func(context.Context) error {
+		j.add("database closed")
+		return nil
+	})
+	lc.server("http server", func() error { return nil }, func(context.Context) error {
+		j.add("http server stopped")
+		return nil
+	})
+
+	if err := lc.stop(context.Background()); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+	if got := j.list(); !slices.Equal(got, []string{"database closed"}) {
+		t.Errorf("stopped %v, want only the database", got)
+	}
+}
+
+// TestLifecycleStopTimeout компонент, не уложившийся в общий дедлайн, возвращает ошибку,
+// но остальные компоненты все равно останавливаются
+func TestLifecycleStopTimeout(t *testing.T) {
+	j := &journal{}
+	lc := newLifecycle(slog.New(slog.DiscardHandler))
+
+	lc.resource("database", func(context.Context) error {
+		j.add("database closed")
+		return nil
+	})
+	lc.resource("event sink", func(context.Context) error {
+		return errors.New("broker unreachable")
+	})
+	release := make(chan struct{})
+	defer close(release)
+	lc.worker("webhooks", func(context.Context) {
+		<-release
+	})
+
+	lc.start()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := lc.stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop webhooks") {
+		t.Errorf("stop() error = %v, want the webhooks worker deadline", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "stop event sink: broker unreachable") {
+		t.Errorf("stop() error = %v, want the event sink error joined", err)
+	}
+	if got := j.list(); !slices.Equal(got, []string{"database closed"}) {
+		t.Errorf("stopped %v, want the database closed after the failures", got)
+	}
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	lc := newLifecycle(slog.New(slog.DiscardHandler))
+	errBind := errors.New("address already in use")
+
+	lc.server("grpc server", func() error { return errBind }, func(context.Context) error { return nil })
+	lc.server("http server", func() error { return errBind }, func(context.Context) error { return nil })
+
+	select {
+	case err := <-lc.start():
+		if !errors.Is(err, errBind) || !strings.HasSuffix(err.Error(), "server: address already in use") {
+			t.Errorf("failure = %v, want the server error with its name", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("start() did not report the failed server")
+	}
+}
+
+type database struct{}
+
+func (database) Ping(context.Context) error {
+	return nil
+}
+
+func (database) MigrationVersion(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// TestAppRunComponentFailure сбой компонента останавливает приложение: готовность
+// снимается, остальные компоненты останавливаются, Run возвращает ошибку сбоя и остановки
+func TestAppRunComponentFailure(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	j := &journal{}
+	lc := newLifecycle(logger)
+
+	lc.resource("database", func(context.Context) error {
+		j.add("database closed")
+		return nil
+	})
+	release := make(chan struct{})
+	defer close(release)
+	lc.worker("change feed", func(context.Context) {
+		<-release
+	})
+	lc.worker("webhooks", func(ctx context.Context) {
+		select {
+		case <-ctx.Done():
+			j.add("webhooks stopped")
+		case <-release:
+		}
+	})
+	lc.server("http server", func() error { return errors.New("address already in use") }, func(context.Context) error { return nil })
+
+	healthService := service.NewHealth(logger, config.HealthConfig{CheckTimeout: time.Second}, database{}, 0)
+	app := &App{
+		cfg:           config.ShutdownConfig{DrainDelay: time.Hour, Timeout: 50 * time.Millisecond},
+		lifecycle:     lc,
+		healthService: healthService,
+		logger:        logger,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the component failure")
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "http server: address already in use") {
+		t.Errorf("Run() error = %v, want the http server failure", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop change feed") {
+		t.Errorf("Run() error = %v, want the change feed stop deadline", err)
+	}
+	if !healthService.ShuttingDown() {
+		t.Error("readiness is not withdrawn after the failure")
+	}
+	if got := j.list(); !slices.Equal(got, []string{"webhooks stopped", "database closed"}) {
+		t.Errorf("stopped %v, want webhooks then database", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"os"
 	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/tracing"
 )
 
 func setupLogger(lvl string) *slog.Logger {
@@ -25,5 +27,6 @@ func setupLogger(lvl string) *slog.Logger {
 		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
 	}
 
-	return slog.New(handler)
+	// trace_id и span_id добавляются в каждую запись, у контекста которой есть спан
+	return slog.New(tracing.NewLogHandler(handler))
 }
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minJWKSRefetch как часто можно перечитывать JWKS из-за неизвестного kid:
+// иначе токены с выдуманным kid превращаются в запросы к провайдеру
+const minJWKSRefetch = time.Minute
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// keySet открытые ключи RS256 из JWKS. Ключи из файла читаются один раз, по URL
+// перечитываются раз в refresh и при появлении неизвестного kid.
+type keySet struct {
+	logger  *slog.Logger
+	file    string
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(ctx context.Context, logger *slog.Logger, file, url string, refresh time.Duration) (*keySet, error) {
+	set := &keySet{
+		logger:  logger,
+		file:    file,
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := set.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// key возвращает ключ по kid. Пустой kid допустим, если в наборе один ключ.
+func (s *keySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.lookup(kid)
+	stale := s.url != "" && time.Since(s.fetchedAt) > s.refresh
+	canRefetch := s.url != "" && time.Since(s.fetchedAt) > minJWKSRefetch
+	s.mu.RUnlock()
+
+	if (ok && !stale) || (!ok && !canRefetch) {
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}
+
+	if err := s.load(ctx); err != nil {
+		s.logger.Warn("Failed to refresh JWKS", slog.String("url", s.url), slog.String("error", err.Error()))
+		// устаревший ключ лучше отказа: провайдер может быть временно недоступен
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, ok = s.lookup(kid); !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+func (s *keySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *keySet) load(ctx context.Context) error {
+	data, err := s.read(ctx)
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *keySet) read(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+}
+
+// parseJWKS извлекает RSA-ключи для подписи, остальные ключи пропускаются
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != "RS256") {
+			continue
+		}
+
+		key, err := rsaPublicKey(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RS256 signing keys")
+	}
+
+	return keys, nil
+}
+
+func rsaPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid key parameters")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
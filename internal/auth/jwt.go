@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+	// OrganizationID привязывает токен к организации, см. domain.ResolveTenant
+	OrganizationID string `json:"org_id"`
+}
+
+// JWT проверяет токены доступа и превращает их в domain.Principal.
+// Subject токена должен быть UUID пользователя.
+type JWT struct {
+	logger *slog.Logger
+	secret []byte
+	keys   *keySet
+	// roles соответствие значений claim roles ролям сервиса
+	roles   map[string]domain.Role
+	options []jwt.ParserOption
+}
+
+func NewJWT(ctx context.Context, cfg config.AuthConfig, baseLogger *slog.Logger) (*JWT, error) {
+	logger := baseLogger.WithGroup("jwt")
+
+	var methods []string
+	if cfg.Secret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	var keys *keySet
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		var err error
+		keys, err = newKeySet(ctx, logger, cfg.JWKSFile, cfg.JWKSURL, cfg.JWKSRefresh)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("no jwt verification keys configured")
+	}
+
+	options := []jwt.ParserOption{
+		// алгоритм берется из заголовка токена, поэтому допустимые задаются явно
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &JWT{
+		logger: logger,
+		secret: []byte(cfg.Secret),
+		keys:   keys,
+		roles: map[string]domain.Role{
+			cfg.AdminRole:   domain.RoleAdmin,
+			cfg.SupportRole: domain.RoleSupport,
+		},
+		options: options,
+	}, nil
+}
+
+// Authenticate проверяет подпись и claims токена. Любая ошибка оборачивает
+// domain.ErrUnauthenticated.
+func (j *JWT) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	var tokenClaims claims
+	_, err := jwt.ParseWithClaims(token, &tokenClaims, func(token *jwt.Token) (any, error) {
+		switch token.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return j.secret, nil
+		case jwt.SigningMethodRS256.Alg():
+			kid, _ := token.Header["kid"].(string)
+			return j.keys.key(ctx, kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+		}
+	}, j.options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUnauthenticated, err)
+	}
+
+	subject, err := uuid.Parse(tokenClaims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject must be a user UUID", domain.ErrUnauthenticated)
+	}
+
+	// роль user есть у каждого, кто вошел по токену
+	roles := []domain.Role{domain.RoleUser}
+	for _, claim := range tokenClaims.Roles {
+		if role, ok := j.roles[claim]; ok && !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+
+	principal := &domain.Principal{
+		Subject: subject,
+		Roles:   roles,
+	}
+	if tokenClaims.OrganizationID != "" {
+		organizationID, err := uuid.Parse(tokenClaims.OrganizationID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: org_id must be a UUID", domain.ErrUnauthenticated)
+		}
+		principal.OrganizationID = &organizationID
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,381 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const testSecret = "test-secret-at-least-32-bytes-long"
+
+var testSubject = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+func validClaims() claims {
+	return claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   testSubject.String(),
+			Issuer:    "https://issuer.example",
+			Audience:  jwt.ClaimStrings{"subscriptions"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func signHS256(t *testing.T, tokenClaims jwt.Claims, secret string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+func signRS256(t *testing.T, tokenClaims jwt.Claims, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, tokenClaims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return signed
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	return key
+}
+
+func jwksDocument(keys map[string]*rsa.PrivateKey) []byte {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for kid, key := range keys {
+		document.Keys = append(document.Keys, jsonWebKey{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, _ := json.Marshal(document)
+	return data
+}
+
+func newTestJWT(t *testing.T, cfg config.AuthConfig) *JWT {
+	t.Helper()
+
+	authenticator, err := NewJWT(context.Background(), cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+
+	return authenticator
+}
+
+func TestJWTAuthenticateHS256(t *testing.T) {
+	authenticator := newTestJWT(t, config.AuthConfig{
+		Secret:      testSecret,
+		Issuer:      "https://issuer.example",
+		Audience:    "subscriptions",
+		AdminRole:   "admin",
+		SupportRole: "support",
+	})
+
+	tests := []struct {
+		name      string
+		token     func() string
+		wantRoles []domain.Role
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			token:     func() string { return signHS256(t, validClaims(), testSecret) },
+			wantRoles: []domain.Role{domain.RoleUser},
+		},
+		{
+			name: "roles from claim",
+			token: func() string {
+				c := validClaims()
+				c.Roles = []string{"viewer", "admin", "support", "admin"}
+				return signHS256(t, c, testSecret)
+			},
+			wantRoles: []domain.Role{domain.RoleUser, domain.RoleAdmin, domain.RoleSupport},
+		},
+		{
+			name:    "wrong secret",
+			token:   func() string { return signHS256(t, validClaims(), "another-secret-at-least-32-bytes") },
+			wantErr: true,
+		},
+		{
+			name: "tampered payload",
+			token: func() string {
+				token := signHS256(t, validClaims(), testSecret)
+				other := validClaims()
+				other.Roles = []string{"admin"}
+				forged := signHS256(t, other, "another-secret-at-least-32-bytes")
+				// подпись исходного токена с полезной нагрузкой другого
+				return forged[:len(forged)-43] + token[len(token)-43:]
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := validClaims()
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+				return signHS256(t, c, testSecret)
+			},
+			wantErr: true,
+		},
+		{
+			name: "without exp",
+			token: func() string {
+				c := validClaims()
+				c.ExpiresAt = nil
+				return signHS256(t, c, testSecret)
+			},
+			wantErr: true,
+		},
+		{
+			name: "not yet valid",
+			token: func() string {
+				c := validClaims()
+				c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+				return signHS256(t, c, testSecret)
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				c := validClaims()
+				c.Issuer = "https://evil.example"
+				return signHS256(t, c, testSecret)
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				c := validClaims()
+				c.Audience = jwt.ClaimStrings{"billing"}
+				return signHS256(t, c, testSecret)
+			},
+			wantErr: true,
+		},
+		{
+			name: "subject is not a uuid",
+			token: func() string {
+				c := validClaims()
+				c.Subject = "alice"
+				return signHS256(t, c, testSecret)
+			},
+			wantErr: true,
+		},
+		{
+			name: "alg none",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatalf("sign token: %v", err)
+				}
+				return token
+			},
+			wantErr: true,
+		},
+		{
+			name:    "rs256 without configured keys",
+			token:   func() string { return signRS256(t, validClaims(), generateKey(t), "") },
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   func() string { return "not.a.jwt" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), tt.token())
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrUnauthenticated) {
+					t.Errorf("Authenticate() error = %v, want ErrUnauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if principal.Subject != testSubject || !slices.Equal(principal.Roles, tt.wantRoles) {
+				t.Errorf("Authenticate() = %+v, want subject %s, roles %v", principal, testSubject, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticateJWKSFile(t *testing.T) {
+	key, otherKey := generateKey(t), generateKey(t)
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, jwksDocument(map[string]*rsa.PrivateKey{"key-1": key}), 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	authenticator := newTestJWT(t, config.AuthConfig{JWKSFile: file})
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: signRS256(t, validClaims(), key, "key-1")},
+		{name: "single key without kid", token: signRS256(t, validClaims(), key, "")},
+		{name: "unknown kid", token: signRS256(t, validClaims(), key, "key-2"), wantErr: true},
+		{name: "signed by another key", token: signRS256(t, validClaims(), otherKey, "key-1"), wantErr: true},
+		// без секрета HS256 не допускается, иначе открытый ключ сработал бы как секрет HMAC
+		{name: "hs256 without secret", token: signHS256(t, validClaims(), testSecret), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(context.Background(), tt.token)
+			if tt.wantErr && !errors.Is(err, domain.ErrUnauthenticated) {
+				t.Errorf("Authenticate() error = %v, want ErrUnauthenticated", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Authenticate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticateJWKSURLRotation(t *testing.T) {
+	oldKey, newKey := generateKey(t), generateKey(t)
+
+	var document atomic.Value
+	document.Store(jwksDocument(map[string]*rsa.PrivateKey{"old": oldKey}))
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(document.Load().([]byte))
+	}))
+	defer server.Close()
+
+	authenticator := newTestJWT(t, config.AuthConfig{JWKSURL: server.URL, JWKSRefresh: time.Hour})
+
+	if _, err := authenticator.Authenticate(context.Background(), signRS256(t, validClaims(), oldKey, "old")); err != nil {
+		t.Fatalf("Authenticate() with the initial key error = %v", err)
+	}
+
+	// провайдер сменил ключ; неизвестный kid перечитывает JWKS не чаще minJWKSRefetch
+	document.Store(jwksDocument(map[string]*rsa.PrivateKey{"new": newKey}))
+	newToken := signRS256(t, validClaims(), newKey, "new")
+	if _, err := authenticator.Authenticate(context.Background(), newToken); err == nil {
+		t.Fatal("Authenticate() accepted an unknown kid without refetching")
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1 before minJWKSRefetch passes", got)
+	}
+
+	authenticator.keys.mu.Lock()
+	authenticator.keys.fetchedAt = time.Now().Add(-2 * minJWKSRefetch)
+	authenticator.keys.mu.Unlock()
+
+	if _, err := authenticator.Authenticate(context.Background(), newToken); err != nil {
+		t.Fatalf("Authenticate() with the rotated key error = %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", got)
+	}
+}
+
+func TestNewJWTWithoutKeys(t *testing.T) {
+	if _, err := NewJWT(context.Background(), config.AuthConfig{}, slog.New(slog.DiscardHandler)); err == nil {
+		t.Error("NewJWT() error = nil, want error without secret and JWKS")
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	key := generateKey(t)
+	valid := jsonWebKey{
+		Kty: "RSA",
+		Kid: "rsa",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+	encryption := valid
+	encryption.Kid, encryption.Use = "enc", "enc"
+	ec := jsonWebKey{Kty: "EC", Kid: "ec"}
+	weakExponent := valid
+	weakExponent.Kid, weakExponent.E = "weak", base64.RawURLEncoding.EncodeToString([]byte{1})
+
+	tests := []struct {
+		name     string
+		keys     []jsonWebKey
+		wantKids []string
+		wantErr  bool
+	}{
+		{name: "skips non-signing keys", keys: []jsonWebKey{valid, encryption, ec}, wantKids: []string{"rsa"}},
+		{name: "no signing keys", keys: []jsonWebKey{encryption, ec}, wantErr: true},
+		{name: "invalid exponent", keys: []jsonWebKey{valid, weakExponent}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(map[string]any{"keys": tt.keys})
+			keys, err := parseJWKS(data)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseJWKS() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJWKS() error = %v", err)
+			}
+			if len(keys) != len(tt.wantKids) {
+				t.Fatalf("parseJWKS() returned %d keys, want %v", len(keys), tt.wantKids)
+			}
+			for _, kid := range tt.wantKids {
+				if keys[kid] == nil {
+					t.Errorf("key %q is missing", kid)
+				}
+			}
+		})
+	}
+}
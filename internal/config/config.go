@@ -5,15 +5,27 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	DatabaseConfig DatabaseConfig `yaml:"database"`
-	ServerConfig   ServerConfig   `yaml:"server"`
-	LoggerConfig   LoggerConfig   `yaml:"logger"`
+	DatabaseConfig  DatabaseConfig  `yaml:"database"`
+	ServerConfig    ServerConfig    `yaml:"server"`
+	LoggerConfig    LoggerConfig    `yaml:"logger"`
+	WebhookConfig   WebhookConfig   `yaml:"webhooks"`
+	OutboxConfig    OutboxConfig    `yaml:"outbox"`
+	EventsConfig    EventsConfig    `yaml:"events"`
+	GRPCConfig      GRPCConfig      `yaml:"grpc"`
+	GraphQLConfig   GraphQLConfig   `yaml:"graphql"`
+	AuthConfig      AuthConfig      `yaml:"auth"`
+	TenantConfig    TenantConfig    `yaml:"tenant"`
+	RateLimitConfig RateLimitConfig `yaml:"rate_limit"`
+	TracingConfig   TracingConfig   `yaml:"tracing"`
+	HealthConfig    HealthConfig    `yaml:"health"`
+	ShutdownConfig  ShutdownConfig  `yaml:"shutdown"`
 }
 
 type DatabaseConfig struct {
@@ -33,6 +45,194 @@ type LoggerConfig struct {
 	Level string `yaml:"level"`
 }
 
+type GRPCConfig struct {
+	Port string `yaml:"port"`
+	// Reflection позволяет вызывать методы через grpcurl без .proto файлов
+	Reflection bool `yaml:"reflection"`
+}
+
+type GraphQLConfig struct {
+	// ComplexityLimit запросы дороже отклоняются до выполнения
+	ComplexityLimit int `yaml:"complexity_limit"`
+	// MaxDepth учитывает и интроспекцию: запросу схемы из GraphiQL нужна глубина 13
+	MaxDepth int `yaml:"max_depth"`
+	// Playground включает GraphiQL на /api/graphql/playground, только для разработки
+	Playground bool `yaml:"playground"`
+}
+
+// TenantConfig выбор организации запроса. Организация из claim org_id токена важнее
+// заголовка; вызывающие без нее указывают организацию заголовком Header.
+type TenantConfig struct {
+	Header string `yaml:"header"`
+	// Required при false запросы без организации относятся к организации по умолчанию
+	Required bool `yaml:"required"`
+}
+
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+)
+
+// RateLimitConfig ограничение частоты запросов к HTTP API. Каждый вызывающий (API-ключ,
+// пользователь из JWT, без аутентификации — IP-адрес) получает по корзине на маршрут.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend memory — корзины в памяти экземпляра, postgres — общие для всех экземпляров
+	Backend string `yaml:"backend"`
+	// Default лимит маршрутов без собственного правила в Routes
+	Default RateLimitRule `yaml:"default"`
+	// Routes лимиты маршрутов по методу и шаблону пути gin: "POST /api/subscriptions/total"
+	Routes map[string]RateLimitRule `yaml:"routes"`
+	// Principals общий лимит на все маршруты для отдельных вызывающих по UUID пользователя
+	// или API-ключа, действует вместе с лимитами маршрутов
+	Principals map[string]RateLimitRule `yaml:"principals"`
+}
+
+// RateLimitRule корзина емкостью Burst запросов, пополняется на Rate запросов в секунду
+type RateLimitRule struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// HealthConfig проверки /readyz и /health
+type HealthConfig struct {
+	// CheckTimeout сколько ждать ответа зависимостей, прежде чем считать их недоступными
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+}
+
+// ShutdownConfig остановка по SIGINT и SIGTERM
+type ShutdownConfig struct {
+	// DrainDelay сколько сервис продолжает обслуживать запросы после перехода /readyz в 503,
+	// пока балансировщики не уберут его из ротации
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// Timeout сколько ждать завершения текущих запросов и фоновых задач, после чего
+	// оставшиеся соединения обрываются
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+const (
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+)
+
+// TracingConfig трассировка OpenTelemetry. Контекст трассы принимается из заголовка
+// traceparent (W3C Trace Context) и при выключенной трассировке, чтобы идентификаторы
+// вызывающего попадали в логи.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Exporter otlp — отправка в коллектор, stdout — вывод спанов в консоль для локальной разработки
+	Exporter    string `yaml:"exporter"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio доля трасс, которые начинает сервис; решение вызывающего из traceparent соблюдается
+	SampleRatio float64    `yaml:"sample_ratio"`
+	OTLP        OTLPConfig `yaml:"otlp"`
+}
+
+type OTLPConfig struct {
+	// Protocol grpc (порт коллектора 4317) или http (4318)
+	Protocol string `yaml:"protocol"`
+	Endpoint string `yaml:"endpoint"`
+	// Insecure отправка без TLS, для коллектора в той же сети
+	Insecure bool              `yaml:"insecure"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// AuthConfig настройки проверки JWT. Токены HS256 проверяются секретом из переменной
+// окружения AUTH_JWT_SECRET, RS256 — открытыми ключами из JWKS.
+type AuthConfig struct {
+	// Enabled при false запросы не аутентифицируются и доступ не ограничивается
+	Enabled bool   `yaml:"enabled"`
+	Secret  string `yaml:"-"`
+	// JWKSFile и JWKSURL источник открытых ключей RS256, достаточно одного из них
+	JWKSFile string `yaml:"jwks_file"`
+	JWKSURL  string `yaml:"jwks_url"`
+	// JWKSRefresh как часто перечитывать JWKS по URL. Неизвестный kid тоже приводит
+	// к перечитыванию, но не чаще раза в минуту.
+	JWKSRefresh time.Duration `yaml:"jwks_refresh"`
+	// Issuer и Audience проверяются, если заданы
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// Leeway допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration `yaml:"leeway"`
+	// AdminRole и SupportRole значения claim roles, соответствующие ролям администратора
+	// и поддержки. Остальные роли назначаются через API.
+	AdminRole   string `yaml:"admin_role"`
+	SupportRole string `yaml:"support_role"`
+}
+
+// WebhookConfig настройки отправки вебхуков. Незаданные значения заменяются значениями по умолчанию.
+type WebhookConfig struct {
+	// PollInterval как часто искать отправки, которые пора выполнить
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// BatchSize сколько отправок выполняется за один проход
+	BatchSize int `yaml:"batch_size"`
+	// Timeout ожидание ответа получателя
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts после стольких неудачных попыток отправка помечается failed
+	MaxAttempts int `yaml:"max_attempts"`
+	// RetryBaseDelay задержка перед первым повтором, далее удваивается до RetryMaxDelay
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay"`
+	// AllowPrivateNetworks разрешает адреса loopback и частных сетей, например для получателей
+	// в локальном окружении. В рабочем окружении включать нельзя: вебхук станет способом
+	// обращаться к внутренним сервисам.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+}
+
+const (
+	SinkWebhook = "webhook"
+	SinkStdout  = "stdout"
+	SinkNATS    = "nats"
+	SinkKafka   = "kafka"
+)
+
+// OutboxConfig настройки relay, который рассылает события из outbox по получателям
+type OutboxConfig struct {
+	// Sinks получатели событий: webhook, stdout, nats, kafka
+	Sinks []string `yaml:"sinks"`
+	// PollInterval как часто перечитывать outbox, если не пришло уведомление NOTIFY
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	BatchSize      int           `yaml:"batch_size"`
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay"`
+	// Retention сколько хранить опубликованные события
+	Retention time.Duration `yaml:"retention"`
+	// EndingSoonDays за сколько дней до окончания подписки отправлять subscription.ending_soon
+	EndingSoonDays int `yaml:"ending_soon_days"`
+	// EndingSoonInterval как часто искать заканчивающиеся подписки
+	EndingSoonInterval time.Duration `yaml:"ending_soon_interval"`
+	NATS               NATSConfig    `yaml:"nats"`
+	Kafka              KafkaConfig   `yaml:"kafka"`
+}
+
+// EventsConfig настройки SSE-потока изменений подписок
+type EventsConfig struct {
+	// Retention сколько хранить изменения для возобновления потока по Last-Event-ID
+	Retention time.Duration `yaml:"retention"`
+	// Heartbeat как часто отправлять комментарий-пинг, чтобы прокси не закрывали соединение
+	Heartbeat time.Duration `yaml:"heartbeat"`
+	// PollInterval как часто перечитывать изменения, если не пришло уведомление NOTIFY
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// BufferSize сколько изменений ждут отправки медленному клиенту, после чего поток закрывается
+	BufferSize int `yaml:"buffer_size"`
+}
+
+type NATSConfig struct {
+	URL string `yaml:"url"`
+	// SubjectPrefix к нему добавляется тип события: subscriptions.subscription.created
+	SubjectPrefix string `yaml:"subject_prefix"`
+	// JetStream публиковать с подтверждением и дедупликацией по Nats-Msg-Id
+	JetStream bool `yaml:"jetstream"`
+}
+
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+}
+
 func MustLoadConfig() *Config {
 	config, err := LoadConfig()
 	if err != nil {
@@ -69,6 +269,17 @@ func LoadConfig() (*Config, error) {
 
 	config.DatabaseConfig.Username = os.Getenv("DATABASE_USERNAME")
 	config.DatabaseConfig.Password = os.Getenv("DATABASE_PASSWORD")
+	config.AuthConfig.Secret = os.Getenv("AUTH_JWT_SECRET")
+	config.WebhookConfig.setDefaults()
+	config.OutboxConfig.setDefaults()
+	config.EventsConfig.setDefaults()
+	config.GraphQLConfig.setDefaults()
+	config.AuthConfig.setDefaults()
+	config.TenantConfig.setDefaults()
+	config.RateLimitConfig.setDefaults()
+	config.TracingConfig.setDefaults()
+	config.HealthConfig.setDefaults()
+	config.ShutdownConfig.setDefaults()
 
 	return &config, nil
 }
@@ -86,9 +297,72 @@ func (c *Config) LogValue() slog.Value {
 		slog.Group("server",
 			slog.String("host", c.ServerConfig.Port),
 		),
+		slog.Group("grpc",
+			slog.String("port", c.GRPCConfig.Port),
+			slog.Bool("reflection", c.GRPCConfig.Reflection),
+		),
+		slog.Group("graphql",
+			slog.Int("complexity_limit", c.GraphQLConfig.ComplexityLimit),
+			slog.Int("max_depth", c.GraphQLConfig.MaxDepth),
+			slog.Bool("playground", c.GraphQLConfig.Playground),
+		),
+		slog.Group("auth",
+			slog.Bool("enabled", c.AuthConfig.Enabled),
+			slog.Bool("has_secret", c.AuthConfig.Secret != ""),
+			slog.String("jwks_file", c.AuthConfig.JWKSFile),
+			slog.String("jwks_url", c.AuthConfig.JWKSURL),
+			slog.String("issuer", c.AuthConfig.Issuer),
+			slog.String("audience", c.AuthConfig.Audience),
+		),
+		slog.Group("tenant",
+			slog.String("header", c.TenantConfig.Header),
+			slog.Bool("required", c.TenantConfig.Required),
+		),
+		slog.Group("rate_limit",
+			slog.Bool("enabled", c.RateLimitConfig.Enabled),
+			slog.String("backend", c.RateLimitConfig.Backend),
+			slog.Float64("default_rate", c.RateLimitConfig.Default.Rate),
+			slog.Int("default_burst", c.RateLimitConfig.Default.Burst),
+			slog.Int("routes", len(c.RateLimitConfig.Routes)),
+			slog.Int("principals", len(c.RateLimitConfig.Principals)),
+		),
+		slog.Group("tracing",
+			slog.Bool("enabled", c.TracingConfig.Enabled),
+			slog.String("exporter", c.TracingConfig.Exporter),
+			slog.String("service_name", c.TracingConfig.ServiceName),
+			slog.Float64("sample_ratio", c.TracingConfig.SampleRatio),
+			slog.String("otlp_protocol", c.TracingConfig.OTLP.Protocol),
+			slog.String("otlp_endpoint", c.TracingConfig.OTLP.Endpoint),
+		),
+		slog.Group("health",
+			slog.Duration("check_timeout", c.HealthConfig.CheckTimeout),
+		),
+		slog.Group("shutdown",
+			slog.Duration("drain_delay", c.ShutdownConfig.DrainDelay),
+			slog.Duration("timeout", c.ShutdownConfig.Timeout),
+		),
 		slog.Group("logger",
 			slog.String("level", c.LoggerConfig.Level),
 		),
+		slog.Group("webhooks",
+			slog.Duration("poll_interval", c.WebhookConfig.PollInterval),
+			slog.Int("batch_size", c.WebhookConfig.BatchSize),
+			slog.Duration("timeout", c.WebhookConfig.Timeout),
+			slog.Int("max_attempts", c.WebhookConfig.MaxAttempts),
+			slog.Bool("allow_private_networks", c.WebhookConfig.AllowPrivateNetworks),
+		),
+		slog.Group("outbox",
+			slog.Any("sinks", c.OutboxConfig.Sinks),
+			slog.Duration("poll_interval", c.OutboxConfig.PollInterval),
+			slog.Int("batch_size", c.OutboxConfig.BatchSize),
+			slog.Duration("retention", c.OutboxConfig.Retention),
+			slog.Int("ending_soon_days", c.OutboxConfig.EndingSoonDays),
+		),
+		slog.Group("events",
+			slog.Duration("retention", c.EventsConfig.Retention),
+			slog.Duration("heartbeat", c.EventsConfig.Heartbeat),
+			slog.Int("buffer_size", c.EventsConfig.BufferSize),
+		),
 	)
 }
 
@@ -115,9 +389,24 @@ func (c *Config) Validate() error {
 	if err := c.LoggerConfig.Validate(); err != nil {
 		errors = append(errors, fmt.Sprintf("error validating logger config: %s", err))
 	}
+	if err := c.GRPCConfig.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("error validating grpc config: %s", err))
+	}
+	if err := c.OutboxConfig.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("error validating outbox config: %s", err))
+	}
+	if err := c.AuthConfig.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("error validating auth config: %s", err))
+	}
+	if err := c.RateLimitConfig.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("error validating rate limit config: %s", err))
+	}
+	if err := c.TracingConfig.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("error validating tracing config: %s", err))
+	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf(strings.Join(errors, "; "))
+		return fmt.Errorf("%s", strings.Join(errors, "; "))
 	}
 
 	return nil
@@ -146,7 +435,7 @@ func (c *DatabaseConfig) Validate() error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf(strings.Join(errors, ", "))
+		return fmt.Errorf("%s", strings.Join(errors, ", "))
 	}
 
 	return nil
@@ -160,6 +449,98 @@ func (c *ServerConfig) Validate() error {
 	return nil
 }
 
+func (c *GRPCConfig) Validate() error {
+	if c.Port == "" {
+		return fmt.Errorf("port is required")
+	}
+
+	return nil
+}
+
+func (c *AuthConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Secret == "" && c.JWKSFile == "" && c.JWKSURL == "" {
+		return fmt.Errorf("AUTH_JWT_SECRET, jwks_file or jwks_url is required")
+	}
+	if c.JWKSFile != "" && c.JWKSURL != "" {
+		return fmt.Errorf("only one of jwks_file and jwks_url can be set")
+	}
+
+	return nil
+}
+
+func (c *RateLimitConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	var errors []string
+	if c.Backend != RateLimitMemory && c.Backend != RateLimitPostgres {
+		errors = append(errors, fmt.Sprintf("unknown backend %q", c.Backend))
+	}
+	if err := c.Default.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("default: %s", err))
+	}
+	for route, rule := range c.Routes {
+		if err := rule.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("route %q: %s", route, err))
+		}
+	}
+	for principal, rule := range c.Principals {
+		if err := rule.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("principal %q: %s", principal, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%s", strings.Join(errors, ", "))
+	}
+
+	return nil
+}
+
+func (r *RateLimitRule) Validate() error {
+	if r.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if r.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+
+	return nil
+}
+
+func (c *TracingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	var errors []string
+	switch c.Exporter {
+	case TracingExporterStdout:
+	case TracingExporterOTLP:
+		if c.OTLP.Protocol != OTLPProtocolGRPC && c.OTLP.Protocol != OTLPProtocolHTTP {
+			errors = append(errors, fmt.Sprintf("unknown otlp protocol %q", c.OTLP.Protocol))
+		}
+		if c.OTLP.Endpoint == "" {
+			errors = append(errors, "otlp.endpoint is required for the otlp exporter")
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("unknown exporter %q", c.Exporter))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errors = append(errors, "sample_ratio must be between 0 and 1")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%s", strings.Join(errors, ", "))
+	}
+
+	return nil
+}
+
 func (c *LoggerConfig) Validate() error {
 	if c.Level == "" {
 		return fmt.Errorf("level is required")
@@ -167,3 +548,171 @@ func (c *LoggerConfig) Validate() error {
 
 	return nil
 }
+
+func (c *WebhookConfig) setDefaults() {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 20
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.RetryBaseDelay <= 0 {
+		c.RetryBaseDelay = 30 * time.Second
+	}
+	if c.RetryMaxDelay <= 0 {
+		c.RetryMaxDelay = 6 * time.Hour
+	}
+}
+
+func (c *OutboxConfig) setDefaults() {
+	if len(c.Sinks) == 0 {
+		c.Sinks = []string{SinkWebhook}
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.RetryBaseDelay <= 0 {
+		c.RetryBaseDelay = 5 * time.Second
+	}
+	if c.RetryMaxDelay <= 0 {
+		c.RetryMaxDelay = 10 * time.Minute
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+	if c.EndingSoonDays <= 0 {
+		c.EndingSoonDays = 7
+	}
+	if c.EndingSoonInterval <= 0 {
+		c.EndingSoonInterval = time.Hour
+	}
+	if c.NATS.SubjectPrefix == "" {
+		c.NATS.SubjectPrefix = "subscriptions"
+	}
+	if c.Kafka.Topic == "" {
+		c.Kafka.Topic = "subscriptions.events"
+	}
+}
+
+func (c *AuthConfig) setDefaults() {
+	if c.JWKSRefresh <= 0 {
+		c.JWKSRefresh = time.Hour
+	}
+	if c.Leeway <= 0 {
+		c.Leeway = 30 * time.Second
+	}
+	if c.AdminRole == "" {
+		c.AdminRole = "admin"
+	}
+	if c.SupportRole == "" {
+		c.SupportRole = "support"
+	}
+}
+
+func (c *TenantConfig) setDefaults() {
+	if c.Header == "" {
+		c.Header = "X-Organization-ID"
+	}
+}
+
+func (c *RateLimitConfig) setDefaults() {
+	if c.Backend == "" {
+		c.Backend = RateLimitMemory
+	}
+	if c.Default.Rate <= 0 {
+		c.Default.Rate = 10
+	}
+	if c.Default.Burst <= 0 {
+		c.Default.Burst = 20
+	}
+}
+
+func (c *TracingConfig) setDefaults() {
+	if c.Exporter == "" {
+		c.Exporter = TracingExporterOTLP
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = "subscriptions"
+	}
+	if c.SampleRatio <= 0 {
+		c.SampleRatio = 1
+	}
+	if c.OTLP.Protocol == "" {
+		c.OTLP.Protocol = OTLPProtocolGRPC
+	}
+}
+
+func (c *GraphQLConfig) setDefaults() {
+	if c.ComplexityLimit <= 0 {
+		c.ComplexityLimit = 1000
+	}
+	if c.MaxDepth <= 0 {
+		c.MaxDepth = 15
+	}
+}
+
+func (c *HealthConfig) setDefaults() {
+	if c.CheckTimeout <= 0 {
+		c.CheckTimeout = 2 * time.Second
+	}
+}
+
+// setDefaults нулевая задержка допустима: без балансировщика ждать некого
+func (c *ShutdownConfig) setDefaults() {
+	if c.DrainDelay < 0 {
+		c.DrainDelay = 0
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+}
+
+func (c *EventsConfig) setDefaults() {
+	if c.Retention <= 0 {
+		c.Retention = time.Hour
+	}
+	if c.Heartbeat <= 0 {
+		c.Heartbeat = 15 * time.Second
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 64
+	}
+}
+
+func (c *OutboxConfig) Validate() error {
+	var errors []string
+
+	for _, sink := range c.Sinks {
+		switch sink {
+		case SinkWebhook, SinkStdout:
+		case SinkNATS:
+			if c.NATS.URL == "" {
+				errors = append(errors, "nats.url is required for the nats sink")
+			}
+		case SinkKafka:
+			if len(c.Kafka.Brokers) == 0 {
+				errors = append(errors, "kafka.brokers is required for the kafka sink")
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("unknown sink %q", sink))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%s", strings.Join(errors, ", "))
+	}
+
+	return nil
+}
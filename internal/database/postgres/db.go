@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,8 +19,13 @@ func NewDB(pool *pgxpool.Pool) *DB {
 
 func GetConnection(cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
 	ctx := context.Background()
-	dsn := cfg.DSN()
-	pool, err := pgxpool.New(ctx, dsn)
+	poolCfg, err := pgxpool.ParseConfig(cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("error parsing database config: %w", err)
+	}
+	poolCfg.ConnConfig.Tracer = NewQueryTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
@@ -31,6 +37,50 @@ func GetConnection(cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+func (db *DB) Ping(ctx context.Context) error {
+	return db.pool.Ping(ctx)
+}
+
+// MigrationVersion текущая версия миграций по правилам goose: журнал просматривается
+// от новых записей к старым, версия, последняя запись которой — откат, пропускается
+func (db *DB) MigrationVersion(ctx context.Context) (int64, error) {
+	rows, err := db.pool.Query(ctx, `SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC`)
+	if err != nil {
+		return 0, fmt.Errorf("error getting migration version: %w", err)
+	}
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByPos[migrationRecord])
+	if err != nil {
+		return 0, fmt.Errorf("error getting migration version: %w", err)
+	}
+
+	return currentMigrationVersion(records), nil
+}
+
+// migrationRecord строка журнала goose_db_version
+type migrationRecord struct {
+	VersionID int64
+	IsApplied bool
+}
+
+// currentMigrationVersion последняя примененная версия из записей журнала, упорядоченных
+// от новых к старым. Откат миграции goose удаляет ее запись, а старые версии goose добавляют
+// строку с is_applied = false, поэтому версия применена, только если ее последняя запись —
+// применение.
+func currentMigrationVersion(records []migrationRecord) int64 {
+	rolledBack := make(map[int64]bool)
+	for _, record := range records {
+		if rolledBack[record.VersionID] {
+			continue
+		}
+		if record.IsApplied {
+			return record.VersionID
+		}
+		rolledBack[record.VersionID] = true
+	}
+
+	return 0
+}
+
 func (db *DB) Close() {
 	db.pool.Close()
 }
@@ -0,0 +1,63 @@
+package postgres
+
+import "testing"
+
+func TestCurrentMigrationVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []migrationRecord
+		want    int64
+	}{
+		{name: "empty journal"},
+		{name: "initial row", records: []migrationRecord{{VersionID: 0, IsApplied: true}}},
+		{
+			name: "applied in order",
+			records: []migrationRecord{
+				{VersionID: 20261019210000, IsApplied: true},
+				{VersionID: 20250101000000, IsApplied: true},
+				{VersionID: 0, IsApplied: true},
+			},
+			want: 20261019210000,
+		},
+		{
+			name: "latest rolled back",
+			records: []migrationRecord{
+				{VersionID: 20261019210000, IsApplied: false},
+				{VersionID: 20261019210000, IsApplied: true},
+				{VersionID: 20250101000000, IsApplied: true},
+			},
+			want: 20250101000000,
+		},
+		{
+			name: "rolled back and applied again",
+			records: []migrationRecord{
+				{VersionID: 20261019210000, IsApplied: true},
+				{VersionID: 20261019210000, IsApplied: false},
+				{VersionID: 20261019210000, IsApplied: true},
+			},
+			want: 20261019210000,
+		},
+		{
+			// MAX(version_id) здесь вернул бы откаченную миграцию, примененную вне очереди
+			name: "out of order migration rolled back",
+			records: []migrationRecord{
+				{VersionID: 20261019210000, IsApplied: false},
+				{VersionID: 20250101000000, IsApplied: true},
+				{VersionID: 20261019210000, IsApplied: true},
+			},
+			want: 20250101000000,
+		},
+		{
+			name:    "everything rolled back",
+			records: []migrationRecord{{VersionID: 20250101000000, IsApplied: false}, {VersionID: 20250101000000, IsApplied: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := currentMigrationVersion(tt.records); got != tt.want {
+				t.Errorf("currentMigrationVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
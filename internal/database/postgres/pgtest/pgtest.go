@@ -0,0 +1,112 @@
+// Package pgtest подключает тесты к PostgreSQL из TEST_DATABASE_URL
+package pgtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Pool подключается к базе из TEST_DATABASE_URL и применяет миграции в отдельной
+// схеме, которая удаляется после теста. Без TEST_DATABASE_URL тест пропускается.
+func Pool(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(ctx, url)
+		if err != nil {
+			t.Errorf("connect: %v", err)
+			return
+		}
+		defer conn.Close(ctx)
+		if _, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir(), "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("find migrations: %v", err)
+	}
+	slices.Sort(files)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		// без аргументов pgx выполняет запрос простым протоколом, поэтому раздел Up
+		// с несколькими командами отправляется целиком
+		if _, err := pool.Exec(ctx, migrationUp(string(content))); err != nil {
+			t.Fatalf("apply %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return pool
+}
+
+// migrationsDir каталог migrations в корне репозитория, независимо от пакета теста
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "migrations")
+}
+
+// migrationUp возвращает раздел Up миграции goose
+func migrationUp(content string) string {
+	_, up, _ := strings.Cut(content, "-- +goose Up")
+	up, _, _ = strings.Cut(up, "-- +goose Down")
+
+	return up
+}
+
+// AllTenants выполняет fn в транзакции без ограничения политик Row-Level Security,
+// чтобы тест мог подготовить и проверить данные любой организации
+func AllTenants(t testing.TB, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) {
+	t.Helper()
+	ctx := context.Background()
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT set_config('app.all_tenants', 'on', true)`); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+	if err != nil {
+		t.Fatalf("all tenants transaction: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyColumns = `id, organization_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at`
+
+// lastUsedResolution с какой точностью обновляется last_used_at: запись на каждый
+// запрос пакетной задачи не нужна
+const lastUsedResolution = time.Minute
+
+type APIKey struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewAPIKey(pool *pgxpool.Pool, baseLogger *slog.Logger) *APIKey {
+	logger := baseLogger.WithGroup("api key repository")
+
+	return &APIKey{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// CreateAPIKey выпускает ключ в организации запроса: ключ действует только в ней
+func (a *APIKey) CreateAPIKey(ctx context.Context, key *domain.APIKey, keyHash string) error {
+	defer metrics.ObserveRepository("api_key", "CreateAPIKey", time.Now())
+
+	return inTenantTx(ctx, a.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		key.OrganizationID = organizationID
+		query := `INSERT INTO api_keys (id, organization_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING created_at, updated_at`
+
+		return tx.QueryRow(ctx, query, key.UUID, key.OrganizationID, key.Name, key.Prefix, keyHash, key.Scopes, key.ExpiresAt).
+			Scan(&key.CreatedAt, &key.UpdatedAt)
+	})
+}
+
+func (a *APIKey) GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	defer metrics.ObserveRepository("api_key", "GetAPIKey", time.Now())
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1 AND organization_id = $2`
+	return a.queryAPIKey(ctx, query, id)
+}
+
+func (a *APIKey) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	defer metrics.ObserveRepository("api_key", "ListAPIKeys", time.Now())
+
+	var keys []*domain.APIKey
+	err := inTenantTx(ctx, a.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE organization_id = $1 ORDER BY created_at`
+		rows, err := tx.Query(ctx, query, organizationID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			key, err := scanAPIKey(rows)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+
+		return rows.Err()
+	})
+
+	return keys, err
+}
+
+// RotateAPIKey заменяет хеш ключа. Прежний хеш остается действительным еще grace.
+// Отозванный ключ ротировать нельзя.
+func (a *APIKey) RotateAPIKey(ctx context.Context, id uuid.UUID, prefix, keyHash string, grace time.Duration) (*domain.APIKey, error) {
+	defer metrics.ObserveRepository("api_key", "RotateAPIKey", time.Now())
+
+	query := `UPDATE api_keys SET previous_key_hash = key_hash, previous_expires_at = NOW() + $5::interval,
+			prefix = $3, key_hash = $4, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND revoked_at IS NULL RETURNING ` + apiKeyColumns
+	return a.queryAPIKey(ctx, query, id, prefix, keyHash, grace)
+}
+
+// RevokeAPIKey отзывает ключ вместе с прежним после ротации. Повторный отзыв не меняет время отзыва.
+func (a *APIKey) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	defer metrics.ObserveRepository("api_key", "RevokeAPIKey", time.Now())
+
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()), updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 RETURNING ` + apiKeyColumns
+	return a.queryAPIKey(ctx, query, id)
+}
+
+// FindActiveAPIKey ищет действующий ключ по хешу, в том числе прежний ключ в период ротации.
+// Вызывается при аутентификации, до определения организации, поэтому ищет во всех.
+func (a *APIKey) FindActiveAPIKey(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	defer metrics.ObserveRepository("api_key", "FindActiveAPIKey", time.Now())
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE (key_hash = $1 OR (previous_key_hash = $1 AND previous_expires_at > NOW()))
+			AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+	var key *domain.APIKey
+	err := inAllTenantsTx(ctx, a.pool, func(tx pgx.Tx) error {
+		var err error
+		key, err = scanAPIKey(tx.QueryRow(ctx, query, keyHash))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return key, err
+}
+
+func (a *APIKey) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveRepository("api_key", "TouchAPIKey", time.Now())
+
+	query := `UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - $2::interval)`
+	return inAllTenantsTx(ctx, a.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, id, lastUsedResolution)
+		return err
+	})
+}
+
+// queryAPIKey выполняет запрос одного ключа организации запроса: id и организация
+// передаются первыми двумя параметрами
+func (a *APIKey) queryAPIKey(ctx context.Context, query string, id uuid.UUID, args ...any) (*domain.APIKey, error) {
+	var key *domain.APIKey
+	err := inTenantTx(ctx, a.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		var err error
+		key, err = scanAPIKey(tx.QueryRow(ctx, query, append([]any{id, organizationID}, args...)...))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return key, err
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(&key.UUID, &key.OrganizationID, &key.Name, &key.Prefix, &key.Scopes, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt,
+		&key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const auditColumns = `id, organization_id, subscription_id, user_id, action, actor_user_id, actor_api_key_id,
+	request_id, source_ip, before, after, diff, occurred_at`
+
+// Audit читает журнал изменений подписок. Записи добавляет репозиторий подписок
+// в транзакции самого изменения, см. insertAuditRecords.
+type Audit struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewAudit(pool *pgxpool.Pool, baseLogger *slog.Logger) *Audit {
+	logger := baseLogger.WithGroup("audit repository")
+
+	return &Audit{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// SearchAudit возвращает записи организации запроса от новых к старым
+func (a *Audit) SearchAudit(ctx context.Context, params *domain.SearchAuditParams) (*domain.AuditPage, error) {
+	defer metrics.ObserveRepository("audit", "SearchAudit", time.Now())
+
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter filterBuilder
+	filter.add("organization_id = ?", organizationID)
+	if params.Cursor != "" {
+		afterID, err := strconv.ParseInt(params.Cursor, 10, 64)
+		if err != nil || afterID <= 0 {
+			return nil, domain.ErrInvalidAuditCursor
+		}
+		filter.add("id < ?", afterID)
+	}
+	applyAuditFilter(&filter, &params.Filter)
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log` + filter.where() +
+		` ORDER BY id DESC LIMIT ` + filter.arg(params.Limit+1)
+
+	tx, err := beginTenantTx(ctx, a.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]*domain.AuditRecord, 0, params.Limit+1)
+	for rows.Next() {
+		record, err := scanAuditRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.AuditPage{Records: records}
+	if len(records) > params.Limit {
+		page.Records = records[:params.Limit]
+		page.NextCursor = strconv.FormatInt(page.Records[params.Limit-1].ID, 10)
+	}
+
+	return page, tx.Commit(ctx)
+}
+
+func applyAuditFilter(filter *filterBuilder, auditFilter *domain.AuditFilter) {
+	if auditFilter.SubscriptionUUID != nil {
+		filter.add("subscription_id = ?", *auditFilter.SubscriptionUUID)
+	}
+	if auditFilter.UserUUID != nil {
+		filter.add("user_id = ?", *auditFilter.UserUUID)
+	}
+	if auditFilter.ActorUUID != nil {
+		q := filter.arg(*auditFilter.ActorUUID)
+		filter.add("actor_user_id = " + q + " OR actor_api_key_id = " + q)
+	}
+	if auditFilter.Action != nil {
+		filter.add("action = ?", string(*auditFilter.Action))
+	}
+	if auditFilter.RequestID != nil {
+		filter.add("request_id = ?", *auditFilter.RequestID)
+	}
+	if auditFilter.From != nil {
+		filter.add("occurred_at >= ?", *auditFilter.From)
+	}
+	if auditFilter.To != nil {
+		filter.add("occurred_at < ?", *auditFilter.To)
+	}
+}
+
+// insertAuditRecords записывает изменения в журнал через переданный querier, чтобы запись
+// попала в ту же транзакцию, что и изменение подписки. Вызывающий, request id и адрес
+// клиента берутся из контекста запроса.
+func insertAuditRecords(ctx context.Context, db querier, records ...*domain.AuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var actorUser, actorAPIKey *uuid.UUID
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		if principal.APIKeyUUID != nil {
+			actorAPIKey = principal.APIKeyUUID
+		} else {
+			actorUser = &principal.Subject
+		}
+	}
+	requestID, _ := ctx.Value(middleware.RequestIDKey).(string)
+	sourceIP, _ := ctx.Value(middleware.ClientIPKey).(string)
+
+	columns := []string{"organization_id", "subscription_id", "user_id", "action", "actor_user_id", "actor_api_key_id",
+		"request_id", "source_ip", "before", "after", "diff", "occurred_at"}
+	rows := make([][]any, 0, len(records))
+	for _, record := range records {
+		record.ActorUserUUID, record.ActorAPIKeyUUID = actorUser, actorAPIKey
+		record.RequestID, record.SourceIP = requestID, sourceIP
+
+		before, err := marshalAuditJSON(record.Before)
+		if err != nil {
+			return err
+		}
+		after, err := marshalAuditJSON(record.After)
+		if err != nil {
+			return err
+		}
+		diff, err := json.Marshal(record.Diff)
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, []any{record.OrganizationID, record.SubscriptionUUID, record.UserUUID, string(record.Action),
+			record.ActorUserUUID, record.ActorAPIKeyUUID, record.RequestID, record.SourceIP, before, after, diff, record.OccurredAt})
+	}
+
+	if len(rows) == 1 {
+		_, err := db.Exec(ctx, `INSERT INTO audit_log (organization_id, subscription_id, user_id, action, actor_user_id,
+			actor_api_key_id, request_id, source_ip, before, after, diff, occurred_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`, rows[0]...)
+		return err
+	}
+
+	_, err := db.CopyFrom(ctx, pgx.Identifier{"audit_log"}, columns, pgx.CopyFromRows(rows))
+	return err
+}
+
+// marshalAuditJSON пустой снимок хранится как NULL, а не как JSON null
+func marshalAuditJSON(snapshot map[string]any) ([]byte, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(snapshot)
+}
+
+func scanAuditRecord(row pgx.Row) (*domain.AuditRecord, error) {
+	var record domain.AuditRecord
+	var action string
+	var before, after, diff []byte
+	err := row.Scan(&record.ID, &record.OrganizationID, &record.SubscriptionUUID, &record.UserUUID, &action,
+		&record.ActorUserUUID, &record.ActorAPIKeyUUID, &record.RequestID, &record.SourceIP, &before, &after, &diff,
+		&record.OccurredAt)
+	if err != nil {
+		return nil, err
+	}
+	record.Action = domain.AuditAction(action)
+
+	for _, field := range []struct {
+		data []byte
+		dest any
+	}{{before, &record.Before}, {after, &record.After}, {diff, &record.Diff}} {
+		if len(field.data) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field.data, field.dest); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
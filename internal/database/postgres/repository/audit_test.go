@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/pgtest"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TestAuditLogRecordsChanges каждое изменение подписки записывается в журнал той же
+// транзакцией, удаленная подписка восстанавливается из записи об удалении
+func TestAuditLogRecordsChanges(t *testing.T) {
+	pool := pgtest.Pool(t)
+	logger := slog.New(slog.DiscardHandler)
+	subscriptions := NewSubscription(pool, logger)
+	audit := NewAudit(pool, logger)
+
+	actor := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	ctx = context.WithValue(ctx, middleware.ClientIPKey, "203.0.113.7")
+	ctx = domain.WithPrincipal(domain.WithTenant(ctx, uuid.New()), &domain.Principal{Subject: actor, Roles: []domain.Role{domain.RoleAdmin}})
+
+	subscription := &domain.Subscription{
+		UUID:        uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserUUID:    uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Notes:       "family plan",
+	}
+	if err := subscriptions.CreateSubscription(ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+	if _, err := subscriptions.GetDeletedSubscription(ctx, subscription.UUID); !errors.Is(err, domain.ErrSubscriptionNotDeleted) {
+		t.Errorf("GetDeletedSubscription() of an existing subscription error = %v, want ErrSubscriptionNotDeleted", err)
+	}
+
+	params := &domain.UpdateSubscriptionParams{ServiceName: "Netflix", Price: 700, Notes: "family plan"}
+	if _, err := subscriptions.UpdateSubscription(ctx, subscription.UUID, params, nil); err != nil {
+		t.Fatalf("UpdateSubscription() error = %v", err)
+	}
+	if _, err := subscriptions.DeleteSubscription(ctx, subscription.UUID, nil); err != nil {
+		t.Fatalf("DeleteSubscription() error = %v", err)
+	}
+
+	deleted, err := subscriptions.GetDeletedSubscription(ctx, subscription.UUID)
+	if err != nil {
+		t.Fatalf("GetDeletedSubscription() error = %v", err)
+	}
+	if deleted.UUID != subscription.UUID || deleted.Price != 700 || deleted.ServiceName != "Netflix" || deleted.UserUUID != subscription.UserUUID {
+		t.Errorf("GetDeletedSubscription() = %+v, want the state before deletion", deleted)
+	}
+	if err := subscriptions.RestoreSubscription(ctx, deleted); err != nil {
+		t.Fatalf("RestoreSubscription() error = %v", err)
+	}
+	if err := subscriptions.RestoreSubscription(ctx, deleted); !errors.Is(err, domain.ErrSubscriptionNotDeleted) {
+		t.Errorf("repeated RestoreSubscription() error = %v, want ErrSubscriptionNotDeleted", err)
+	}
+
+	page, err := audit.SearchAudit(ctx, &domain.SearchAuditParams{
+		Filter: domain.AuditFilter{SubscriptionUUID: &subscription.UUID},
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatalf("SearchAudit() error = %v", err)
+	}
+	wantActions := []domain.AuditAction{domain.AuditActionRestore, domain.AuditActionDelete, domain.AuditActionUpdate, domain.AuditActionCreate}
+	if len(page.Records) != len(wantActions) {
+		t.Fatalf("SearchAudit() returned %d records, want %d", len(page.Records), len(wantActions))
+	}
+	for i, record := range page.Records {
+		if record.Action != wantActions[i] {
+			t.Errorf("record %d action = %s, want %s", i, record.Action, wantActions[i])
+		}
+		if record.RequestID != "req-1" || record.SourceIP != "203.0.113.7" || record.ActorUserUUID == nil || *record.ActorUserUUID != actor {
+			t.Errorf("record %d = request %q, ip %q, actor %v; want the request context", i, record.RequestID, record.SourceIP, record.ActorUserUUID)
+		}
+	}
+	if change := page.Records[2].Diff["price"]; change.From != float64(500) || change.To != float64(700) {
+		t.Errorf("update diff price = %+v, want 500 -> 700", change)
+	}
+}
+
+// TestAuditLogAppendOnly триггеры запрещают изменять, удалять и очищать записи журнала
+func TestAuditLogAppendOnly(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	ctx = domain.WithTenant(ctx, uuid.New())
+
+	subscription := &domain.Subscription{
+		UUID:      uuid.New(),
+		Price:     500,
+		UserUUID:  uuid.New(),
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := NewSubscription(pool, slog.New(slog.DiscardHandler)).CreateSubscription(ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "update", query: `UPDATE audit_log SET request_id = 'forged'`},
+		{name: "delete", query: `DELETE FROM audit_log`},
+		{name: "truncate", query: `TRUNCATE audit_log`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, `SELECT set_config('app.all_tenants', 'on', true)`); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, tt.query)
+				return err
+			})
+			if err == nil || !strings.Contains(err.Error(), "append-only") {
+				t.Errorf("%s error = %v, want the append-only trigger to reject it", tt.query, err)
+			}
+		})
+	}
+
+	var records int
+	pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log WHERE subscription_id = $1 AND request_id = 'req-1'`, subscription.UUID).
+			Scan(&records)
+	})
+	if records != 1 {
+		t.Errorf("audit_log has %d original records, want 1", records)
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CalendarToken struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewCalendarToken(pool *pgxpool.Pool, baseLogger *slog.Logger) *CalendarToken {
+	logger := baseLogger.WithGroup("calendar token repository")
+
+	return &CalendarToken{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// SaveTokenHash сохраняет хеш токена пользователя в организации запроса, заменяя предыдущий
+// токен этой организации. Лента по токену показывает подписки организации, в которой он выпущен.
+func (c *CalendarToken) SaveTokenHash(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	defer metrics.ObserveRepository("calendar_token", "SaveTokenHash", time.Now())
+
+	query := `INSERT INTO calendar_feed_tokens (organization_id, user_id, token_hash) VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()`
+
+	return inTenantTx(ctx, c.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		_, err := tx.Exec(ctx, query, organizationID, userID, tokenHash)
+		return err
+	})
+}
+
+// FindTokenOrganization возвращает организацию, в которой пользователю выпущен токен с таким
+// хешем. Запрос ленты приходит без организации, поэтому токен ищется во всех организациях.
+func (c *CalendarToken) FindTokenOrganization(ctx context.Context, userID uuid.UUID, tokenHash string) (uuid.UUID, error) {
+	defer metrics.ObserveRepository("calendar_token", "FindTokenOrganization", time.Now())
+
+	var organizationID uuid.UUID
+	query := `SELECT organization_id FROM calendar_feed_tokens WHERE user_id = $1 AND token_hash = $2`
+	err := inAllTenantsTx(ctx, c.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, userID, tokenHash).Scan(&organizationID)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, domain.ErrInvalidFeedToken
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return organizationID, nil
+}
+
+// DeleteToken отзывает токен пользователя в организации запроса
+func (c *CalendarToken) DeleteToken(ctx context.Context, userID uuid.UUID) error {
+	defer metrics.ObserveRepository("calendar_token", "DeleteToken", time.Now())
+
+	return inTenantTx(ctx, c.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		_, err := tx.Exec(ctx, `DELETE FROM calendar_feed_tokens WHERE organization_id = $1 AND user_id = $2`, organizationID, userID)
+		return err
+	})
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const candidateColumns = `id, user_id, merchant, service_name, price, charge_amount, period, start_date, end_date,
+	occurrences, last_charge_date, confidence, subscription_id, created_at`
+
+type SubscriptionCandidate struct {
+	db     querier
+	logger *slog.Logger
+}
+
+func NewSubscriptionCandidate(pool *pgxpool.Pool, baseLogger *slog.Logger) *SubscriptionCandidate {
+	logger := baseLogger.WithGroup("subscription candidate repository")
+
+	return &SubscriptionCandidate{
+		db:     pool,
+		logger: logger,
+	}
+}
+
+// InTx возвращает репозиторий, запросы которого идут через транзакцию repo, полученного
+// в Subscription.WithTx: кандидаты меняются атомарно вместе с подписками
+func (s *SubscriptionCandidate) InTx(repo *Subscription) *SubscriptionCandidate {
+	txRepo := *s
+	txRepo.db = repo.db
+
+	return &txRepo
+}
+
+// ReplacePendingCandidates заменяет неподтвержденных кандидатов пользователя
+// результатом разбора новой выписки. Подтвержденные кандидаты сохраняются.
+func (s *SubscriptionCandidate) ReplacePendingCandidates(ctx context.Context, userID uuid.UUID, candidates []*domain.SubscriptionCandidate) error {
+	defer metrics.ObserveRepository("subscription_candidate", "ReplacePendingCandidates", time.Now())
+
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginTenantTx(ctx, s.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM subscription_candidates WHERE user_id = $1 AND organization_id = $2 AND subscription_id IS NULL`,
+		userID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for _, c := range candidates {
+		batch.Queue(`INSERT INTO subscription_candidates (id, organization_id, user_id, merchant, service_name, price, charge_amount, period,
+			start_date, end_date, occurrences, last_charge_date, confidence)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING created_at`,
+			c.UUID, organizationID, c.UserUUID, c.Merchant, c.ServiceName, c.Price, c.ChargeAmount, string(c.Period),
+			c.StartDate, c.EndDate, c.Occurrences, c.LastChargeDate, c.Confidence,
+		).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&c.CreatedAt)
+		})
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListPendingCandidates возвращает неподтвержденных кандидатов пользователя
+func (s *SubscriptionCandidate) ListPendingCandidates(ctx context.Context, userID uuid.UUID) ([]*domain.SubscriptionCandidate, error) {
+	defer metrics.ObserveRepository("subscription_candidate", "ListPendingCandidates", time.Now())
+
+	candidates := make([]*domain.SubscriptionCandidate, 0)
+	err := inTenantTx(ctx, s.db, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT ` + candidateColumns + ` FROM subscription_candidates
+			WHERE user_id = $1 AND organization_id = $2 AND subscription_id IS NULL
+			ORDER BY confidence DESC, service_name`
+		rows, err := tx.Query(ctx, query, userID, organizationID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			candidate, err := scanCandidate(rows)
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, candidate)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// GetPendingCandidate возвращает неподтвержденного кандидата пользователя и блокирует его
+// до конца транзакции: внутри InTx параллельное подтверждение того же кандидата ждет ее
+// завершения и после фиксации кандидата уже не находит
+func (s *SubscriptionCandidate) GetPendingCandidate(ctx context.Context, userID, candidateID uuid.UUID) (*domain.SubscriptionCandidate, error) {
+	defer metrics.ObserveRepository("subscription_candidate", "GetPendingCandidate", time.Now())
+
+	var candidate *domain.SubscriptionCandidate
+	err := inTenantTx(ctx, s.db, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT ` + candidateColumns + ` FROM subscription_candidates
+			WHERE id = $1 AND user_id = $2 AND organization_id = $3 AND subscription_id IS NULL
+			FOR UPDATE`
+		var err error
+		candidate, err = scanCandidate(tx.QueryRow(ctx, query, candidateID, userID, organizationID))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCandidateNotFound
+	}
+
+	return candidate, err
+}
+
+// MarkConfirmed связывает кандидата с созданной по нему подпиской
+func (s *SubscriptionCandidate) MarkConfirmed(ctx context.Context, candidateID, subscriptionID uuid.UUID) error {
+	defer metrics.ObserveRepository("subscription_candidate", "MarkConfirmed", time.Now())
+
+	return inTenantTx(ctx, s.db, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `UPDATE subscription_candidates SET subscription_id = $2
+			WHERE id = $1 AND organization_id = $3 AND subscription_id IS NULL`
+		tag, err := tx.Exec(ctx, query, candidateID, subscriptionID, organizationID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrCandidateNotFound
+		}
+
+		return nil
+	})
+}
+
+// ConfirmedPeriods возвращает периоды повторения кандидатов пользователя, подтвержденных
+// в подписки, по id созданной подписки
+func (s *SubscriptionCandidate) ConfirmedPeriods(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]domain.RecurrencePeriod, error) {
+	defer metrics.ObserveRepository("subscription_candidate", "ConfirmedPeriods", time.Now())
+
+	periods := make(map[uuid.UUID]domain.RecurrencePeriod)
+	err := inTenantTx(ctx, s.db, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT subscription_id, period FROM subscription_candidates
+			WHERE user_id = $1 AND organization_id = $2 AND subscription_id IS NOT NULL`
+		rows, err := tx.Query(ctx, query, userID, organizationID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var subscriptionID uuid.UUID
+			var period string
+			if err := rows.Scan(&subscriptionID, &period); err != nil {
+				return err
+			}
+			periods[subscriptionID] = domain.RecurrencePeriod(period)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// DeleteCandidate отклоняет неподтвержденного кандидата
+func (s *SubscriptionCandidate) DeleteCandidate(ctx context.Context, userID, candidateID uuid.UUID) error {
+	defer metrics.ObserveRepository("subscription_candidate", "DeleteCandidate", time.Now())
+
+	return inTenantTx(ctx, s.db, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `DELETE FROM subscription_candidates
+			WHERE id = $1 AND user_id = $2 AND organization_id = $3 AND subscription_id IS NULL`
+		tag, err := tx.Exec(ctx, query, candidateID, userID, organizationID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrCandidateNotFound
+		}
+
+		return nil
+	})
+}
+
+func scanCandidate(row pgx.Row) (*domain.SubscriptionCandidate, error) {
+	var candidate domain.SubscriptionCandidate
+	var period string
+	err := row.Scan(
+		&candidate.UUID,
+		&candidate.UserUUID,
+		&candidate.Merchant,
+		&candidate.ServiceName,
+		&candidate.Price,
+		&candidate.ChargeAmount,
+		&period,
+		&candidate.StartDate,
+		&candidate.EndDate,
+		&candidate.Occurrences,
+		&candidate.LastChargeDate,
+		&candidate.Confidence,
+		&candidate.SubscriptionUUID,
+		&candidate.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	candidate.Period = domain.RecurrencePeriod(period)
+
+	return &candidate, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const changesChannel = "subscription_changes"
+
+// SubscriptionChange читает изменения подписок, которые записывает триггер на таблице subscriptions
+type SubscriptionChange struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewSubscriptionChange(pool *pgxpool.Pool, baseLogger *slog.Logger) *SubscriptionChange {
+	logger := baseLogger.WithGroup("subscription change repository")
+
+	return &SubscriptionChange{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// ListChangesAfter возвращает до limit изменений с id больше afterID по возрастанию id.
+// Поток изменений общий для всех организаций, поэтому организация задается фильтром.
+func (s *SubscriptionChange) ListChangesAfter(ctx context.Context, afterID int64, filter *domain.ChangeFilter, limit int) ([]*domain.SubscriptionChange, error) {
+	defer metrics.ObserveRepository("subscription_change", "ListChangesAfter", time.Now())
+
+	query := `SELECT subscription_id, organization_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at,
+			id, event_type, occurred_at
+		FROM subscription_changes
+		WHERE id > $1
+			AND ($2::uuid IS NULL OR user_id = $2)
+			AND ($3::text IS NULL OR service_name ILIKE $3)
+			AND ($5::uuid IS NULL OR organization_id = $5)
+		ORDER BY id
+		LIMIT $4`
+
+	var filterUser, filterService, filterOrganization any
+	if filter != nil {
+		if filter.OrganizationID != nil {
+			filterOrganization = *filter.OrganizationID
+		}
+		if filter.UserUUID != nil {
+			filterUser = *filter.UserUUID
+		}
+		if filter.ServiceName != nil {
+			filterService = *filter.ServiceName
+		}
+	}
+
+	var changes []*domain.SubscriptionChange
+	err := inAllTenantsTx(ctx, s.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, afterID, filterUser, filterService, limit, filterOrganization)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var change domain.SubscriptionChange
+			var eventType string
+			change.Subscription, err = scanSubscription(rows, &change.ID, &eventType, &change.OccurredAt)
+			if err != nil {
+				return err
+			}
+			change.Type = domain.EventType(eventType)
+			changes = append(changes, &change)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// LastChangeID id последнего записанного изменения, 0 если изменений нет
+func (s *SubscriptionChange) LastChangeID(ctx context.Context) (int64, error) {
+	defer metrics.ObserveRepository("subscription_change", "LastChangeID", time.Now())
+
+	var id int64
+	err := inAllTenantsTx(ctx, s.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM subscription_changes`).Scan(&id)
+	})
+
+	return id, err
+}
+
+// DeleteChangesBefore удаляет изменения старше before
+func (s *SubscriptionChange) DeleteChangesBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveRepository("subscription_change", "DeleteChangesBefore", time.Now())
+
+	var deleted int64
+	err := inAllTenantsTx(ctx, s.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM subscription_changes WHERE occurred_at < $1`, before)
+		deleted = tag.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+// Listen подписывается на уведомления о новых изменениях подписок
+func (s *SubscriptionChange) Listen(ctx context.Context) (*Listener, error) {
+	return listen(ctx, s.pool, changesChannel)
+}
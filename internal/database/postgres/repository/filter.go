@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+// filterBuilder собирает условие WHERE из фрагментов SQL. Значения никогда не
+// подставляются в текст запроса: каждый "?" во фрагменте заменяется на
+// очередной позиционный параметр $n, а само значение уходит в args.
+type filterBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (b *filterBuilder) add(condition string, values ...any) {
+	for _, value := range values {
+		condition = strings.Replace(condition, "?", b.arg(value), 1)
+	}
+
+	b.conditions = append(b.conditions, condition)
+}
+
+// arg регистрирует значение и возвращает его плейсхолдер, чтобы один параметр
+// можно было использовать в условии несколько раз.
+func (b *filterBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *filterBuilder) applyFilter(filter *domain.SubscriptionFilter) {
+	if names := nonEmpty(filter.ServiceNames); len(names) > 0 {
+		switch filter.ServiceNameMatch {
+		case domain.ServiceNameIgnoreCase:
+			b.add("service_name ILIKE ANY(?)", escapeLikePatterns(names))
+		default:
+			b.add("service_name = ANY(?)", names)
+		}
+	}
+	if filter.UserID != nil {
+		b.add("user_id = ?", *filter.UserID)
+	}
+	if len(filter.UserIDs) > 0 {
+		b.add("user_id = ANY(?)", filter.UserIDs)
+	}
+	if filter.PriceMin != nil {
+		b.add("price >= ?", *filter.PriceMin)
+	}
+	if filter.PriceMax != nil {
+		b.add("price <= ?", *filter.PriceMax)
+	}
+	if filter.StartFrom != nil {
+		b.add("start_date >= ?", *filter.StartFrom)
+	}
+	if filter.StartTo != nil {
+		b.add("start_date <= ?", *filter.StartTo)
+	}
+	if filter.EndFrom != nil {
+		b.add("end_date >= ?", *filter.EndFrom)
+	}
+	if filter.EndTo != nil {
+		b.add("end_date <= ?", *filter.EndTo)
+	}
+	if filter.ActiveAt != nil {
+		p := b.arg(*filter.ActiveAt)
+		b.add("start_date <= " + p + " AND (end_date IS NULL OR end_date >= " + p + ")")
+	}
+	if filter.OpenEnded != nil {
+		if *filter.OpenEnded {
+			b.add("end_date IS NULL")
+		} else {
+			b.add("end_date IS NOT NULL")
+		}
+	}
+	if filter.CreatedSince != nil {
+		b.add("created_at >= ?", *filter.CreatedSince)
+	}
+	if filter.UpdatedSince != nil {
+		b.add("update_at >= ?", *filter.UpdatedSince)
+	}
+}
+
+func (b *filterBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE (" + strings.Join(b.conditions, ") AND (") + ")"
+}
+
+func (b *filterBuilder) clone() *filterBuilder {
+	return &filterBuilder{
+		conditions: slices.Clone(b.conditions),
+		args:       slices.Clone(b.args),
+	}
+}
+
+// escapeLikePatterns экранирует символы шаблона LIKE, чтобы ILIKE сравнивал строки
+// целиком без учета регистра. \ — символ экранирования LIKE по умолчанию.
+func escapeLikePatterns(values []string) []string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = replacer.Replace(value)
+	}
+
+	return result
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
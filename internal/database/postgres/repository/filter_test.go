@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestFilterBuilderPlaceholders(t *testing.T) {
+	var b filterBuilder
+	b.add("organization_id = ?", "org")
+	b.add("price >= ? AND price <= ?", 100, 500)
+	p := b.arg("2025-07-01")
+	b.add("start_date <= " + p + " AND (end_date IS NULL OR end_date >= " + p + ")")
+	b.add("end_date IS NULL")
+
+	wantWhere := " WHERE (organization_id = $1) AND (price >= $2 AND price <= $3)" +
+		" AND (start_date <= $4 AND (end_date IS NULL OR end_date >= $4)) AND (end_date IS NULL)"
+	if b.where() != wantWhere {
+		t.Errorf("where() = %q, want %q", b.where(), wantWhere)
+	}
+	if want := []any{"org", 100, 500, "2025-07-01"}; !reflect.DeepEqual(b.args, want) {
+		t.Errorf("args = %#v, want %#v", b.args, want)
+	}
+}
+
+func TestFilterBuilderEmpty(t *testing.T) {
+	var b filterBuilder
+	if b.where() != "" {
+		t.Fatalf("where() = %q, want empty", b.where())
+	}
+}
+
+func TestFilterBuilderClone(t *testing.T) {
+	var b filterBuilder
+	b.add("price = ?", 1)
+
+	clone := b.clone()
+	clone.add("user_id = ?", "u")
+
+	if len(b.conditions) != 1 || len(b.args) != 1 {
+		t.Fatalf("clone changed the original: %v %v", b.conditions, b.args)
+	}
+	if clone.where() != " WHERE (price = $1) AND (user_id = $2)" {
+		t.Fatalf("clone.where() = %q", clone.where())
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	userID := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	month := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	priceMin := 100
+	openEnded := false
+
+	tests := []struct {
+		name      string
+		filter    domain.SubscriptionFilter
+		wantWhere string
+		wantArgs  []any
+	}{
+		{name: "empty", wantWhere: ""},
+		{
+			name:      "exact service names",
+			filter:    domain.SubscriptionFilter{ServiceNames: []string{" Netflix ", "", "Yandex_Plus"}},
+			wantWhere: " WHERE (service_name = ANY($1))",
+			wantArgs:  []any{[]string{"Netflix", "Yandex_Plus"}},
+		},
+		{
+			name: "case-insensitive service names are escaped",
+			filter: domain.SubscriptionFilter{
+				ServiceNames:     []string{"50%_off", `back\slash`},
+				ServiceNameMatch: domain.ServiceNameIgnoreCase,
+			},
+			wantWhere: " WHERE (service_name ILIKE ANY($1))",
+			wantArgs:  []any{[]string{`50\%\_off`, `back\\slash`}},
+		},
+		{
+			name:      "blank service names are ignored",
+			filter:    domain.SubscriptionFilter{ServiceNames: []string{" ", ""}},
+			wantWhere: "",
+		},
+		{
+			name: "several conditions",
+			filter: domain.SubscriptionFilter{
+				UserID:    &userID,
+				PriceMin:  &priceMin,
+				ActiveAt:  &month,
+				OpenEnded: &openEnded,
+			},
+			wantWhere: " WHERE (user_id = $1) AND (price >= $2)" +
+				" AND (start_date <= $3 AND (end_date IS NULL OR end_date >= $3)) AND (end_date IS NOT NULL)",
+			wantArgs: []any{userID, 100, month},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b filterBuilder
+			b.applyFilter(&tt.filter)
+
+			if b.where() != tt.wantWhere {
+				t.Errorf("where() = %q, want %q", b.where(), tt.wantWhere)
+			}
+			if !reflect.DeepEqual(b.args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", b.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestEscapeLikePatterns(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "Netflix", want: "Netflix"},
+		{value: "100%", want: `100\%`},
+		{value: "a_b", want: `a\_b`},
+		{value: `c:\path`, want: `c:\\path`},
+		{value: `\%`, want: `\\\%`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLikePatterns([]string{tt.value})[0]; got != tt.want {
+			t.Errorf("escapeLikePatterns(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
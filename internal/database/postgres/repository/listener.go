@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Listener держит отдельное соединение из пула с LISTEN на канал уведомлений
+type Listener struct {
+	conn *pgxpool.Conn
+}
+
+func listen(ctx context.Context, pool *pgxpool.Pool, channel string) (*Listener, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return &Listener{conn: conn}, nil
+}
+
+// Wait ждет уведомления не дольше timeout. Истечение timeout не считается ошибкой:
+// слушатели в любом случае перечитывают таблицу, уведомление лишь будит их раньше.
+func (l *Listener) Wait(ctx context.Context, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := l.conn.Conn().WaitForNotification(waitCtx)
+	if err != nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil
+	}
+
+	return err
+}
+
+func (l *Listener) Close() {
+	// соединение с незавершенным ожиданием могло быть закрыто, такое не возвращаем в пул
+	if l.conn.Conn().IsClosed() {
+		l.conn.Release()
+		return
+	}
+
+	_, _ = l.conn.Exec(context.Background(), "UNLISTEN *")
+	l.conn.Release()
+}
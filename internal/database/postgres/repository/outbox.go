@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const outboxChannel = "outbox_events"
+
+type Outbox struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewOutbox(pool *pgxpool.Pool, baseLogger *slog.Logger) *Outbox {
+	logger := baseLogger.WithGroup("outbox repository")
+
+	return &Outbox{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// ProcessBatch блокирует до limit готовых к отправке событий и передает их в handle
+// по порядку. Успешно обработанные события помечаются опубликованными, для остальных
+// следующая попытка откладывается до nextAttempt. Блокировка держится до конца
+// обработки, поэтому событие не уйдет из двух экземпляров сервиса одновременно,
+// а при падении процесса оно останется неопубликованным и будет отправлено снова.
+func (o *Outbox) ProcessBatch(
+	ctx context.Context,
+	limit int,
+	nextAttempt func(attempts int) time.Time,
+	handle func(ctx context.Context, event *domain.OutboxEvent) error,
+) (int, error) {
+	defer metrics.ObserveRepository("outbox", "ProcessBatch", time.Now())
+
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// relay публикует события всех организаций
+	if err := setAllTenants(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	query := `SELECT position, event_id, organization_id, event_type, aggregate_id, payload, attempts, created_at FROM outbox
+		WHERE published_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY position
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var events []*domain.OutboxEvent
+	for rows.Next() {
+		var event domain.OutboxEvent
+		var eventType string
+		err := rows.Scan(&event.Position, &event.EventID, &event.OrganizationID, &eventType, &event.AggregateID, &event.Payload, &event.Attempts, &event.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		event.Type = domain.EventType(eventType)
+		events = append(events, &event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := handle(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			_, err = tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE position = $1`,
+				event.Position, err.Error(), nextAttempt(event.Attempts+1))
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		_, err := tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = '', published_at = NOW() WHERE position = $1`, event.Position)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), tx.Commit(ctx)
+}
+
+// DeletePublished удаляет опубликованные события старше before
+func (o *Outbox) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveRepository("outbox", "DeletePublished", time.Now())
+
+	var deleted int64
+	err := inAllTenantsTx(ctx, o.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < $1`, before)
+		deleted = tag.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+// Listen подписывается на уведомления о новых событиях outbox
+func (o *Outbox) Listen(ctx context.Context) (*Listener, error) {
+	return listen(ctx, o.pool, outboxChannel)
+}
+
+// insertOutboxEvents записывает события через переданный querier, чтобы они попали
+// в ту же транзакцию, что и изменение подписки
+func insertOutboxEvents(ctx context.Context, db querier, events ...*domain.Event) error {
+	switch len(events) {
+	case 0:
+		return nil
+	case 1:
+		event := events[0]
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(ctx, `INSERT INTO outbox (event_id, organization_id, event_type, aggregate_id, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			event.ID, event.Subscription.OrganizationID, string(event.Type), event.Subscription.UUID, payload, event.OccurredAt)
+		return err
+	}
+
+	columns := []string{"event_id", "organization_id", "event_type", "aggregate_id", "payload", "created_at"}
+	_, err := db.CopyFrom(ctx, pgx.Identifier{"outbox"}, columns, pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+		event := events[i]
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		return []any{event.ID, event.Subscription.OrganizationID, string(event.Type), event.Subscription.UUID, payload, event.OccurredAt}, nil
+	}))
+
+	return err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/pgtest"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TestOutboxProcessBatchRedelivery событие, которое приняли не все получатели, остается
+// неопубликованным и при следующей попытке отправляется снова с тем же event_id
+func TestOutboxProcessBatchRedelivery(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := context.Background()
+	repo := NewOutbox(pool, slog.New(slog.DiscardHandler))
+
+	eventID := uuid.New()
+	pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO outbox (event_id, organization_id, event_type, aggregate_id, payload) VALUES ($1, $2, $3, $4, '{}')`,
+			eventID, uuid.New(), string(domain.EventSubscriptionCreated), uuid.New())
+		return err
+	})
+
+	// первый получатель принимает событие, второй отказывает в первой попытке
+	var firstSink, secondSink []uuid.UUID
+	secondFails := true
+	handle := func(_ context.Context, event *domain.OutboxEvent) error {
+		firstSink = append(firstSink, event.EventID)
+		if secondFails {
+			return errors.New("second: unavailable")
+		}
+		secondSink = append(secondSink, event.EventID)
+		return nil
+	}
+	retryNow := func(int) time.Time { return time.Now().Add(-time.Minute) }
+
+	processed, err := repo.ProcessBatch(ctx, 10, retryNow, handle)
+	if err != nil || processed != 1 {
+		t.Fatalf("first ProcessBatch() = %d, %v; want 1, nil", processed, err)
+	}
+
+	var attempts int
+	var lastError string
+	var published *time.Time
+	readEvent := func() {
+		t.Helper()
+		pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+			return tx.QueryRow(ctx, `SELECT attempts, last_error, published_at FROM outbox WHERE event_id = $1`, eventID).
+				Scan(&attempts, &lastError, &published)
+		})
+	}
+	readEvent()
+	if attempts != 1 || lastError != "second: unavailable" || published != nil {
+		t.Fatalf("after partial failure attempts = %d, last_error = %q, published_at = %v; want 1, error, NULL",
+			attempts, lastError, published)
+	}
+
+	secondFails = false
+	processed, err = repo.ProcessBatch(ctx, 10, retryNow, handle)
+	if err != nil || processed != 1 {
+		t.Fatalf("second ProcessBatch() = %d, %v; want 1, nil", processed, err)
+	}
+
+	if len(firstSink) != 2 || firstSink[0] != eventID || firstSink[1] != eventID {
+		t.Errorf("first sink received %v, want the event twice with id %s", firstSink, eventID)
+	}
+	if len(secondSink) != 1 {
+		t.Errorf("second sink received %v, want the event once", secondSink)
+	}
+
+	readEvent()
+	if attempts != 2 || lastError != "" || published == nil {
+		t.Errorf("after redelivery attempts = %d, last_error = %q, published_at = %v; want 2, empty, set",
+			attempts, lastError, published)
+	}
+
+	processed, err = repo.ProcessBatch(ctx, 10, retryNow, handle)
+	if err != nil || processed != 0 {
+		t.Errorf("third ProcessBatch() = %d, %v; want 0, nil", processed, err)
+	}
+}
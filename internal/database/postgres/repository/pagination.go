@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+const defaultSort = "created_at"
+
+// sortColumn описывает колонку, по которой разрешена сортировка, и способ
+// сохранить её значение в курсоре.
+type sortColumn struct {
+	name   string
+	format func(subscription *domain.Subscription) string
+	parse  func(value string) (any, error)
+}
+
+var sortColumns = map[string]sortColumn{
+	"service_name": {
+		name:   "service_name",
+		format: func(s *domain.Subscription) string { return s.ServiceName },
+		parse:  func(v string) (any, error) { return v, nil },
+	},
+	"price": {
+		name:   "price",
+		format: func(s *domain.Subscription) string { return strconv.Itoa(s.Price) },
+		parse:  func(v string) (any, error) { return strconv.Atoi(v) },
+	},
+	"start_date": {
+		name:   "start_date",
+		format: func(s *domain.Subscription) string { return s.StartDate.Format(time.DateOnly) },
+		parse:  func(v string) (any, error) { return time.Parse(time.DateOnly, v) },
+	},
+	"created_at": {
+		name:   "created_at",
+		format: func(s *domain.Subscription) string { return s.CreatedAt.Format(time.RFC3339Nano) },
+		parse:  func(v string) (any, error) { return time.Parse(time.RFC3339Nano, v) },
+	},
+}
+
+type orderBy struct {
+	column sortColumn
+	desc   bool
+}
+
+// cursor непрозрачный для клиента указатель на границу страницы.
+type cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	ID       string   `json:"id"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+func resolveSort(fields []domain.SortField) ([]orderBy, string, error) {
+	if len(fields) == 0 {
+		fields = []domain.SortField{{Field: defaultSort}}
+	}
+
+	order := make([]orderBy, 0, len(fields))
+	signature := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		column, ok := sortColumns[field.Field]
+		if !ok {
+			return nil, "", fmt.Errorf("%w: unknown field %q", domain.ErrInvalidSort, field.Field)
+		}
+		if seen[field.Field] {
+			return nil, "", fmt.Errorf("%w: duplicate field %q", domain.ErrInvalidSort, field.Field)
+		}
+		seen[field.Field] = true
+
+		order = append(order, orderBy{column: column, desc: field.Desc})
+		if field.Desc {
+			signature = append(signature, "-"+field.Field)
+		} else {
+			signature = append(signature, field.Field)
+		}
+	}
+
+	return order, strings.Join(signature, ","), nil
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value, signature string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	if c.Sort != signature || c.ID == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+func newCursor(subscription *domain.Subscription, order []orderBy, signature string, backward bool) string {
+	values := make([]string, 0, len(order))
+	for _, o := range order {
+		values = append(values, o.column.format(subscription))
+	}
+
+	return encodeCursor(cursor{
+		Sort:     signature,
+		Values:   values,
+		ID:       subscription.UUID.String(),
+		Backward: backward,
+	})
+}
+
+// addKeyset добавляет условие "строка идет после курсора" для произвольного
+// набора направлений сортировки: (a > $1) OR (a = $1 AND b < $2) OR ...
+// Последним ключом всегда служит id, чтобы порядок был строгим.
+func (b *filterBuilder) addKeyset(c *cursor, order []orderBy, reverse bool) error {
+	if len(c.Values) != len(order) {
+		return domain.ErrInvalidCursor
+	}
+
+	columns := make([]string, 0, len(order)+1)
+	descs := make([]bool, 0, len(order)+1)
+	placeholders := make([]string, 0, len(order)+1)
+	for i, o := range order {
+		value, err := o.column.parse(c.Values[i])
+		if err != nil {
+			return domain.ErrInvalidCursor
+		}
+
+		columns = append(columns, o.column.name)
+		descs = append(descs, o.desc != reverse)
+		placeholders = append(placeholders, b.arg(value))
+	}
+
+	columns = append(columns, "id")
+	descs = append(descs, reverse)
+	placeholders = append(placeholders, b.arg(c.ID)+"::uuid")
+
+	terms := make([]string, 0, len(columns))
+	for i := range columns {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = "+placeholders[j])
+		}
+
+		op := " > "
+		if descs[i] {
+			op = " < "
+		}
+		parts = append(parts, columns[i]+op+placeholders[i])
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	b.add(strings.Join(terms, " OR "))
+	return nil
+}
+
+func orderByClause(order []orderBy, reverse bool) string {
+	parts := make([]string, 0, len(order)+1)
+	for _, o := range order {
+		if o.desc != reverse {
+			parts = append(parts, o.column.name+" DESC")
+		} else {
+			parts = append(parts, o.column.name+" ASC")
+		}
+	}
+
+	if reverse {
+		parts = append(parts, "id DESC")
+	} else {
+		parts = append(parts, "id ASC")
+	}
+
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
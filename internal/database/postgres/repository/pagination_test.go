@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveSort(t *testing.T) {
+	tests := []struct {
+		name          string
+		fields        []domain.SortField
+		wantSignature string
+		wantErr       error
+	}{
+		{name: "default", wantSignature: "created_at"},
+		{name: "single ascending", fields: []domain.SortField{{Field: "price"}}, wantSignature: "price"},
+		{
+			name:          "mixed directions",
+			fields:        []domain.SortField{{Field: "price", Desc: true}, {Field: "start_date"}},
+			wantSignature: "-price,start_date",
+		},
+		{name: "unknown field", fields: []domain.SortField{{Field: "user_id"}}, wantErr: domain.ErrInvalidSort},
+		{
+			name:    "duplicate field",
+			fields:  []domain.SortField{{Field: "price"}, {Field: "price", Desc: true}},
+			wantErr: domain.ErrInvalidSort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, signature, err := resolveSort(tt.fields)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveSort() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveSort() error = %v", err)
+			}
+			if signature != tt.wantSignature {
+				t.Errorf("signature = %q, want %q", signature, tt.wantSignature)
+			}
+			if want := max(len(tt.fields), 1); len(order) != want {
+				t.Errorf("len(order) = %d, want %d", len(order), want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	order, signature, err := resolveSort([]domain.SortField{{Field: "price", Desc: true}, {Field: "start_date"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subscription := &domain.Subscription{
+		UUID:      uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Price:     399,
+		StartDate: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+	}
+	encoded := newCursor(subscription, order, signature, true)
+
+	decoded, err := decodeCursor(encoded, signature)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	want := &cursor{Sort: signature, Values: []string{"399", "2025-07-01"}, ID: subscription.UUID.String(), Backward: true}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Fatalf("decodeCursor() = %+v, want %+v", decoded, want)
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "not base64", value: "%%%"},
+		{name: "not json", value: base64.RawURLEncoding.EncodeToString([]byte("price"))},
+		{name: "other sort", value: encodeCursor(cursor{Sort: "-price", Values: []string{"1"}, ID: "x"})},
+		{name: "no id", value: encodeCursor(cursor{Sort: "price", Values: []string{"1"}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.value, "price"); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Fatalf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestAddKeyset(t *testing.T) {
+	const id = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+	tests := []struct {
+		name          string
+		fields        []domain.SortField
+		values        []string
+		reverse       bool
+		wantCondition string
+		wantArgs      []any
+	}{
+		{
+			name:          "ascending",
+			fields:        []domain.SortField{{Field: "price"}},
+			values:        []string{"100"},
+			wantCondition: "(price > $1) OR (price = $1 AND id > $2::uuid)",
+			wantArgs:      []any{100, id},
+		},
+		{
+			name:          "descending",
+			fields:        []domain.SortField{{Field: "price", Desc: true}},
+			values:        []string{"100"},
+			wantCondition: "(price < $1) OR (price = $1 AND id > $2::uuid)",
+			wantArgs:      []any{100, id},
+		},
+		{
+			name:          "reverse flips every direction",
+			fields:        []domain.SortField{{Field: "price", Desc: true}},
+			values:        []string{"100"},
+			reverse:       true,
+			wantCondition: "(price > $1) OR (price = $1 AND id < $2::uuid)",
+			wantArgs:      []any{100, id},
+		},
+		{
+			name:   "two columns",
+			fields: []domain.SortField{{Field: "service_name"}, {Field: "start_date", Desc: true}},
+			values: []string{"Netflix", "2025-07-01"},
+			wantCondition: "(service_name > $1) OR (service_name = $1 AND start_date < $2)" +
+				" OR (service_name = $1 AND start_date = $2 AND id > $3::uuid)",
+			wantArgs: []any{"Netflix", time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC), id},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, _, err := resolveSort(tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b filterBuilder
+			if err := b.addKeyset(&cursor{Values: tt.values, ID: id}, order, tt.reverse); err != nil {
+				t.Fatalf("addKeyset() error = %v", err)
+			}
+			if want := " WHERE (" + tt.wantCondition + ")"; b.where() != want {
+				t.Errorf("where() = %q, want %q", b.where(), want)
+			}
+			if !reflect.DeepEqual(b.args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", b.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestAddKeysetInvalidCursor(t *testing.T) {
+	order, _, err := resolveSort([]domain.SortField{{Field: "price"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		values []string
+	}{
+		{name: "missing value", values: nil},
+		{name: "extra value", values: []string{"1", "2"}},
+		{name: "value of wrong type", values: []string{"cheap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b filterBuilder
+			if err := b.addKeyset(&cursor{Values: tt.values, ID: "x"}, order, false); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Fatalf("addKeyset() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestOrderByClause(t *testing.T) {
+	order, _, err := resolveSort([]domain.SortField{{Field: "price", Desc: true}, {Field: "created_at"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		reverse bool
+		want    string
+	}{
+		{reverse: false, want: " ORDER BY price DESC, created_at ASC, id ASC"},
+		{reverse: true, want: " ORDER BY price ASC, created_at DESC, id DESC"},
+	}
+
+	for _, tt := range tests {
+		if got := orderByClause(order, tt.reverse); got != tt.want {
+			t.Errorf("orderByClause(reverse=%v) = %q, want %q", tt.reverse, got, tt.want)
+		}
+	}
+}
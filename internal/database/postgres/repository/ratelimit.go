@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// rateLimitSweep как часто удалять наполнившиеся корзины
+const rateLimitSweep = time.Minute
+
+// rateLimitRefill токены корзины после пополнения: $2 емкость, $3 скорость в секунду
+const rateLimitRefill = `LEAST($2::float8, bucket.tokens + EXTRACT(EPOCH FROM NOW() - bucket.updated_at)::float8 * $3::float8)`
+
+const rateLimitTokens = `CASE WHEN ` + rateLimitRefill + ` >= 1 THEN ` + rateLimitRefill + ` - 1 ELSE ` + rateLimitRefill + ` END`
+
+// RateLimit корзины token bucket в Postgres, общие для всех экземпляров сервиса.
+// Каждая попытка — один атомарный upsert, поэтому экземпляры не расходятся в подсчете.
+type RateLimit struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimit(pool *pgxpool.Pool, baseLogger *slog.Logger) *RateLimit {
+	logger := baseLogger.WithGroup("rate limit repository")
+
+	return &RateLimit{
+		pool:      pool,
+		logger:    logger,
+		lastSweep: time.Now(),
+	}
+}
+
+func (r *RateLimit) Take(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitDecision, error) {
+	defer metrics.ObserveRepository("rate_limit", "Take", time.Now())
+
+	r.sweep(ctx)
+
+	query := `INSERT INTO rate_limit_buckets AS bucket (key, tokens, allowed, updated_at, full_at)
+		VALUES ($1, $2::float8 - 1, TRUE, NOW(), NOW() + make_interval(secs => 1 / $3::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = ` + rateLimitTokens + `,
+			allowed = ` + rateLimitRefill + ` >= 1,
+			updated_at = NOW(),
+			full_at = NOW() + make_interval(secs => ($2::float8 - (` + rateLimitTokens + `)) / $3::float8)
+		RETURNING tokens, allowed`
+
+	var tokens float64
+	var allowed bool
+	if err := r.pool.QueryRow(ctx, query, key, float64(limit.Burst), limit.Rate).Scan(&tokens, &allowed); err != nil {
+		return nil, err
+	}
+
+	return limit.Decision(tokens, allowed), nil
+}
+
+// sweep не чаще раза в rateLimitSweep удаляет полные корзины: они ничем не отличаются
+// от отсутствующих, а без удаления таблица росла бы с каждым новым IP-адресом
+func (r *RateLimit) sweep(ctx context.Context) {
+	r.mu.Lock()
+	if time.Since(r.lastSweep) < rateLimitSweep {
+		r.mu.Unlock()
+		return
+	}
+	r.lastSweep = time.Now()
+	r.mu.Unlock()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE full_at < NOW()`)
+	if err != nil {
+		r.logger.Warn("Failed to delete full rate limit buckets", slog.String("error", err.Error()))
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		r.logger.Debug("Deleted full rate limit buckets", slog.Int64("count", tag.RowsAffected()))
+	}
+}
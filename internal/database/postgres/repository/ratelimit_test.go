@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/pgtest"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+// TestRateLimitTake корзина в Postgres отдает burst токенов подряд, затем отказывает
+// до пополнения; корзины разных ключей независимы
+func TestRateLimitTake(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := context.Background()
+	limiter := NewRateLimit(pool, slog.New(slog.DiscardHandler))
+	// пополнение на один токен занимает около 17 минут и не влияет на тест
+	limit := domain.RateLimit{Rate: 0.001, Burst: 3}
+
+	for want := 2; want >= 0; want-- {
+		decision, err := limiter.Take(ctx, "user:a", limit)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if !decision.Allowed || decision.Remaining != want || decision.Limit != 3 {
+			t.Fatalf("Take() = %+v, want allowed with %d remaining", decision, want)
+		}
+	}
+
+	decision, err := limiter.Take(ctx, "user:a", limit)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if decision.Allowed || decision.Remaining != 0 || decision.RetryAfter <= 0 {
+		t.Errorf("Take() over the limit = %+v, want denied with RetryAfter", decision)
+	}
+
+	decision, err = limiter.Take(ctx, "user:b", limit)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("Take() for another key = %+v, want a full bucket", decision)
+	}
+}
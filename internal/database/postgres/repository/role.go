@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Role struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewRole(pool *pgxpool.Pool, baseLogger *slog.Logger) *Role {
+	logger := baseLogger.WithGroup("role repository")
+
+	return &Role{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// AssignRole назначает роль в организации запроса. Повторное назначение возвращает
+// существующую запись без изменений.
+func (r *Role) AssignRole(ctx context.Context, userID uuid.UUID, role domain.Role, grantedBy *uuid.UUID) (*domain.RoleAssignment, error) {
+	defer metrics.ObserveRepository("role", "AssignRole", time.Now())
+
+	query := `WITH inserted AS (
+			INSERT INTO user_roles (organization_id, user_id, role, granted_by) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (organization_id, user_id, role) DO NOTHING
+			RETURNING user_id, role, granted_by, created_at
+		)
+		SELECT user_id, role, granted_by, created_at FROM inserted
+		UNION ALL
+		SELECT user_id, role, granted_by, created_at FROM user_roles WHERE organization_id = $1 AND user_id = $2 AND role = $3
+		LIMIT 1`
+
+	var assignment *domain.RoleAssignment
+	err := inTenantTx(ctx, r.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		var err error
+		assignment, err = scanRoleAssignment(tx.QueryRow(ctx, query, organizationID, userID, role, grantedBy))
+		return err
+	})
+
+	return assignment, err
+}
+
+func (r *Role) RevokeRole(ctx context.Context, userID uuid.UUID, role domain.Role) error {
+	defer metrics.ObserveRepository("role", "RevokeRole", time.Now())
+
+	return inTenantTx(ctx, r.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `DELETE FROM user_roles WHERE organization_id = $1 AND user_id = $2 AND role = $3`
+		tag, err := tx.Exec(ctx, query, organizationID, userID, role)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrRoleAssignmentNotFound
+		}
+
+		return nil
+	})
+}
+
+// ListRoles роли пользователя в организации запроса
+func (r *Role) ListRoles(ctx context.Context, userID uuid.UUID) ([]*domain.RoleAssignment, error) {
+	defer metrics.ObserveRepository("role", "ListRoles", time.Now())
+
+	var assignments []*domain.RoleAssignment
+	err := inTenantTx(ctx, r.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT user_id, role, granted_by, created_at FROM user_roles
+			WHERE organization_id = $1 AND user_id = $2 ORDER BY created_at`
+		rows, err := tx.Query(ctx, query, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			assignment, err := scanRoleAssignment(rows)
+			if err != nil {
+				return err
+			}
+			assignments = append(assignments, assignment)
+		}
+
+		return rows.Err()
+	})
+
+	return assignments, err
+}
+
+func scanRoleAssignment(row pgx.Row) (*domain.RoleAssignment, error) {
+	var assignment domain.RoleAssignment
+	err := row.Scan(&assignment.UserUUID, &assignment.Role, &assignment.GrantedBy, &assignment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
@@ -2,19 +2,39 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"html"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const exportFetchSize = 500
+
+const subscriptionColumns = `id, organization_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at`
+
+// querier общий интерфейс пула и транзакции pgx
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type Subscription struct {
-	pool   *pgxpool.Pool
+	db     querier
 	logger *slog.Logger
 }
 
@@ -22,37 +42,109 @@ func NewSubscription(pool *pgxpool.Pool, baseLogger *slog.Logger) *Subscription
 	logger := baseLogger.WithGroup("subscription repository")
 
 	return &Subscription{
-		pool:   pool,
+		db:     pool,
 		logger: logger,
 	}
 }
 
+// WithTx выполняет fn в транзакции: все вызовы переданного в fn репозитория идут
+// через одну транзакцию, которая фиксируется, только если fn вернула nil.
+// Вложенный вызов создает savepoint во внешней транзакции. Транзакция видит только
+// данные организации запроса, см. setTenant.
+func (s *Subscription) WithTx(ctx context.Context, fn func(repo *Subscription) error) error {
+	return s.withTx(ctx, setTenant, fn)
+}
+
+// withTx scope настраивает политики Row-Level Security внешней транзакции,
+// savepoint наследует ее настройку
+func (s *Subscription) withTx(ctx context.Context, scope func(context.Context, pgx.Tx) error, fn func(repo *Subscription) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, nested := s.db.(pgx.Tx); !nested {
+		if err := scope(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	txRepo := *s
+	txRepo.db = tx
+	if err := fn(&txRepo); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// beginReadOnly открывает снимок для согласованного чтения нескольких запросов.
+// Внутри WithTx используется savepoint внешней транзакции.
+func (s *Subscription) beginReadOnly(ctx context.Context) (pgx.Tx, error) {
+	pool, ok := s.db.(*pgxpool.Pool)
+	if !ok {
+		return s.db.Begin(ctx)
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	if err := setTenant(ctx, tx); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
 func (s *Subscription) CreateSubscription(ctx context.Context, subscription *domain.Subscription) error {
+	defer metrics.ObserveRepository("subscription", "CreateSubscription", time.Now())
+
 	logger := s.logger.With(
 		slog.String("request_id", ctx.Value(middleware.RequestIDKey).(string)),
 		slog.String("func", "CreateSubscription"),
 	)
 
-	query := `INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := s.pool.Exec(ctx, query,
-		subscription.UUID.String(),
-		subscription.ServiceName,
-		subscription.Price,
-		subscription.UserUUID.String(),
-		subscription.StartDate,
-		subscription.EndDate,
-	)
-
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	subscription.OrganizationID = organizationID
+
+	query := `INSERT INTO subscriptions (id, organization_id, service_name, price, user_id, start_date, end_date, notes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at, update_at`
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		err := repo.db.QueryRow(ctx, query,
+			subscription.UUID.String(),
+			subscription.OrganizationID,
+			subscription.ServiceName,
+			subscription.Price,
+			subscription.UserUUID.String(),
+			subscription.StartDate,
+			subscription.EndDate,
+			subscription.Notes,
+		).Scan(&subscription.CreatedAt, &subscription.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := insertAuditRecords(ctx, repo.db, domain.NewAuditRecord(domain.AuditActionCreate, nil, subscription)); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, repo.db, domain.NewEvent(domain.EventSubscriptionCreated, subscription))
+	})
 	if err != nil {
 		logger.Error("db query failed",
 			slog.String("query", "insert subscription"),
 			slog.Any("params", map[string]any{
-				"uuid":         subscription.UUID.String(),
-				"service_name": subscription.ServiceName,
-				"price":        subscription.Price,
-				"user_id":      subscription.UserUUID.String(),
-				"start_date":   subscription.StartDate,
-				"end_date":     subscription.EndDate,
+				"uuid":            subscription.UUID.String(),
+				"organization_id": subscription.OrganizationID.String(),
+				"service_name":    subscription.ServiceName,
+				"price":           subscription.Price,
+				"user_id":         subscription.UserUUID.String(),
+				"start_date":      subscription.StartDate,
+				"end_date":        subscription.EndDate,
 			}),
 		)
 		return err
@@ -61,122 +153,469 @@ func (s *Subscription) CreateSubscription(ctx context.Context, subscription *dom
 	return nil
 }
 
-func (s *Subscription) GetSubscription(uuid uuid.UUID) (*domain.Subscription, error) {
-	ctx := context.Background()
-	var subscription domain.Subscription
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1`
-	err := s.pool.QueryRow(ctx, query, uuid).Scan(
-		&subscription.UUID,
-		&subscription.ServiceName,
-		&subscription.Price,
-		&subscription.UserUUID,
-		&subscription.StartDate,
-		&subscription.EndDate,
-	)
+// CopySubscriptions вставляет подписки одним COPY. Значения created_at и update_at
+// проставляются базой и в переданные структуры не возвращаются.
+func (s *Subscription) CopySubscriptions(ctx context.Context, subscriptions []*domain.Subscription) (int64, error) {
+	defer metrics.ObserveRepository("subscription", "CopySubscriptions", time.Now())
+
+	organizationID, err := tenantID(ctx)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	return &subscription, nil
+	var copied int64
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		columns := []string{"id", "organization_id", "service_name", "price", "user_id", "start_date", "end_date", "notes"}
+		var err error
+		copied, err = repo.db.CopyFrom(ctx, pgx.Identifier{"subscriptions"}, columns, pgx.CopyFromSlice(len(subscriptions), func(i int) ([]any, error) {
+			subscription := subscriptions[i]
+			subscription.OrganizationID = organizationID
+			return []any{
+				subscription.UUID,
+				subscription.OrganizationID,
+				subscription.ServiceName,
+				subscription.Price,
+				subscription.UserUUID,
+				subscription.StartDate,
+				subscription.EndDate,
+				subscription.Notes,
+			}, nil
+		}))
+		if err != nil {
+			return err
+		}
+
+		events := make([]*domain.Event, 0, len(subscriptions))
+		records := make([]*domain.AuditRecord, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			events = append(events, domain.NewEvent(domain.EventSubscriptionCreated, subscription))
+			records = append(records, domain.NewAuditRecord(domain.AuditActionCreate, nil, subscription))
+		}
+		if err := insertAuditRecords(ctx, repo.db, records...); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, repo.db, events...)
+	})
+
+	return copied, err
 }
 
-func (s *Subscription) UpdateSubscription(uuid uuid.UUID, params *domain.UpdateSubscriptionParams) error {
-	ctx := context.Background()
-	query := `UPDATE subscriptions SET service_name=$1, price=$2, end_date=$3 WHERE id = $4`
-	_, err := s.pool.Exec(ctx, query, params.ServiceName, params.Price, params.EndDate, uuid)
+// GetSubscription возвращает подписку, при asOf — ее версию, действовавшую в этот момент
+func (s *Subscription) GetSubscription(ctx context.Context, uuid uuid.UUID, asOf *time.Time) (*domain.Subscription, error) {
+	defer metrics.ObserveRepository("subscription", "GetSubscription", time.Now())
+
+	organizationID, err := tenantID(ctx)
 	if err != nil {
+		return nil, err
+	}
+
+	var filter filterBuilder
+	filter.add("id = ?", uuid)
+	filter.add("organization_id = ?", organizationID)
+	source := subscriptionSource(&filter, asOf)
+
+	query := `SELECT ` + subscriptionColumns + ` FROM ` + source + filter.where()
+	var subscription *domain.Subscription
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		var err error
+		subscription, err = scanSubscription(repo.db.QueryRow(ctx, query, filter.args...))
 		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return subscription, nil
+}
+
+// UpdateSubscription изменяет подписку. check, если задана, проверяет текущее состояние
+// подписки под блокировкой строки: ее ошибка отменяет изменение, и между проверкой и
+// изменением владельца подписки никто не поменяет.
+func (s *Subscription) UpdateSubscription(
+	ctx context.Context,
+	uuid uuid.UUID,
+	params *domain.UpdateSubscriptionParams,
+	check func(*domain.Subscription) error,
+) (*domain.Subscription, error) {
+	defer metrics.ObserveRepository("subscription", "UpdateSubscription", time.Now())
+
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// прежнее состояние блокируется до конца транзакции, чтобы журнал записал точную разницу
+	selectQuery := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND organization_id = $2 FOR UPDATE`
+	query := `UPDATE subscriptions SET service_name=$1, price=$2, end_date=$3, notes=$4, update_at=NOW() WHERE id = $5 AND organization_id = $6 RETURNING ` + subscriptionColumns
+	var subscription *domain.Subscription
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		before, err := scanSubscription(repo.db.QueryRow(ctx, selectQuery, uuid, organizationID))
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(before); err != nil {
+				return err
+			}
+		}
+		subscription, err = scanSubscription(repo.db.QueryRow(ctx, query, params.ServiceName, params.Price, params.EndDate, params.Notes, uuid, organizationID))
+		if err != nil {
+			return err
+		}
+
+		if err := insertAuditRecords(ctx, repo.db, domain.NewAuditRecord(domain.AuditActionUpdate, before, subscription)); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, repo.db, domain.NewEvent(domain.EventSubscriptionUpdated, subscription))
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
 }
 
-func (s *Subscription) DeleteSubscription(uuid uuid.UUID) error {
-	ctx := context.Background()
-	query := `DELETE FROM subscriptions WHERE id = $1`
-	_, err := s.pool.Exec(ctx, query, uuid)
+// DeleteSubscription удаляет подписку и возвращает ее последнее состояние. check, как
+// в UpdateSubscription, проверяет подписку под блокировкой перед удалением.
+func (s *Subscription) DeleteSubscription(ctx context.Context, uuid uuid.UUID, check func(*domain.Subscription) error) (*domain.Subscription, error) {
+	defer metrics.ObserveRepository("subscription", "DeleteSubscription", time.Now())
+
+	organizationID, err := tenantID(ctx)
 	if err != nil {
+		return nil, err
+	}
+
+	selectQuery := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND organization_id = $2 FOR UPDATE`
+	query := `DELETE FROM subscriptions WHERE id = $1 AND organization_id = $2`
+	var subscription *domain.Subscription
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		var err error
+		subscription, err = scanSubscription(repo.db.QueryRow(ctx, selectQuery, uuid, organizationID))
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(subscription); err != nil {
+				return err
+			}
+		}
+		if _, err := repo.db.Exec(ctx, query, uuid, organizationID); err != nil {
+			return err
+		}
+
+		if err := insertAuditRecords(ctx, repo.db, domain.NewAuditRecord(domain.AuditActionDelete, subscription, nil)); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, repo.db, domain.NewEvent(domain.EventSubscriptionDeleted, subscription))
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetDeletedSubscription возвращает подписку в состоянии перед удалением по последней
+// записи журнала. Если последняя запись не удаление, возвращается ErrSubscriptionNotDeleted.
+func (s *Subscription) GetDeletedSubscription(ctx context.Context, uuid uuid.UUID) (*domain.Subscription, error) {
+	defer metrics.ObserveRepository("subscription", "GetDeletedSubscription", time.Now())
+
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE subscription_id = $1 AND organization_id = $2
+		ORDER BY id DESC LIMIT 1`
+	var record *domain.AuditRecord
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		var err error
+		record, err = scanAuditRecord(repo.db.QueryRow(ctx, query, uuid, organizationID))
 		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.Action != domain.AuditActionDelete {
+		return nil, domain.ErrSubscriptionNotDeleted
 	}
 
-	return nil
+	return record.BeforeSubscription()
+}
+
+// RestoreSubscription заново создает удаленную подписку с прежним id. Если подписка
+// с таким id уже есть, например ее восстановил параллельный запрос, возвращается
+// ErrSubscriptionNotDeleted.
+func (s *Subscription) RestoreSubscription(ctx context.Context, subscription *domain.Subscription) error {
+	defer metrics.ObserveRepository("subscription", "RestoreSubscription", time.Now())
+
+	query := `INSERT INTO subscriptions (id, organization_id, service_name, price, user_id, start_date, end_date, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (id) DO NOTHING RETURNING created_at, update_at`
+	err := s.WithTx(ctx, func(repo *Subscription) error {
+		err := repo.db.QueryRow(ctx, query,
+			subscription.UUID,
+			subscription.OrganizationID,
+			subscription.ServiceName,
+			subscription.Price,
+			subscription.UserUUID,
+			subscription.StartDate,
+			subscription.EndDate,
+			subscription.Notes,
+		).Scan(&subscription.CreatedAt, &subscription.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := insertAuditRecords(ctx, repo.db, domain.NewAuditRecord(domain.AuditActionRestore, nil, subscription)); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, repo.db, domain.NewEvent(domain.EventSubscriptionCreated, subscription))
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrSubscriptionNotDeleted
+	}
+
+	return err
+}
+
+// ClaimEndingSoon записывает в outbox события subscription.ending_soon для подписок,
+// которые заканчиваются в ближайшие days дней и о которых еще не уведомляли.
+// Подписки отмечаются той же транзакцией, поэтому повторный вызов их не вернет,
+// пока не изменится дата окончания. Обрабатываются подписки всех организаций.
+func (s *Subscription) ClaimEndingSoon(ctx context.Context, days int) (int, error) {
+	defer metrics.ObserveRepository("subscription", "ClaimEndingSoon", time.Now())
+
+	query := `WITH claimed AS (
+			INSERT INTO subscription_ending_notifications (subscription_id, end_date)
+			SELECT id, end_date FROM subscriptions
+			WHERE end_date IS NOT NULL AND end_date >= CURRENT_DATE AND end_date <= CURRENT_DATE + $1::int
+			ON CONFLICT DO NOTHING
+			RETURNING subscription_id
+		)
+		SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id IN (SELECT subscription_id FROM claimed)`
+
+	var claimed int
+	err := s.withTx(ctx, setAllTenants, func(repo *Subscription) error {
+		rows, err := repo.db.Query(ctx, query, days)
+		if err != nil {
+			return err
+		}
+
+		var events []*domain.Event
+		for rows.Next() {
+			subscription, err := scanSubscription(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			events = append(events, domain.NewEvent(domain.EventSubscriptionEndingSoon, subscription))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		claimed = len(events)
+		return insertOutboxEvents(ctx, repo.db, events...)
+	})
+
+	return claimed, err
 }
 
-func (s *Subscription) ListSubscriptions(params *domain.ListSubscriptionParams) ([]*domain.Subscription, error) {
-	ctx := context.Background()
-	tx, err := s.pool.Begin(ctx)
+func (s *Subscription) ListSubscriptions(ctx context.Context, params *domain.ListSubscriptionParams) (*domain.SubscriptionPage, error) {
+	defer metrics.ObserveRepository("subscription", "ListSubscriptions", time.Now())
+
+	order, signature, err := resolveSort(params.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *cursor
+	if params.Cursor != "" {
+		after, err = decodeCursor(params.Cursor, signature)
+		if err != nil {
+			return nil, err
+		}
+	}
+	backward := after != nil && after.Backward
+
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter filterBuilder
+	filter.add("organization_id = ?", organizationID)
+	filter.applyFilter(&params.Filter)
+	source := subscriptionSource(&filter, params.AsOf)
+
+	pageFilter := filter.clone()
+	if after != nil {
+		if err := pageFilter.addKeyset(after, order, backward); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.beginReadOnly(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	query, args := s.buildListSubscriptionsQuery(params)
-	rows, err := s.pool.Query(ctx, query, args...)
+	query := `SELECT ` + subscriptionColumns + ` FROM ` + source + pageFilter.where() +
+		orderByClause(order, backward) +
+		" LIMIT " + pageFilter.arg(params.Limit+1)
+	rows, err := tx.Query(ctx, query, pageFilter.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	subscriptions := make([]*domain.Subscription, 0)
+	subscriptions := make([]*domain.Subscription, 0, params.Limit+1)
 	for rows.Next() {
-		var subscription domain.Subscription
-		_ = rows.Scan(
-			&subscription.UUID,
-			&subscription.ServiceName,
-			&subscription.Price,
-			&subscription.UserUUID,
-			&subscription.StartDate,
-			&subscription.EndDate,
-		)
-		subscriptions = append(subscriptions, &subscription)
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
 	}
-
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	err = tx.Commit(ctx)
-	if err != nil {
+	hasMore := len(subscriptions) > params.Limit
+	if hasMore {
+		subscriptions = subscriptions[:params.Limit]
+	}
+	if backward {
+		slices.Reverse(subscriptions)
+	}
+
+	page := &domain.SubscriptionPage{Subscriptions: subscriptions}
+	if len(subscriptions) > 0 {
+		first, last := subscriptions[0], subscriptions[len(subscriptions)-1]
+		if (backward && hasMore) || (!backward && after != nil) {
+			page.PrevCursor = newCursor(first, order, signature, true)
+		}
+		if (!backward && hasMore) || backward {
+			page.NextCursor = newCursor(last, order, signature, false)
+		}
+	}
+
+	if params.WithTotal {
+		var total int
+		query := `SELECT COUNT(*) FROM ` + source + filter.where()
+		if err := tx.QueryRow(ctx, query, filter.args...).Scan(&total); err != nil {
+			return nil, err
+		}
+		page.TotalCount = &total
+	}
+
+	if err = tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 
-	return subscriptions, nil
+	return page, nil
 }
 
-func (s *Subscription) buildListSubscriptionsQuery(params *domain.ListSubscriptionParams) (string, []any) {
-	var conditions []string
-	var args []any
-	pos := 1
+// StreamSubscriptions читает подписки серверным курсором порциями по exportFetchSize
+// и передает их в fn по одной, не накапливая всю выборку в памяти.
+func (s *Subscription) StreamSubscriptions(ctx context.Context, params *domain.ExportSubscriptionsParams, fn func(*domain.Subscription) error) error {
+	defer metrics.ObserveRepository("subscription", "StreamSubscriptions", time.Now())
 
-	if params.ServiceName != nil && *params.ServiceName != "" {
-		conditions = append(conditions, "service_name ILIKE $"+strconv.Itoa(pos))
-		args = append(args, *params.ServiceName)
-		pos++
+	order, _, err := resolveSort(params.Sort)
+	if err != nil {
+		return err
 	}
 
-	if params.UserID != nil {
-		conditions = append(conditions, "user_id = $"+strconv.Itoa(pos))
-		args = append(args, *params.UserID)
-		pos++
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
 	}
 
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions`
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	var filter filterBuilder
+	filter.add("organization_id = ?", organizationID)
+	filter.applyFilter(&params.Filter)
+
+	tx, err := s.beginReadOnly(ctx)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback(ctx)
 
-	query += " LIMIT $" + strconv.Itoa(pos) + " OFFSET $" + strconv.Itoa(pos+1)
-	args = append(args, params.Page, params.Limit)
+	query := `DECLARE export_cursor NO SCROLL CURSOR FOR SELECT ` + subscriptionColumns + ` FROM subscriptions` +
+		filter.where() + orderByClause(order, false)
+	if _, err := tx.Exec(ctx, query, filter.args...); err != nil {
+		return err
+	}
 
-	return query, args
+	fetch := `FETCH FORWARD ` + strconv.Itoa(exportFetchSize) + ` FROM export_cursor`
+	for {
+		fetched, err := fetchSubscriptions(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `CLOSE export_cursor`); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (s *Subscription) TotalCostSubscriptions(params *domain.TotalCostSubscriptionsParams) (int, error) {
-	ctx := context.Background()
+func fetchSubscriptions(ctx context.Context, tx pgx.Tx, query string, fn func(*domain.Subscription) error) (int, error) {
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return fetched, err
+		}
+		fetched++
+
+		if err := fn(subscription); err != nil {
+			return fetched, err
+		}
+	}
+
+	return fetched, rows.Err()
+}
+
+func (s *Subscription) TotalCostSubscriptions(ctx context.Context, params *domain.TotalCostSubscriptionsParams) (int, error) {
+	defer metrics.ObserveRepository("subscription", "TotalCostSubscriptions", time.Now())
+
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	var totalCost int
-	query, args := s.buildTotalCostSubscriptionsQuery(params)
+	query, args := s.buildTotalCostSubscriptionsQuery(organizationID, params)
 
-	err := s.pool.QueryRow(ctx, query, args...).Scan(&totalCost)
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		return repo.db.QueryRow(ctx, query, args...).Scan(&totalCost)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -184,35 +623,152 @@ func (s *Subscription) TotalCostSubscriptions(params *domain.TotalCostSubscripti
 	return totalCost, nil
 }
 
-func (s *Subscription) buildTotalCostSubscriptionsQuery(params *domain.TotalCostSubscriptionsParams) (string, []any) {
-	var conditions []string
-	var args []any
-	pos := 1
+func (s *Subscription) buildTotalCostSubscriptionsQuery(organizationID uuid.UUID, params *domain.TotalCostSubscriptionsParams) (string, []any) {
+	var filter filterBuilder
+	filter.add("organization_id = ?", organizationID)
+	filter.applyFilter(&params.Filter)
+	filter.add("start_date >= ?", params.StartDate)
+	filter.add("end_date IS NULL OR end_date <= ?", params.EndDate)
+	source := subscriptionSource(&filter, params.AsOf)
+
+	query := `SELECT COALESCE(SUM(price), 0) FROM ` + source + filter.where()
+
+	return query, filter.args
+}
+
+// SubscriptionStats считает показатели подписок всех организаций, действующих в текущем месяце
+func (s *Subscription) SubscriptionStats(ctx context.Context) (*domain.SubscriptionStats, error) {
+	defer metrics.ObserveRepository("subscription", "SubscriptionStats", time.Now())
+
+	query := `SELECT COUNT(*), COALESCE(SUM(price), 0) FROM subscriptions
+		WHERE start_date <= date_trunc('month', CURRENT_DATE) AND (end_date IS NULL OR end_date >= date_trunc('month', CURRENT_DATE))`
+
+	var stats domain.SubscriptionStats
+	err := s.withTx(ctx, setAllTenants, func(repo *Subscription) error {
+		return repo.db.QueryRow(ctx, query).Scan(&stats.ActiveSubscriptions, &stats.MonthlyRunRate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// subscriptionSource таблица, из которой читаются подписки: текущие или, при asOf,
+// версии из subscriptions_history, действовавшие в этот момент. Колонки таблиц
+// совпадают, поэтому фильтры и сортировка работают с обеими.
+func subscriptionSource(filter *filterBuilder, asOf *time.Time) string {
+	if asOf == nil {
+		return "subscriptions"
+	}
+
+	p := filter.arg(*asOf)
+	filter.add("valid_from <= " + p + " AND (valid_to IS NULL OR valid_to > " + p + ")")
+	return "subscriptions_history"
+}
+
+// Маркеры совпадений для ts_headline — символы из области частного использования Unicode.
+// Из данных они удаляются до подсветки, поэтому после экранирования маркеры однозначно
+// заменяются тегами.
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+// stripHighlightMarks выражение SQL: колонка без символов маркеров
+func stripHighlightMarks(column string) string {
+	return "translate(" + column + ", '" + highlightStart + highlightStop + "', '')"
+}
+
+// highlightHTML экранирует фрагмент как HTML и заменяет маркеры тегами <mark>,
+// чтобы разметка из названия или заметок не попала в ответ как HTML
+func highlightHTML(fragment string) string {
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(html.EscapeString(fragment))
+}
+
+// SearchSubscriptions ищет подписки по названию сервиса и заметкам: полнотекстово
+// по search_vector и нечетко через триграммы pg_trgm, чтобы находились опечатки
+// вроде "netflx".
+func (s *Subscription) SearchSubscriptions(ctx context.Context, params *domain.SearchSubscriptionsParams) ([]*domain.SearchResult, error) {
+	defer metrics.ObserveRepository("subscription", "SearchSubscriptions", time.Now())
 
-	if params.ServiceName != nil && *params.ServiceName != "" {
-		conditions = append(conditions, "service_name = $"+strconv.Itoa(pos))
-		args = append(args, *params.ServiceName)
-		pos++
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	var filter filterBuilder
+	q := filter.arg(params.Query)
+	filter.add("search_vector @@ tsq OR service_name % " + q + " OR " + q + " <% service_name OR " + q + " <% notes")
+	filter.add("organization_id = ?", organizationID)
 	if params.UserID != nil {
-		conditions = append(conditions, "user_id = $"+strconv.Itoa(pos))
-		args = append(args, *params.UserID)
-		pos++
+		filter.add("user_id = ?", *params.UserID)
 	}
 
-	conditions = append(conditions, "start_date >= $"+strconv.Itoa(pos))
-	args = append(args, params.StartDate)
-	pos++
+	query := `WITH search AS (SELECT websearch_to_tsquery('simple', ` + q + `) AS tsq)
+		SELECT ` + subscriptionColumns + `,
+			GREATEST(ts_rank(search_vector, tsq), similarity(service_name, ` + q + `), word_similarity(` + q + `, notes) / 2) AS rank,
+			CASE
+				WHEN to_tsvector('simple', service_name) @@ tsq
+					THEN ts_headline('simple', ` + stripHighlightMarks("service_name") + `, tsq,
+						'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, HighlightAll=true')
+				WHEN service_name % ` + q + ` OR ` + q + ` <% service_name
+					THEN '` + highlightStart + `' || ` + stripHighlightMarks("service_name") + ` || '` + highlightStop + `'
+				ELSE ` + stripHighlightMarks("service_name") + `
+			END,
+			ts_headline('simple', ` + stripHighlightMarks("notes") + `, tsq,
+				'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, MaxFragments=2')
+		FROM subscriptions, search` + filter.where() + `
+		ORDER BY rank DESC, id
+		LIMIT ` + filter.arg(params.Limit)
+
+	results := make([]*domain.SearchResult, 0, params.Limit)
+	err = s.WithTx(ctx, func(repo *Subscription) error {
+		rows, err := repo.db.Query(ctx, query, filter.args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var result domain.SearchResult
+			result.Subscription, err = scanSubscription(rows, &result.Rank, &result.ServiceNameHighlight, &result.NotesHighlight)
+			if err != nil {
+				return err
+			}
+			result.ServiceNameHighlight = highlightHTML(result.ServiceNameHighlight)
+			result.NotesHighlight = highlightHTML(result.NotesHighlight)
+			results = append(results, &result)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	conditions = append(conditions, "(end_date IS NULL OR end_date <= $"+strconv.Itoa(pos)+")")
-	args = append(args, params.EndDate)
-	pos++
+	return results, nil
+}
 
-	query := `SELECT COALESCE(SUM(price), 0) FROM subscriptions WHERE `
-	if len(conditions) > 0 {
-		query += strings.Join(conditions, " AND ")
+// scanSubscription читает колонки subscriptionColumns, extra получает
+// значения дополнительных колонок, перечисленных в запросе после них.
+func scanSubscription(row pgx.Row, extra ...any) (*domain.Subscription, error) {
+	var subscription domain.Subscription
+	dest := []any{
+		&subscription.UUID,
+		&subscription.OrganizationID,
+		&subscription.ServiceName,
+		&subscription.Price,
+		&subscription.UserUUID,
+		&subscription.StartDate,
+		&subscription.EndDate,
+		&subscription.Notes,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
 	}
 
-	return query, args
+	return &subscription, nil
 }
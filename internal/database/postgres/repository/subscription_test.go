@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/pgtest"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"github.com/google/uuid"
+)
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{name: "plain text", fragment: "Netflix", want: "Netflix"},
+		{name: "match", fragment: highlightStart + "Netflix" + highlightStop + " Premium", want: "<mark>Netflix</mark> Premium"},
+		{
+			name:     "markup is escaped",
+			fragment: `<img src=x onerror="alert(1)">` + highlightStart + "plus" + highlightStop,
+			want:     `&lt;img src=x onerror=&#34;alert(1)&#34;&gt;<mark>plus</mark>`,
+		},
+		{name: "marks from data are not trusted", fragment: "</mark><script>", want: "&lt;/mark&gt;&lt;script&gt;"},
+		{name: "ampersand and quotes", fragment: `AT&T 'family'`, want: "AT&amp;T &#39;family&#39;"},
+		{name: "empty", fragment: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightHTML(tt.fragment); got != tt.want {
+				t.Fatalf("highlightHTML(%q) = %q, want %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSubscriptionTenantIsolation подписка видна и изменяется только в организации,
+// в которой создана
+func TestSubscriptionTenantIsolation(t *testing.T) {
+	pool := pgtest.Pool(t)
+	repo := NewSubscription(pool, slog.New(slog.DiscardHandler))
+	base := context.WithValue(context.Background(), middleware.RequestIDKey, "test")
+	own := domain.WithTenant(base, uuid.New())
+	other := domain.WithTenant(base, uuid.New())
+
+	subscription := &domain.Subscription{
+		UUID:        uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserUUID:    uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := repo.CreateSubscription(own, subscription); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	if _, err := repo.GetSubscription(other, subscription.UUID, nil); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Errorf("GetSubscription() from another organization error = %v, want ErrSubscriptionNotFound", err)
+	}
+	params := &domain.UpdateSubscriptionParams{ServiceName: "Spotify", Price: 300}
+	if _, err := repo.UpdateSubscription(other, subscription.UUID, params, nil); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Errorf("UpdateSubscription() from another organization error = %v, want ErrSubscriptionNotFound", err)
+	}
+	if _, err := repo.DeleteSubscription(other, subscription.UUID, nil); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Errorf("DeleteSubscription() from another organization error = %v, want ErrSubscriptionNotFound", err)
+	}
+	if _, err := repo.GetSubscription(base, subscription.UUID, nil); !errors.Is(err, domain.ErrTenantRequired) {
+		t.Errorf("GetSubscription() without organization error = %v, want ErrTenantRequired", err)
+	}
+
+	got, err := repo.GetSubscription(own, subscription.UUID, nil)
+	if err != nil {
+		t.Fatalf("GetSubscription() error = %v", err)
+	}
+	if got.ServiceName != "Netflix" || got.OrganizationID != subscription.OrganizationID {
+		t.Errorf("GetSubscription() = %+v, want the unchanged subscription of its organization", got)
+	}
+	if _, err := repo.DeleteSubscription(own, subscription.UUID, nil); err != nil {
+		t.Errorf("DeleteSubscription() error = %v", err)
+	}
+}
+
+func TestSubscriptionSource(t *testing.T) {
+	var current filterBuilder
+	current.add("organization_id = ?", "org")
+	if source := subscriptionSource(&current, nil); source != "subscriptions" || len(current.conditions) != 1 {
+		t.Errorf("subscriptionSource(nil) = %q with %d conditions, want subscriptions without a period", source, len(current.conditions))
+	}
+
+	asOf := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var history filterBuilder
+	history.add("organization_id = ?", "org")
+	if source := subscriptionSource(&history, &asOf); source != "subscriptions_history" {
+		t.Errorf("subscriptionSource(asOf) = %q, want subscriptions_history", source)
+	}
+	wantWhere := " WHERE (organization_id = $1) AND (valid_from <= $2 AND (valid_to IS NULL OR valid_to > $2))"
+	if history.where() != wantWhere || len(history.args) != 2 || history.args[1] != asOf {
+		t.Errorf("where() = %q with %v, want %q with the moment", history.where(), history.args, wantWhere)
+	}
+}
+
+// TestSubscriptionAsOf запросы на момент времени видят версию подписки, действовавшую
+// тогда, в том числе удаленную позже
+func TestSubscriptionAsOf(t *testing.T) {
+	pool := pgtest.Pool(t)
+	repo := NewSubscription(pool, slog.New(slog.DiscardHandler))
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "test")
+	ctx = domain.WithTenant(ctx, uuid.New())
+
+	// моменты берутся по часам базы, потому что версии отмечает триггер
+	dbNow := func() time.Time {
+		t.Helper()
+		var now time.Time
+		if err := pool.QueryRow(ctx, `SELECT clock_timestamp()`).Scan(&now); err != nil {
+			t.Fatalf("read database time: %v", err)
+		}
+		return now
+	}
+
+	userID := uuid.New()
+	subscription := &domain.Subscription{
+		UUID:        uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserUUID:    userID,
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	beforeCreate := dbNow()
+	if err := repo.CreateSubscription(ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+	created := dbNow()
+	params := &domain.UpdateSubscriptionParams{ServiceName: "Netflix", Price: 700}
+	if _, err := repo.UpdateSubscription(ctx, subscription.UUID, params, nil); err != nil {
+		t.Fatalf("UpdateSubscription() error = %v", err)
+	}
+	updated := dbNow()
+	if _, err := repo.DeleteSubscription(ctx, subscription.UUID, nil); err != nil {
+		t.Fatalf("DeleteSubscription() error = %v", err)
+	}
+	deleted := dbNow()
+
+	tests := []struct {
+		name      string
+		asOf      *time.Time
+		wantPrice int
+	}{
+		{name: "before creation", asOf: &beforeCreate},
+		{name: "after creation", asOf: &created, wantPrice: 500},
+		{name: "after update", asOf: &updated, wantPrice: 700},
+		{name: "after deletion", asOf: &deleted},
+		{name: "current state"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetSubscription(ctx, subscription.UUID, tt.asOf)
+			if tt.wantPrice == 0 {
+				if !errors.Is(err, domain.ErrSubscriptionNotFound) {
+					t.Errorf("GetSubscription() = %v, %v; want ErrSubscriptionNotFound", got, err)
+				}
+			} else if err != nil || got.Price != tt.wantPrice {
+				t.Errorf("GetSubscription() = %v, %v; want price %d", got, err, tt.wantPrice)
+			}
+
+			page, err := repo.ListSubscriptions(ctx, &domain.ListSubscriptionParams{
+				Filter: domain.SubscriptionFilter{UserID: &userID},
+				Limit:  10,
+				AsOf:   tt.asOf,
+			})
+			if err != nil {
+				t.Fatalf("ListSubscriptions() error = %v", err)
+			}
+			var listed []int
+			for _, subscription := range page.Subscriptions {
+				listed = append(listed, subscription.Price)
+			}
+			if (tt.wantPrice == 0 && len(listed) != 0) || (tt.wantPrice != 0 && (len(listed) != 1 || listed[0] != tt.wantPrice)) {
+				t.Errorf("ListSubscriptions() prices = %v, want [%d] or none", listed, tt.wantPrice)
+			}
+
+			total, err := repo.TotalCostSubscriptions(ctx, &domain.TotalCostSubscriptionsParams{
+				Filter:    domain.SubscriptionFilter{UserID: &userID},
+				StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+				AsOf:      tt.asOf,
+			})
+			if err != nil || total != tt.wantPrice {
+				t.Errorf("TotalCostSubscriptions() = %d, %v; want %d", total, err, tt.wantPrice)
+			}
+		})
+	}
+}
+
+// TestSubscriptionStats показатели считаются по подпискам всех организаций,
+// действующим в текущем месяце
+func TestSubscriptionStats(t *testing.T) {
+	pool := pgtest.Pool(t)
+	repo := NewSubscription(pool, slog.New(slog.DiscardHandler))
+	base := context.WithValue(context.Background(), middleware.RequestIDKey, "test")
+
+	month := time.Now().UTC()
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastYear := month.AddDate(-1, 0, 0)
+	nextYear := month.AddDate(1, 0, 0)
+
+	for _, subscription := range []*domain.Subscription{
+		{Price: 500, StartDate: lastYear},
+		{Price: 300, StartDate: month, EndDate: &month},
+		{Price: 1000, StartDate: lastYear, EndDate: &lastYear},
+		{Price: 2000, StartDate: nextYear},
+	} {
+		subscription.UUID, subscription.UserUUID, subscription.ServiceName = uuid.New(), uuid.New(), "Netflix"
+		if err := repo.CreateSubscription(domain.WithTenant(base, uuid.New()), subscription); err != nil {
+			t.Fatalf("CreateSubscription() error = %v", err)
+		}
+	}
+
+	stats, err := repo.SubscriptionStats(context.Background())
+	if err != nil {
+		t.Fatalf("SubscriptionStats() error = %v", err)
+	}
+	if stats.ActiveSubscriptions != 2 || stats.MonthlyRunRate != 800 {
+		t.Errorf("SubscriptionStats() = %+v, want 2 active with run rate 800", stats)
+	}
+}
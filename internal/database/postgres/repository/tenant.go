@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// tenantID организация запроса. Без нее запросы к данным организаций не выполняются,
+// чтобы ошибка в цепочке вызовов не открыла данные всех организаций.
+func tenantID(ctx context.Context) (uuid.UUID, error) {
+	organizationID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return uuid.Nil, domain.ErrTenantRequired
+	}
+
+	return organizationID, nil
+}
+
+// setTenant включает политики Row-Level Security для организации запроса до конца
+// транзакции: set_config(..., true) — параметризуемая форма SET LOCAL app.tenant_id.
+// Соединение возвращается в пул без настройки, потому что она действует только в транзакции.
+func setTenant(ctx context.Context, tx pgx.Tx) error {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, organizationID.String())
+	return err
+}
+
+// setAllTenants снимает ограничение политик для фоновых задач, которые обрабатывают
+// данные всех организаций
+func setAllTenants(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('app.all_tenants', 'on', true)`)
+	return err
+}
+
+// beginTenantTx открывает транзакцию с политиками организации запроса. Если db уже
+// транзакция, открывается savepoint в ней.
+func beginTenantTx(ctx context.Context, db querier) (pgx.Tx, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := setTenant(ctx, tx); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// inTenantTx выполняет fn в транзакции с политиками организации запроса
+func inTenantTx(ctx context.Context, db querier, fn func(tx pgx.Tx, organizationID uuid.UUID) error) error {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginTenantTx(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx, organizationID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// inAllTenantsTx выполняет fn в транзакции без ограничения политик: для запросов,
+// которые выполняются до определения организации (аутентификация) или обходят все
+// организации (фоновые задачи)
+func inAllTenantsTx(ctx context.Context, db querier, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setAllTenants(ctx, tx); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	webhookColumns  = `id, organization_id, url, secret, events, active, created_at, updated_at`
+	deliveryColumns = `id, organization_id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, created_at, updated_at`
+)
+
+type Webhook struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewWebhook(pool *pgxpool.Pool, baseLogger *slog.Logger) *Webhook {
+	logger := baseLogger.WithGroup("webhook repository")
+
+	return &Webhook{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// CreateWebhook создает вебхук в организации запроса: он получает события только ее подписок
+func (w *Webhook) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	defer metrics.ObserveRepository("webhook", "CreateWebhook", time.Now())
+
+	return inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		webhook.OrganizationID = organizationID
+		query := `INSERT INTO webhooks (id, organization_id, url, secret, events, active) VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING created_at, updated_at`
+
+		return tx.QueryRow(ctx, query, webhook.UUID, webhook.OrganizationID, webhook.URL, webhook.Secret, eventNames(webhook.Events), webhook.Active).
+			Scan(&webhook.CreatedAt, &webhook.UpdatedAt)
+	})
+}
+
+func (w *Webhook) GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	defer metrics.ObserveRepository("webhook", "GetWebhook", time.Now())
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND organization_id = $2`
+	var webhook *domain.Webhook
+	err := inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		var err error
+		webhook, err = scanWebhook(tx.QueryRow(ctx, query, id, organizationID))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWebhookNotFound
+	}
+
+	return webhook, err
+}
+
+func (w *Webhook) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	defer metrics.ObserveRepository("webhook", "ListWebhooks", time.Now())
+
+	webhooks := make([]*domain.Webhook, 0)
+	err := inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE organization_id = $1 ORDER BY created_at`
+		rows, err := tx.Query(ctx, query, organizationID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			webhook, err := scanWebhook(rows)
+			if err != nil {
+				return err
+			}
+			webhooks = append(webhooks, webhook)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (w *Webhook) UpdateWebhook(ctx context.Context, id uuid.UUID, params *domain.UpdateWebhookParams) (*domain.Webhook, error) {
+	defer metrics.ObserveRepository("webhook", "UpdateWebhook", time.Now())
+
+	query := `UPDATE webhooks SET url = $1, secret = COALESCE(NULLIF($2, ''), secret), events = $3, active = $4, updated_at = NOW()
+		WHERE id = $5 AND organization_id = $6 RETURNING ` + webhookColumns
+	var webhook *domain.Webhook
+	err := inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		var err error
+		webhook, err = scanWebhook(tx.QueryRow(ctx, query, params.URL, params.Secret, eventNames(params.Events), params.Active, id, organizationID))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWebhookNotFound
+	}
+
+	return webhook, err
+}
+
+func (w *Webhook) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveRepository("webhook", "DeleteWebhook", time.Now())
+
+	return inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND organization_id = $2`, id, organizationID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrWebhookNotFound
+		}
+
+		return nil
+	})
+}
+
+// CreateDeliveries ставит отправки в очередь одним COPY. Отправки относятся к организации
+// запроса, вебхук должен принадлежать ей же.
+func (w *Webhook) CreateDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	defer metrics.ObserveRepository("webhook", "CreateDeliveries", time.Now())
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		columns := []string{"id", "organization_id", "webhook_id", "event_id", "event_type", "payload"}
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"webhook_deliveries"}, columns, pgx.CopyFromSlice(len(deliveries), func(i int) ([]any, error) {
+			delivery := deliveries[i]
+			delivery.OrganizationID = organizationID
+			return []any{delivery.UUID, delivery.OrganizationID, delivery.WebhookUUID, delivery.EventID, string(delivery.EventType), delivery.Payload}, nil
+		}))
+
+		return err
+	})
+}
+
+// EnqueueEvent создает отправки события всем включенным вебхукам организации события,
+// подписанным на его тип. Вебхуки, для которых отправка этого события уже есть, пропускаются.
+// Вызывается relay outbox, который обрабатывает события всех организаций.
+func (w *Webhook) EnqueueEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	defer metrics.ObserveRepository("webhook", "EnqueueEvent", time.Now())
+
+	query := `INSERT INTO webhook_deliveries (id, organization_id, webhook_id, event_id, event_type, payload)
+		SELECT gen_random_uuid(), wh.organization_id, wh.id, $1, $2, $3 FROM webhooks wh
+		WHERE wh.organization_id = $4 AND wh.active AND (cardinality(wh.events) = 0 OR $2 = ANY(wh.events))
+			AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.webhook_id = wh.id AND d.event_id = $1)`
+
+	return inAllTenantsTx(ctx, w.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, event.EventID, string(event.Type), event.Payload, event.OrganizationID)
+		return err
+	})
+}
+
+// ClaimDueDeliveries забирает отправки, которые пора выполнить, и откладывает их
+// следующую попытку на lease. Если обработчик упадет, не записав результат,
+// отправка будет повторена после истечения lease. SKIP LOCKED позволяет
+// нескольким экземплярам сервиса разбирать очередь параллельно.
+func (w *Webhook) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.DueDelivery, error) {
+	defer metrics.ObserveRepository("webhook", "ClaimDueDeliveries", time.Now())
+
+	query := `WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $2::interval
+			FROM due WHERE d.id = due.id
+			RETURNING d.*
+		)
+		SELECT c.id, c.organization_id, c.webhook_id, c.event_id, c.event_type, c.payload, c.status, c.attempts, c.next_attempt_at,
+			c.last_status_code, c.last_error, c.created_at, c.updated_at, wh.url, wh.secret
+		FROM claimed c JOIN webhooks wh ON wh.id = c.webhook_id`
+
+	var due []*domain.DueDelivery
+	err := inAllTenantsTx(ctx, w.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, limit, lease)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var item domain.DueDelivery
+			item.Delivery, err = scanDelivery(rows, &item.URL, &item.Secret)
+			if err != nil {
+				return err
+			}
+			due = append(due, &item)
+		}
+
+		return rows.Err()
+	})
+
+	return due, err
+}
+
+// RecordAttempt сохраняет результат попытки отправки
+func (w *Webhook) RecordAttempt(ctx context.Context, attempt *domain.DeliveryAttempt) error {
+	defer metrics.ObserveRepository("webhook", "RecordAttempt", time.Now())
+
+	query := `UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1, last_status_code = $3,
+		last_error = $4, next_attempt_at = $5, updated_at = NOW() WHERE id = $1`
+
+	return inAllTenantsTx(ctx, w.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, attempt.DeliveryUUID, string(attempt.Status), attempt.StatusCode, attempt.Error, attempt.NextAttemptAt)
+		return err
+	})
+}
+
+func (w *Webhook) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	defer metrics.ObserveRepository("webhook", "ListDeliveries", time.Now())
+
+	deliveries := make([]*domain.WebhookDelivery, 0)
+	err := inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+			WHERE webhook_id = $1 AND organization_id = $2 ORDER BY created_at DESC LIMIT $3`
+		rows, err := tx.Query(ctx, query, webhookID, organizationID, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			delivery, err := scanDelivery(rows)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (w *Webhook) GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	defer metrics.ObserveRepository("webhook", "GetDelivery", time.Now())
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2 AND organization_id = $3`
+	var delivery *domain.WebhookDelivery
+	err := inTenantTx(ctx, w.pool, func(tx pgx.Tx, organizationID uuid.UUID) error {
+		var err error
+		delivery, err = scanDelivery(tx.QueryRow(ctx, query, deliveryID, webhookID, organizationID))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrDeliveryNotFound
+	}
+
+	return delivery, err
+}
+
+func scanWebhook(row pgx.Row) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	var events []string
+	err := row.Scan(&webhook.UUID, &webhook.OrganizationID, &webhook.URL, &webhook.Secret, &events, &webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		webhook.Events = append(webhook.Events, domain.EventType(event))
+	}
+
+	return &webhook, nil
+}
+
+func scanDelivery(row pgx.Row, extra ...any) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var eventType, status string
+	dest := append([]any{
+		&delivery.UUID,
+		&delivery.OrganizationID,
+		&delivery.WebhookUUID,
+		&delivery.EventID,
+		&eventType,
+		&delivery.Payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	delivery.EventType = domain.EventType(eventType)
+	delivery.Status = domain.DeliveryStatus(status)
+
+	return &delivery, nil
+}
+
+func eventNames(events []domain.EventType) []string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, string(event))
+	}
+
+	return names
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ent1k1377/subscriptions/internal/database/postgres"
+
+// QueryTracer создает спан на каждый запрос pgx. Запросы вне трассы (фоновые циклы
+// outbox, вебхуков и потока изменений) не трассируются, чтобы не порождать корневые
+// спаны каждые несколько секунд. Параметры запросов в спан не попадают.
+type QueryTracer struct {
+	tracer trace.Tracer
+}
+
+func NewQueryTracer() *QueryTracer {
+	return &QueryTracer{tracer: otel.Tracer(tracerName)}
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := queryOperation(data.SQL)
+	return t.start(ctx, operation,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(data.SQL),
+	)
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	end(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (t *QueryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return t.start(ctx, "COPY "+data.TableName.Sanitize(),
+		semconv.DBOperationName("COPY"),
+		semconv.DBCollectionName(data.TableName.Sanitize()),
+	)
+}
+
+func (t *QueryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	end(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (t *QueryTracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	ctx, _ = t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx
+}
+
+func end(ctx context.Context, rows int64, err error) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(semconv.DBResponseReturnedRows(int(rows)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// queryOperation первое слово запроса: SELECT, INSERT, WITH и т.д.
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const (
+	ScopeSubscriptionsRead  = "subscriptions:read"
+	ScopeSubscriptionsWrite = "subscriptions:write"
+	ScopeReportsRead        = "reports:read"
+)
+
+// Scopes все допустимые права API-ключей
+var Scopes = []string{ScopeSubscriptionsRead, ScopeSubscriptionsWrite, ScopeReportsRead}
+
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// APIKey ключ доступа для сервисов. Сам ключ не хранится, только его хеш;
+// Prefix нужен, чтобы отличать ключи в списке. Ключ действует только в организации,
+// в которой выпущен.
+type APIKey struct {
+	UUID           uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+	Prefix         string
+	Scopes         []string
+	ExpiresAt      *time.Time
+	LastUsedAt     *time.Time
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// IssuedAPIKey ключ вместе с секретом, который показывается один раз
+type IssuedAPIKey struct {
+	APIKey *APIKey
+	Key    string
+}
+
+type CreateAPIKeyParams struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// RotateAPIKeyParams Grace сколько прежний ключ продолжает действовать после ротации,
+// чтобы задачи успели перейти на новый
+type RotateAPIKeyParams struct {
+	Grace time.Duration
+}
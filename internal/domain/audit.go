@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAuditCursor   = errors.New("invalid audit cursor")
+	ErrInvalidAuditSnapshot = errors.New("invalid audit snapshot")
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+	// AuditActionRestore восстановление удаленной подписки
+	AuditActionRestore AuditAction = "restore"
+)
+
+// AuditChange значение поля до и после изменения
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditRecord запись журнала изменений подписки. Записи только добавляются,
+// Before пустой при создании, After — при удалении.
+type AuditRecord struct {
+	ID               int64
+	OrganizationID   uuid.UUID
+	SubscriptionUUID uuid.UUID
+	// UserUUID владелец подписки, по нему проверяется доступ к истории
+	UserUUID        uuid.UUID
+	Action          AuditAction
+	ActorUserUUID   *uuid.UUID
+	ActorAPIKeyUUID *uuid.UUID
+	RequestID       string
+	SourceIP        string
+	Before          map[string]any
+	After           map[string]any
+	Diff            map[string]AuditChange
+	OccurredAt      time.Time
+}
+
+// NewAuditRecord запись об изменении подписки без сведений о вызывающем:
+// их добавляет репозиторий из контекста запроса
+func NewAuditRecord(action AuditAction, before, after *Subscription) *AuditRecord {
+	subscription := after
+	if subscription == nil {
+		subscription = before
+	}
+
+	record := &AuditRecord{
+		OrganizationID:   subscription.OrganizationID,
+		SubscriptionUUID: subscription.UUID,
+		UserUUID:         subscription.UserUUID,
+		Action:           action,
+		Before:           auditSnapshot(before),
+		After:            auditSnapshot(after),
+		OccurredAt:       time.Now().UTC(),
+	}
+	record.Diff = auditDiff(record.Before, record.After)
+
+	return record
+}
+
+// auditSnapshot поля подписки, которые попадают в журнал. Даты в формате MM-YYYY, как в HTTP API.
+func auditSnapshot(subscription *Subscription) map[string]any {
+	if subscription == nil {
+		return nil
+	}
+
+	var endDate any
+	if subscription.EndDate != nil {
+		endDate = subscription.EndDate.Format("01-2006")
+	}
+
+	return map[string]any{
+		"service_name": subscription.ServiceName,
+		"price":        subscription.Price,
+		"user_id":      subscription.UserUUID.String(),
+		"start_date":   subscription.StartDate.Format("01-2006"),
+		"end_date":     endDate,
+		"notes":        subscription.Notes,
+	}
+}
+
+// BeforeSubscription подписка в состоянии до изменения, восстановленная из снимка записи,
+// см. auditSnapshot. Даты создания и изменения в снимок не входят и остаются пустыми.
+func (r *AuditRecord) BeforeSubscription() (*Subscription, error) {
+	if r.Before == nil {
+		return nil, ErrInvalidAuditSnapshot
+	}
+
+	serviceName, ok := r.Before["service_name"].(string)
+	if !ok {
+		return nil, ErrInvalidAuditSnapshot
+	}
+	// JSON хранит числа как float64
+	price, ok := r.Before["price"].(float64)
+	if !ok {
+		return nil, ErrInvalidAuditSnapshot
+	}
+	notes, _ := r.Before["notes"].(string)
+
+	startDate, err := auditSnapshotDate(r.Before["start_date"])
+	if err != nil {
+		return nil, err
+	}
+	var endDate *time.Time
+	if r.Before["end_date"] != nil {
+		date, err := auditSnapshotDate(r.Before["end_date"])
+		if err != nil {
+			return nil, err
+		}
+		endDate = &date
+	}
+
+	return &Subscription{
+		UUID:           r.SubscriptionUUID,
+		OrganizationID: r.OrganizationID,
+		ServiceName:    serviceName,
+		Price:          int(price),
+		UserUUID:       r.UserUUID,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Notes:          notes,
+	}, nil
+}
+
+func auditSnapshotDate(value any) (time.Time, error) {
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, ErrInvalidAuditSnapshot
+	}
+	date, err := time.Parse("01-2006", text)
+	if err != nil {
+		return time.Time{}, ErrInvalidAuditSnapshot
+	}
+
+	return date, nil
+}
+
+func auditDiff(before, after map[string]any) map[string]AuditChange {
+	diff := make(map[string]AuditChange)
+	for field, value := range after {
+		if previous, ok := before[field]; !ok || !reflect.DeepEqual(previous, value) {
+			diff[field] = AuditChange{From: previous, To: value}
+		}
+	}
+	for field, previous := range before {
+		if _, ok := after[field]; !ok {
+			diff[field] = AuditChange{From: previous, To: nil}
+		}
+	}
+
+	return diff
+}
+
+// AuditFilter фильтр поиска по журналу, пустые поля не ограничивают выборку
+type AuditFilter struct {
+	SubscriptionUUID *uuid.UUID
+	UserUUID         *uuid.UUID
+	ActorUUID        *uuid.UUID
+	Action           *AuditAction
+	RequestID        *string
+	From             *time.Time
+	To               *time.Time
+}
+
+// SearchAuditParams Cursor id записи, после которой продолжается выдача от новых к старым
+type SearchAuditParams struct {
+	Filter AuditFilter
+	Limit  int
+	Cursor string
+}
+
+type AuditPage struct {
+	Records    []*AuditRecord
+	NextCursor string
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testAuditSubscription() *Subscription {
+	endDate := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	return &Subscription{
+		UUID:           uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		OrganizationID: uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8"),
+		ServiceName:    "Netflix",
+		Price:          500,
+		UserUUID:       uuid.MustParse("6ba7b812-9dad-11d1-80b4-00c04fd430c8"),
+		StartDate:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:        &endDate,
+		Notes:          "family plan",
+	}
+}
+
+func TestNewAuditRecordDiff(t *testing.T) {
+	before := testAuditSubscription()
+	after := *before
+	after.Price = 700
+	after.EndDate = nil
+
+	tests := []struct {
+		name       string
+		action     AuditAction
+		before     *Subscription
+		after      *Subscription
+		wantFields []string
+	}{
+		{name: "create", action: AuditActionCreate, after: before,
+			wantFields: []string{"end_date", "notes", "price", "service_name", "start_date", "user_id"}},
+		{name: "update", action: AuditActionUpdate, before: before, after: &after, wantFields: []string{"end_date", "price"}},
+		{name: "delete", action: AuditActionDelete, before: before,
+			wantFields: []string{"end_date", "notes", "price", "service_name", "start_date", "user_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := NewAuditRecord(tt.action, tt.before, tt.after)
+			if record.SubscriptionUUID != before.UUID || record.OrganizationID != before.OrganizationID || record.UserUUID != before.UserUUID {
+				t.Errorf("record identifies %s in %s, want the subscription", record.SubscriptionUUID, record.OrganizationID)
+			}
+			if (tt.before == nil) != (record.Before == nil) || (tt.after == nil) != (record.After == nil) {
+				t.Errorf("Before = %v, After = %v; want snapshots only for the given states", record.Before, record.After)
+			}
+
+			if fields := slices.Sorted(maps.Keys(record.Diff)); !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("Diff fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+
+	diff := NewAuditRecord(AuditActionUpdate, before, &after).Diff
+	if diff["price"] != (AuditChange{From: 500, To: 700}) || diff["end_date"] != (AuditChange{From: "12-2025", To: nil}) {
+		t.Errorf("Diff = %v, want price 500 -> 700 and end_date 12-2025 -> null", diff)
+	}
+}
+
+// TestAuditRecordBeforeSubscription снимок, прочитанный из JSONB, восстанавливается
+// в подписку без потерь
+func TestAuditRecordBeforeSubscription(t *testing.T) {
+	subscription := testAuditSubscription()
+	withoutEnd := testAuditSubscription()
+	withoutEnd.EndDate = nil
+
+	for _, want := range []*Subscription{subscription, withoutEnd} {
+		record := NewAuditRecord(AuditActionDelete, want, nil)
+		data, err := json.Marshal(record.Before)
+		if err != nil {
+			t.Fatalf("marshal snapshot: %v", err)
+		}
+		record.Before = nil
+		if err := json.Unmarshal(data, &record.Before); err != nil {
+			t.Fatalf("unmarshal snapshot: %v", err)
+		}
+
+		got, err := record.BeforeSubscription()
+		if err != nil {
+			t.Fatalf("BeforeSubscription() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("BeforeSubscription() = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestAuditRecordBeforeSubscriptionInvalid(t *testing.T) {
+	valid := func() map[string]any {
+		return map[string]any{
+			"service_name": "Netflix",
+			"price":        float64(500),
+			"user_id":      uuid.NewString(),
+			"start_date":   "01-2025",
+			"end_date":     nil,
+			"notes":        "",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		before map[string]any
+	}{
+		{name: "no snapshot"},
+		{name: "no service name", before: func() map[string]any { b := valid(); delete(b, "service_name"); return b }()},
+		{name: "price is a string", before: func() map[string]any { b := valid(); b["price"] = "500"; return b }()},
+		{name: "bad start date", before: func() map[string]any { b := valid(); b["start_date"] = "2025-01-01"; return b }()},
+		{name: "bad end date", before: func() map[string]any { b := valid(); b["end_date"] = "13-2025"; return b }()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &AuditRecord{Action: AuditActionDelete, Before: tt.before}
+			if _, err := record.BeforeSubscription(); !errors.Is(err, ErrInvalidAuditSnapshot) {
+				t.Errorf("BeforeSubscription() error = %v, want ErrInvalidAuditSnapshot", err)
+			}
+		})
+	}
+}
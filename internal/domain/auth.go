@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+// Principal вызывающий, подтвержденный токеном. Subject совпадает с UserUUID его подписок.
+type Principal struct {
+	Subject uuid.UUID
+	Roles   []Role
+	// OrganizationID организация из токена или API-ключа. Без нее вызывающий работает
+	// в организации по умолчанию, см. ResolveTenant.
+	OrganizationID *uuid.UUID
+	// APIKeyUUID ключ, которым аутентифицирован сервис. Права ключа определяются
+	// его Scopes, а не ролями.
+	APIKeyUUID *uuid.UUID
+	Scopes     []string
+}
+
+// Grants права вызывающего по его ролям или scopes API-ключа
+func (p *Principal) Grants() []Grant {
+	var grants []Grant
+	if p.APIKeyUUID != nil {
+		for _, scope := range p.Scopes {
+			for _, permission := range scopePermissions[scope] {
+				grants = append(grants, Grant{Permission: permission, AllUsers: true})
+			}
+		}
+		return grants
+	}
+
+	for _, role := range p.Roles {
+		grants = append(grants, rolePermissions[role]...)
+	}
+	return grants
+}
+
+// Has есть ли у вызывающего право хотя бы на собственные данные
+func (p *Principal) Has(permission Permission) bool {
+	return slices.ContainsFunc(p.Grants(), func(grant Grant) bool {
+		return grant.Permission == permission
+	})
+}
+
+// CanAllUsers распространяется ли право на данные всех пользователей
+func (p *Principal) CanAllUsers(permission Permission) bool {
+	return slices.Contains(p.Grants(), Grant{Permission: permission, AllUsers: true})
+}
+
+// Can может ли вызывающий выполнить действие с данными пользователя
+func (p *Principal) Can(permission Permission, userID uuid.UUID) bool {
+	if p.CanAllUsers(permission) {
+		return true
+	}
+
+	return p.APIKeyUUID == nil && p.Subject == userID && p.Has(permission)
+}
+
+// HasRole назначена ли роль. У API-ключей ролей нет.
+func (p *Principal) HasRole(role Role) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Authenticator проверяет токен доступа. Ошибка оборачивает ErrUnauthenticated.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает вызывающего. Его нет у внутренних вызовов
+// (фоновые задачи, аутентификация выключена), для них проверки доступа не выполняются.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
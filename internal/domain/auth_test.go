@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPrincipalCan(t *testing.T) {
+	self := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	other := uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+	keyID := uuid.MustParse("6ba7b812-9dad-11d1-80b4-00c04fd430c8")
+
+	user := &Principal{Subject: self, Roles: []Role{RoleUser}}
+	support := &Principal{Subject: self, Roles: []Role{RoleSupport}}
+	userAndSupport := &Principal{Subject: self, Roles: []Role{RoleUser, RoleSupport}}
+	admin := &Principal{Subject: self, Roles: []Role{RoleAdmin}}
+	noRoles := &Principal{Subject: self}
+	readKey := &Principal{Subject: self, APIKeyUUID: &keyID, Scopes: []string{ScopeSubscriptionsRead}}
+	// роли не действуют для API-ключа, даже если попали в Principal
+	keyWithRoles := &Principal{Subject: self, APIKeyUUID: &keyID, Roles: []Role{RoleAdmin}}
+
+	tests := []struct {
+		name       string
+		principal  *Principal
+		permission Permission
+		userID     uuid.UUID
+		want       bool
+	}{
+		{name: "user reads own", principal: user, permission: PermissionSubscriptionsRead, userID: self, want: true},
+		{name: "user reads other", principal: user, permission: PermissionSubscriptionsRead, userID: other, want: false},
+		{name: "user deletes own", principal: user, permission: PermissionSubscriptionsDelete, userID: self, want: true},
+		{name: "user manages webhooks", principal: user, permission: PermissionWebhooksManage, userID: self, want: false},
+		{name: "support reads other", principal: support, permission: PermissionSubscriptionsRead, userID: other, want: true},
+		{name: "support writes own", principal: support, permission: PermissionSubscriptionsWrite, userID: self, want: false},
+		{name: "roles add up", principal: userAndSupport, permission: PermissionSubscriptionsWrite, userID: self, want: true},
+		{name: "added roles do not widen grants", principal: userAndSupport, permission: PermissionSubscriptionsWrite, userID: other, want: false},
+		{name: "admin writes other", principal: admin, permission: PermissionSubscriptionsWrite, userID: other, want: true},
+		{name: "admin manages roles", principal: admin, permission: PermissionRolesManage, userID: other, want: true},
+		{name: "admin reads audit", principal: admin, permission: PermissionAuditRead, userID: other, want: true},
+		{name: "support reads audit", principal: support, permission: PermissionAuditRead, userID: other, want: false},
+		{name: "no roles", principal: noRoles, permission: PermissionSubscriptionsRead, userID: self, want: false},
+		{name: "key scope covers all users", principal: readKey, permission: PermissionSubscriptionsExport, userID: other, want: true},
+		{name: "key without scope", principal: readKey, permission: PermissionSubscriptionsWrite, userID: self, want: false},
+		{name: "key ignores roles", principal: keyWithRoles, permission: PermissionSubscriptionsRead, userID: self, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Can(tt.permission, tt.userID); got != tt.want {
+				t.Fatalf("Can(%s, %s) = %v, want %v", tt.permission, tt.userID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrincipalGrants(t *testing.T) {
+	keyID := uuid.New()
+
+	tests := []struct {
+		name         string
+		principal    *Principal
+		permission   Permission
+		wantHas      bool
+		wantAllUsers bool
+	}{
+		{name: "user own data only", principal: &Principal{Roles: []Role{RoleUser}}, permission: PermissionReportsRead, wantHas: true},
+		{
+			name:         "support all users",
+			principal:    &Principal{Roles: []Role{RoleSupport}},
+			permission:   PermissionReportsRead,
+			wantHas:      true,
+			wantAllUsers: true,
+		},
+		{
+			name:         "widest grant wins",
+			principal:    &Principal{Roles: []Role{RoleUser, RoleSupport}},
+			permission:   PermissionSubscriptionsRead,
+			wantHas:      true,
+			wantAllUsers: true,
+		},
+		{name: "unknown role", principal: &Principal{Roles: []Role{"owner"}}, permission: PermissionSubscriptionsRead},
+		{
+			name:         "write scope",
+			principal:    &Principal{APIKeyUUID: &keyID, Scopes: []string{ScopeSubscriptionsWrite}},
+			permission:   PermissionSubscriptionsDelete,
+			wantHas:      true,
+			wantAllUsers: true,
+		},
+		{
+			name:       "unknown scope",
+			principal:  &Principal{APIKeyUUID: &keyID, Scopes: []string{"roles:manage"}},
+			permission: PermissionRolesManage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Has(tt.permission); got != tt.wantHas {
+				t.Errorf("Has(%s) = %v, want %v", tt.permission, got, tt.wantHas)
+			}
+			if got := tt.principal.CanAllUsers(tt.permission); got != tt.wantAllUsers {
+				t.Errorf("CanAllUsers(%s) = %v, want %v", tt.permission, got, tt.wantAllUsers)
+			}
+		})
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	if _, ok := PrincipalFromContext(t.Context()); ok {
+		t.Fatal("empty context has a principal")
+	}
+	if _, ok := PrincipalFromContext(WithPrincipal(t.Context(), nil)); ok {
+		t.Fatal("nil principal was returned")
+	}
+
+	principal := &Principal{Subject: uuid.New()}
+	if got, ok := PrincipalFromContext(WithPrincipal(t.Context(), principal)); !ok || got != principal {
+		t.Fatalf("PrincipalFromContext() = %v, %v, want %v", got, ok, principal)
+	}
+}
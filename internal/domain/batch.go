@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type BatchMode string
+
+const (
+	BatchModeAtomic     BatchMode = "atomic"
+	BatchModeBestEffort BatchMode = "best_effort"
+)
+
+type BatchOperationType string
+
+const (
+	BatchOperationCreate BatchOperationType = "create"
+	BatchOperationUpdate BatchOperationType = "update"
+	BatchOperationDelete BatchOperationType = "delete"
+)
+
+// BatchOperation одна операция пакета. Index хранит позицию операции в исходном
+// запросе, чтобы результат можно было сопоставить с ней.
+type BatchOperation struct {
+	Index  int
+	Type   BatchOperationType
+	UUID   uuid.UUID
+	Create *CreateSubscriptionParams
+	Update *UpdateSubscriptionParams
+}
+
+type BatchParams struct {
+	Mode       BatchMode
+	Operations []*BatchOperation
+}
+
+type BatchItemResult struct {
+	Index        int
+	Subscription *Subscription
+	Err          error
+}
+
+// BatchItemError ошибка операции, из-за которой откатился атомарный пакет
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidFeedToken = errors.New("invalid calendar feed token")
+
+// CalendarSubscription подписка в календаре с периодом продления. Цена подписки
+// месячная, но подписка, подтвержденная из годового кандидата выписки, продлевается раз в год.
+// Пустой Period — период неизвестен, продления не повторяются.
+type CalendarSubscription struct {
+	Subscription *Subscription
+	Period       RecurrencePeriod
+}
+
+// CalendarFeedToken секрет ссылки на календарь пользователя. Token известен
+// только в момент выпуска, в базе хранится его хеш.
+type CalendarFeedToken struct {
+	UserUUID  uuid.UUID
+	Token     string
+	CreatedAt time.Time
+}
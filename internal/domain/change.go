@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrChangeStreamLagged клиент не успевал забирать изменения, поток закрыт.
+	// Клиент может переподключиться с Last-Event-ID и дочитать пропущенное.
+	ErrChangeStreamLagged = errors.New("change stream lagged behind")
+	ErrChangeStreamClosed = errors.New("change stream closed")
+)
+
+// SubscriptionChange изменение подписки, записанное триггером в subscription_changes.
+// ID монотонно растет и используется как id события SSE.
+type SubscriptionChange struct {
+	ID           int64
+	Type         EventType
+	OccurredAt   time.Time
+	Subscription *Subscription
+}
+
+// ChangeFilter фильтр потока изменений, пустые поля не ограничивают выборку
+type ChangeFilter struct {
+	OrganizationID *uuid.UUID
+	UserUUID       *uuid.UUID
+	ServiceName    *string
+}
+
+// Matches название сервиса сравнивается без учета регистра
+func (f *ChangeFilter) Matches(change *SubscriptionChange) bool {
+	if f.OrganizationID != nil && *f.OrganizationID != change.Subscription.OrganizationID {
+		return false
+	}
+	if f.UserUUID != nil && *f.UserUUID != change.Subscription.UserUUID {
+		return false
+	}
+	if f.ServiceName != nil && !strings.EqualFold(*f.ServiceName, change.Subscription.ServiceName) {
+		return false
+	}
+
+	return true
+}
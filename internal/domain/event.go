@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventSubscriptionCreated    EventType = "subscription.created"
+	EventSubscriptionUpdated    EventType = "subscription.updated"
+	EventSubscriptionDeleted    EventType = "subscription.deleted"
+	EventSubscriptionEndingSoon EventType = "subscription.ending_soon"
+)
+
+// Event изменение подписки, о котором уведомляются внешние системы
+type Event struct {
+	ID           uuid.UUID
+	Type         EventType
+	OccurredAt   time.Time
+	Subscription *Subscription
+}
+
+func NewEvent(eventType EventType, subscription *Subscription) *Event {
+	return &Event{
+		ID:           uuid.New(),
+		Type:         eventType,
+		OccurredAt:   time.Now().UTC(),
+		Subscription: subscription,
+	}
+}
+
+type eventJSON struct {
+	ID         string           `json:"id"`
+	Type       EventType        `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Data       subscriptionJSON `json:"data"`
+}
+
+type subscriptionJSON struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id"`
+	ServiceName    string    `json:"service_name"`
+	Price          int       `json:"price"`
+	UserID         string    `json:"user_id"`
+	StartDate      string    `json:"start_date"`
+	EndDate        *string   `json:"end_date"`
+	Notes          string    `json:"notes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// MarshalJSON формат события для внешних получателей: {id, type, occurred_at, data}.
+// Даты подписки передаются в формате MM-YYYY, как в HTTP API.
+func (e *Event) MarshalJSON() ([]byte, error) {
+	subscription := e.Subscription
+
+	var endDate *string
+	if subscription.EndDate != nil {
+		formatted := subscription.EndDate.Format("01-2006")
+		endDate = &formatted
+	}
+
+	return json.Marshal(&eventJSON{
+		ID:         e.ID.String(),
+		Type:       e.Type,
+		OccurredAt: e.OccurredAt,
+		Data: subscriptionJSON{
+			ID:             subscription.UUID.String(),
+			OrganizationID: subscription.OrganizationID.String(),
+			ServiceName:    subscription.ServiceName,
+			Price:          subscription.Price,
+			UserID:         subscription.UserUUID.String(),
+			StartDate:      subscription.StartDate.Format("01-2006"),
+			EndDate:        endDate,
+			Notes:          subscription.Notes,
+			CreatedAt:      subscription.CreatedAt,
+			UpdatedAt:      subscription.UpdatedAt,
+		},
+	})
+}
+
+// OutboxEvent событие, записанное в outbox той же транзакцией, что и изменение.
+// EventID служит ключом дедупликации: при повторной отправке он не меняется.
+type OutboxEvent struct {
+	Position       int64
+	EventID        uuid.UUID
+	OrganizationID uuid.UUID
+	Type           EventType
+	AggregateID    uuid.UUID
+	Payload        []byte
+	Attempts       int
+	CreatedAt      time.Time
+}
+
+// EventSink получатель событий из outbox. Доставка «хотя бы один раз»: после сбоя
+// событие отправляется повторно, получатель отбрасывает дубли по EventID.
+type EventSink interface {
+	Name() string
+	Send(ctx context.Context, event *OutboxEvent) error
+}
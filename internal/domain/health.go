@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type HealthStatus string
+
+const (
+	HealthStatusUp   HealthStatus = "up"
+	HealthStatusDown HealthStatus = "down"
+)
+
+// DependencyHealth результат проверки одной зависимости
+type DependencyHealth struct {
+	Name    string
+	Status  HealthStatus
+	Latency time.Duration
+	// Error причина недоступности, пусто для работающей зависимости
+	Error   string
+	Details map[string]any
+}
+
+// Health состояние сервиса. Status — up, только если все зависимости доступны и
+// сервис не начал остановку: тогда он готов принимать трафик.
+type Health struct {
+	Status       HealthStatus
+	ShuttingDown bool
+	Dependencies []*DependencyHealth
+}
+
+func (h *Health) Ready() bool {
+	return h.Status == HealthStatusUp
+}
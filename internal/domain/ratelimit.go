@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RateLimit правило token bucket: корзина емкостью Burst пополняется на Rate токенов
+// в секунду, каждый запрос забирает один токен
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitDecision результат попытки забрать токен
+type RateLimitDecision struct {
+	Allowed bool
+	Limit   int
+	// Remaining сколько запросов еще можно выполнить без ожидания
+	Remaining int
+	// RetryAfter через сколько появится токен, если запрос отклонен
+	RetryAfter time.Duration
+	// Reset через сколько корзина наполнится полностью
+	Reset time.Duration
+}
+
+// RateLimiter хранилище корзин. Take пополняет корзину key за прошедшее время и
+// забирает из нее токен, если он есть.
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit RateLimit) (*RateLimitDecision, error)
+}
+
+// Refill сколько токенов будет в корзине через elapsed
+func (l RateLimit) Refill(tokens float64, elapsed time.Duration) float64 {
+	return min(float64(l.Burst), tokens+max(elapsed.Seconds(), 0)*l.Rate)
+}
+
+// Decision решение по числу токенов, оставшихся в корзине после попытки
+func (l RateLimit) Decision(tokens float64, allowed bool) *RateLimitDecision {
+	decision := &RateLimitDecision{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     l.duration(float64(l.Burst) - tokens),
+	}
+	if !allowed {
+		decision.RetryAfter = l.duration(1 - tokens)
+	}
+
+	return decision
+}
+
+// duration за сколько корзина пополнится на tokens
+func (l RateLimit) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
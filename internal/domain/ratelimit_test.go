@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitRefill(t *testing.T) {
+	limit := RateLimit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{name: "no time passed", tokens: 3, elapsed: 0, want: 3},
+		{name: "refills at rate", tokens: 3, elapsed: 1500 * time.Millisecond, want: 6},
+		{name: "capped at burst", tokens: 3, elapsed: time.Hour, want: 10},
+		{name: "clock going back does not drain", tokens: 3, elapsed: -time.Second, want: 3},
+		{name: "from empty", tokens: 0, elapsed: 250 * time.Millisecond, want: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limit.Refill(tt.tokens, tt.elapsed); got != tt.want {
+				t.Fatalf("Refill(%v, %s) = %v, want %v", tt.tokens, tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitDecision(t *testing.T) {
+	limit := RateLimit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		allowed bool
+		want    RateLimitDecision
+	}{
+		{
+			name:    "allowed with tokens left",
+			tokens:  7.5,
+			allowed: true,
+			want:    RateLimitDecision{Allowed: true, Limit: 10, Remaining: 7, Reset: 1250 * time.Millisecond},
+		},
+		{
+			name:    "allowed last token",
+			tokens:  0,
+			allowed: true,
+			want:    RateLimitDecision{Allowed: true, Limit: 10, Remaining: 0, Reset: 5 * time.Second},
+		},
+		{
+			name:    "rejected waits for the missing part of a token",
+			tokens:  0.5,
+			allowed: false,
+			want: RateLimitDecision{
+				Allowed: false, Limit: 10, Remaining: 0, RetryAfter: 250 * time.Millisecond, Reset: 4750 * time.Millisecond,
+			},
+		},
+		{
+			name:    "full bucket",
+			tokens:  10,
+			allowed: true,
+			want:    RateLimitDecision{Allowed: true, Limit: 10, Remaining: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limit.Decision(tt.tokens, tt.allowed); *got != tt.want {
+				t.Fatalf("Decision(%v, %v) = %+v, want %+v", tt.tokens, tt.allowed, *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrRoleAssignmentNotFound = errors.New("role assignment not found")
+
+type Role string
+
+const (
+	// RoleUser работает только со своими подписками, есть у любого пользователя с JWT
+	RoleUser Role = "user"
+	// RoleSupport читает подписки и отчеты всех пользователей, но ничего не меняет
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// Roles все роли, которые можно назначить
+var Roles = []Role{RoleUser, RoleSupport, RoleAdmin}
+
+func IsValidRole(role Role) bool {
+	return slices.Contains(Roles, role)
+}
+
+type Permission string
+
+const (
+	PermissionSubscriptionsRead   Permission = "subscriptions:read"
+	PermissionSubscriptionsWrite  Permission = "subscriptions:write"
+	PermissionSubscriptionsDelete Permission = "subscriptions:delete"
+	PermissionSubscriptionsExport Permission = "subscriptions:export"
+	PermissionReportsRead         Permission = "reports:read"
+	PermissionWebhooksManage      Permission = "webhooks:manage"
+	PermissionAPIKeysManage       Permission = "api_keys:manage"
+	PermissionRolesManage         Permission = "roles:manage"
+	// PermissionAuditRead поиск по журналу изменений всех подписок. История отдельной
+	// подписки доступна с правом на ее чтение.
+	PermissionAuditRead Permission = "audit:read"
+)
+
+// Grant право на действие. Без AllUsers оно распространяется только на данные
+// самого вызывающего.
+type Grant struct {
+	Permission Permission
+	AllUsers   bool
+}
+
+// rolePermissions политика доступа: права каждой роли. Права нескольких ролей складываются.
+var rolePermissions = map[Role][]Grant{
+	RoleUser: {
+		{Permission: PermissionSubscriptionsRead},
+		{Permission: PermissionSubscriptionsWrite},
+		{Permission: PermissionSubscriptionsDelete},
+		{Permission: PermissionSubscriptionsExport},
+		{Permission: PermissionReportsRead},
+	},
+	RoleSupport: {
+		{Permission: PermissionSubscriptionsRead, AllUsers: true},
+		{Permission: PermissionSubscriptionsExport, AllUsers: true},
+		{Permission: PermissionReportsRead, AllUsers: true},
+	},
+	RoleAdmin: {
+		{Permission: PermissionSubscriptionsRead, AllUsers: true},
+		{Permission: PermissionSubscriptionsWrite, AllUsers: true},
+		{Permission: PermissionSubscriptionsDelete, AllUsers: true},
+		{Permission: PermissionSubscriptionsExport, AllUsers: true},
+		{Permission: PermissionReportsRead, AllUsers: true},
+		{Permission: PermissionWebhooksManage, AllUsers: true},
+		{Permission: PermissionAPIKeysManage, AllUsers: true},
+		{Permission: PermissionRolesManage, AllUsers: true},
+		{Permission: PermissionAuditRead, AllUsers: true},
+	},
+}
+
+// scopePermissions права API-ключа по его scopes. Ключ работает от имени сервиса,
+// поэтому права распространяются на всех пользователей.
+var scopePermissions = map[string][]Permission{
+	ScopeSubscriptionsRead:  {PermissionSubscriptionsRead, PermissionSubscriptionsExport},
+	ScopeSubscriptionsWrite: {PermissionSubscriptionsWrite, PermissionSubscriptionsDelete},
+	ScopeReportsRead:        {PermissionReportsRead},
+}
+
+// RoleAssignment роль, назначенная пользователю через API, в дополнение к ролям из токена
+type RoleAssignment struct {
+	UserUUID  uuid.UUID
+	Role      Role
+	GrantedBy *uuid.UUID
+	CreatedAt time.Time
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrCandidateNotFound = errors.New("subscription candidate not found")
+
+type RecurrencePeriod string
+
+const (
+	RecurrenceMonthly RecurrencePeriod = "monthly"
+	RecurrenceYearly  RecurrencePeriod = "yearly"
+)
+
+// BankTransaction списание или зачисление из банковской выписки.
+// Amount хранится в копейках, списания отрицательные.
+type BankTransaction struct {
+	Date        time.Time
+	Description string
+	Amount      int64
+}
+
+// SubscriptionCandidate повторяющееся списание, найденное в выписке, которое
+// пользователь может подтвердить как подписку
+type SubscriptionCandidate struct {
+	UUID     uuid.UUID
+	UserUUID uuid.UUID
+	// Merchant нормализованное название получателя, по которому сгруппированы списания
+	Merchant    string
+	ServiceName string
+	// Price стоимость в месяц; для годовых подписок — годовой платеж, деленный на 12
+	Price          int
+	ChargeAmount   int
+	Period         RecurrencePeriod
+	StartDate      time.Time
+	EndDate        *time.Time
+	Occurrences    int
+	LastChargeDate time.Time
+	Confidence     float64
+	// SubscriptionUUID заполняется после подтверждения
+	SubscriptionUUID *uuid.UUID
+	CreatedAt        time.Time
+}
+
+// ConfirmCandidateParams подтверждение кандидата с возможностью поправить предложенные значения
+type ConfirmCandidateParams struct {
+	UUID        uuid.UUID
+	ServiceName *string
+	Price       *int
+	StartDate   *time.Time
+	EndDate     *time.Time
+}
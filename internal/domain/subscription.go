@@ -1,20 +1,31 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrSubscriptionNotDeleted восстановить можно только удаленную подписку
+	ErrSubscriptionNotDeleted = errors.New("subscription is not deleted")
+	ErrInvalidCursor          = errors.New("invalid cursor")
+	ErrInvalidSort            = errors.New("invalid sort")
+)
+
 type Subscription struct {
-	UUID        uuid.UUID
-	ServiceName string
-	Price       int
-	UserUUID    uuid.UUID
-	StartDate   time.Time
-	EndDate     *time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	UUID           uuid.UUID
+	OrganizationID uuid.UUID
+	ServiceName    string
+	Price          int
+	UserUUID       uuid.UUID
+	StartDate      time.Time
+	EndDate        *time.Time
+	Notes          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 type CreateSubscriptionParams struct {
@@ -23,24 +34,115 @@ type CreateSubscriptionParams struct {
 	UserUUID    uuid.UUID
 	StartDate   time.Time
 	EndDate     *time.Time
+	Notes       string
 }
 
 type UpdateSubscriptionParams struct {
 	ServiceName string
 	Price       int
 	EndDate     *time.Time
+	Notes       string
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ServiceNameMatch способ сравнения названия подписки с названиями фильтра
+type ServiceNameMatch int
+
+const (
+	// ServiceNameExact точное совпадение
+	ServiceNameExact ServiceNameMatch = iota
+	// ServiceNameIgnoreCase совпадение без учета регистра, символы % и _ сравниваются буквально
+	ServiceNameIgnoreCase
+)
+
+// SubscriptionFilter набор условий отбора подписок. Пустые поля не участвуют в фильтрации,
+// непустые объединяются через AND.
+type SubscriptionFilter struct {
+	ServiceNames []string
+	// ServiceNameMatch как сравнивать ServiceNames, по умолчанию точно
+	ServiceNameMatch ServiceNameMatch
+	UserID           *uuid.UUID
+	// UserIDs подписки любого из пользователей, нужен для пакетной загрузки
+	UserIDs      []uuid.UUID
+	PriceMin     *int
+	PriceMax     *int
+	StartFrom    *time.Time
+	StartTo      *time.Time
+	EndFrom      *time.Time
+	EndTo        *time.Time
+	ActiveAt     *time.Time
+	OpenEnded    *bool
+	CreatedSince *time.Time
+	UpdatedSince *time.Time
 }
 
 type ListSubscriptionParams struct {
-	ServiceName *string
-	UserID      *uuid.UUID
-	Page        int
-	Limit       int
+	Filter    SubscriptionFilter
+	Cursor    string
+	Limit     int
+	Sort      []SortField
+	WithTotal bool
+	// AsOf подписки в состоянии на этот момент, nil — текущие
+	AsOf *time.Time
+}
+
+type ExportSubscriptionsParams struct {
+	Filter SubscriptionFilter
+	Sort   []SortField
+}
+
+type SubscriptionPage struct {
+	Subscriptions []*Subscription
+	NextCursor    string
+	PrevCursor    string
+	TotalCount    *int
 }
 
 type TotalCostSubscriptionsParams struct {
-	ServiceName *string
-	UserID      *uuid.UUID
-	StartDate   time.Time
-	EndDate     time.Time
+	Filter    SubscriptionFilter
+	StartDate time.Time
+	EndDate   time.Time
+	// AsOf сумма по подпискам в состоянии на этот момент, nil — по текущим
+	AsOf *time.Time
+}
+
+type SearchSubscriptionsParams struct {
+	Query  string
+	UserID *uuid.UUID
+	Limit  int
+}
+
+// SubscriptionStats показатели подписок, действующих в текущем месяце
+type SubscriptionStats struct {
+	ActiveSubscriptions int
+	// MonthlyRunRate сумма ежемесячных цен действующих подписок
+	MonthlyRunRate int
+}
+
+// SearchResult найденная подписка с релевантностью и HTML-фрагментами: текст экранирован,
+// совпадения обрамлены тегами <mark></mark>
+type SearchResult struct {
+	Subscription         *Subscription
+	Rank                 float64
+	ServiceNameHighlight string
+	NotesHighlight       string
+}
+
+// UserSummary сводка по подпискам пользователя на указанный месяц
+type UserSummary struct {
+	UserUUID     uuid.UUID
+	TotalCount   int
+	ActiveCount  int
+	MonthlyCost  int
+	ServiceNames []string
+}
+
+// MonthCost сумма цен подписок, действующих в месяце
+type MonthCost struct {
+	Month time.Time
+	Cost  int
 }
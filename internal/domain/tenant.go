@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTenantRequired = errors.New("organization is required")
+	ErrInvalidTenant  = errors.New("invalid organization id")
+	// ErrTenantUnbound у аутентифицированного вызывающего нет организации, а она обязательна
+	ErrTenantUnbound = errors.New("principal is not bound to an organization")
+)
+
+// DefaultOrganizationID организация, к которой относятся данные, созданные до появления
+// организаций, и запросы без указанной организации, если она не обязательна
+var DefaultOrganizationID = uuid.Nil
+
+// ResolveTenant определяет организацию запроса. Аутентифицированный вызывающий работает
+// только в организации из токена или API-ключа, запрос другой — ErrForbidden. Без нее
+// он относится к DefaultOrganizationID, а если организация обязательна, получает
+// ErrTenantUnbound: выбрать организацию заголовком он не может. Параметром requested
+// организацию выбирают только запросы без аутентификации, без него используется
+// DefaultOrganizationID, если организация не обязательна.
+func ResolveTenant(principal *Principal, requested string, required bool) (uuid.UUID, error) {
+	var requestedID *uuid.UUID
+	if requested != "" {
+		id, err := uuid.Parse(requested)
+		if err != nil {
+			return uuid.Nil, ErrInvalidTenant
+		}
+		requestedID = &id
+	}
+
+	if principal != nil {
+		organizationID := DefaultOrganizationID
+		switch {
+		case principal.OrganizationID != nil:
+			organizationID = *principal.OrganizationID
+		case required:
+			return uuid.Nil, ErrTenantUnbound
+		}
+
+		if requestedID != nil && *requestedID != organizationID {
+			return uuid.Nil, ErrForbidden
+		}
+		return organizationID, nil
+	}
+
+	switch {
+	case requestedID != nil:
+		return *requestedID, nil
+	case required:
+		return uuid.Nil, ErrTenantRequired
+	default:
+		return DefaultOrganizationID, nil
+	}
+}
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, organizationID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, organizationID)
+}
+
+// TenantFromContext возвращает организацию запроса. Репозитории без нее не выполняют
+// запросов к данным организаций.
+func TenantFromContext(ctx context.Context) (uuid.UUID, bool) {
+	organizationID, ok := ctx.Value(tenantKey{}).(uuid.UUID)
+	return organizationID, ok
+}
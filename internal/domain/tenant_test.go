@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveTenant(t *testing.T) {
+	org := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	otherOrg := uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+
+	bound := &Principal{Subject: uuid.New(), OrganizationID: &org}
+	unbound := &Principal{Subject: uuid.New()}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		requested string
+		required  bool
+		want      uuid.UUID
+		wantErr   error
+	}{
+		{name: "principal organization", principal: bound, want: org},
+		{name: "principal organization when required", principal: bound, required: true, want: org},
+		{name: "principal requests own organization", principal: bound, requested: org.String(), want: org},
+		{name: "principal requests other organization", principal: bound, requested: otherOrg.String(), wantErr: ErrForbidden},
+		{name: "unbound principal uses default", principal: unbound, want: DefaultOrganizationID},
+		{name: "unbound principal when required", principal: unbound, required: true, wantErr: ErrTenantUnbound},
+		{
+			name:      "unbound principal cannot pick organization",
+			principal: unbound,
+			requested: otherOrg.String(),
+			wantErr:   ErrForbidden,
+		},
+		{
+			name:      "unbound principal cannot pick organization when required",
+			principal: unbound,
+			requested: otherOrg.String(),
+			required:  true,
+			wantErr:   ErrTenantUnbound,
+		},
+		{name: "anonymous picks organization", requested: otherOrg.String(), want: otherOrg},
+		{name: "anonymous picks organization when required", requested: otherOrg.String(), required: true, want: otherOrg},
+		{name: "anonymous uses default", want: DefaultOrganizationID},
+		{name: "anonymous when required", required: true, wantErr: ErrTenantRequired},
+		{name: "invalid organization", requested: "acme", wantErr: ErrInvalidTenant},
+		{name: "invalid organization from principal", principal: bound, requested: "acme", wantErr: ErrInvalidTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveTenant(tt.principal, tt.requested, tt.required)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveTenant() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveTenant() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ResolveTenant() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantFromContext(t *testing.T) {
+	if _, ok := TenantFromContext(t.Context()); ok {
+		t.Fatal("empty context has an organization")
+	}
+
+	org := uuid.New()
+	if got, ok := TenantFromContext(WithTenant(t.Context(), org)); !ok || got != org {
+		t.Fatalf("TenantFromContext() = %s, %v, want %s", got, ok, org)
+	}
+}
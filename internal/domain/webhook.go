@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrInvalidWebhookURL адрес вебхука ведет во внутреннюю сеть или имеет недопустимую схему
+	ErrInvalidWebhookURL = errors.New("webhook url is not allowed")
+)
+
+// Webhook адрес, на который отправляются события. Пустой Events означает все события.
+type Webhook struct {
+	UUID           uuid.UUID
+	OrganizationID uuid.UUID
+	URL            string
+	Secret         string
+	Events         []EventType
+	Active         bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type CreateWebhookParams struct {
+	URL    string
+	Secret string
+	Events []EventType
+}
+
+// UpdateWebhookParams полностью заменяет настройки вебхука. Пустой Secret оставляет прежний секрет.
+type UpdateWebhookParams struct {
+	URL    string
+	Secret string
+	Events []EventType
+	Active bool
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery отправка одного события на один вебхук со всеми попытками
+type WebhookDelivery struct {
+	UUID           uuid.UUID
+	OrganizationID uuid.UUID
+	WebhookUUID    uuid.UUID
+	EventID        uuid.UUID
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastStatusCode *int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DueDelivery отправка, которую пора выполнить, вместе с адресом и секретом вебхука
+type DueDelivery struct {
+	Delivery *WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+// DeliveryAttempt результат одной попытки отправки. NextAttemptAt nil — повторов больше не будет.
+type DeliveryAttempt struct {
+	DeliveryUUID  uuid.UUID
+	Status        DeliveryStatus
+	StatusCode    *int
+	Error         string
+	NextAttemptAt *time.Time
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// businessTimeout сколько ждать запроса показателей при сборе метрик
+const businessTimeout = 5 * time.Second
+
+// SubscriptionStatsSource источник бизнес-показателей по подпискам всех организаций
+type SubscriptionStatsSource interface {
+	SubscriptionStats(ctx context.Context) (*domain.SubscriptionStats, error)
+}
+
+// BusinessCollector считает бизнес-показатели в момент сбора метрик. Если запрос
+// не удался, показатели пропускаются, а остальные метрики отдаются как обычно.
+type BusinessCollector struct {
+	logger *slog.Logger
+	source SubscriptionStatsSource
+
+	activeSubscriptions *prometheus.Desc
+	monthlyRunRate      *prometheus.Desc
+}
+
+func NewBusinessCollector(baseLogger *slog.Logger, source SubscriptionStatsSource) *BusinessCollector {
+	logger := baseLogger.WithGroup("business metrics")
+
+	return &BusinessCollector{
+		logger: logger,
+		source: source,
+		activeSubscriptions: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_subscriptions"),
+			"Subscriptions active in the current month across all organizations.", nil, nil),
+		monthlyRunRate: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "monthly_run_rate"),
+			"Sum of monthly prices of subscriptions active in the current month across all organizations.", nil, nil),
+	}
+}
+
+func (c *BusinessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeSubscriptions
+	ch <- c.monthlyRunRate
+}
+
+func (c *BusinessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessTimeout)
+	defer cancel()
+
+	stats, err := c.source.SubscriptionStats(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to collect subscription stats", slog.String("error", err.Error()))
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.activeSubscriptions, prometheus.GaugeValue, float64(stats.ActiveSubscriptions))
+	ch <- prometheus.MustNewConstMetric(c.monthlyRunRate, prometheus.GaugeValue, float64(stats.MonthlyRunRate))
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type statsSource struct {
+	stats *domain.SubscriptionStats
+	err   error
+}
+
+func (s statsSource) SubscriptionStats(context.Context) (*domain.SubscriptionStats, error) {
+	return s.stats, s.err
+}
+
+func TestBusinessCollector(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+
+	collector := NewBusinessCollector(logger, statsSource{stats: &domain.SubscriptionStats{ActiveSubscriptions: 3, MonthlyRunRate: 1500}})
+	if count := testutil.CollectAndCount(collector); count != 2 {
+		t.Fatalf("collected %d metrics, want 2", count)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	got := make(map[string]float64)
+	for _, family := range families {
+		got[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	for name, want := range map[string]float64{
+		"subscriptions_active_subscriptions": 3,
+		"subscriptions_monthly_run_rate":     1500,
+	} {
+		if value, ok := got[name]; !ok || value != want {
+			t.Errorf("%s = %v, want %v", name, value, want)
+		}
+	}
+}
+
+// TestBusinessCollectorSourceFails ошибка источника не ломает сбор: бизнес-показатели
+// пропускаются, остальные метрики реестра отдаются
+func TestBusinessCollectorSourceFails(t *testing.T) {
+	collector := NewBusinessCollector(slog.New(slog.DiscardHandler), statsSource{err: errors.New("database is down")})
+	if count := testutil.CollectAndCount(collector); count != 0 {
+		t.Errorf("collected %d metrics from a failing source, want 0", count)
+	}
+
+	requests := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total", Help: "Requests."})
+	requests.Inc()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector, requests)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v, want the other metrics without an error", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "requests_total" {
+		t.Errorf("gathered %d families, want only requests_total", len(families))
+	}
+}
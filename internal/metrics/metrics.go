@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "subscriptions"
+
+var (
+	// HTTPRequests число обработанных HTTP-запросов. route — шаблон пути gin,
+	// для неизвестных путей пустой, чтобы сканеры не раздували число рядов.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests processed, by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency, by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// RepositoryDuration длительность методов репозиториев вместе с транзакцией
+	RepositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "method_duration_seconds",
+		Help:      "Repository method latency, including the transaction, by repository and method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"repository", "method"})
+)
+
+// NewRegistry реестр с метриками процесса, HTTP и репозиториев. Метрики, которым
+// нужны зависимости (пул, бизнес-показатели), регистрируются отдельно в app.New.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		RepositoryDuration,
+	)
+
+	return registry
+}
+
+// Handler отдает метрики реестра в формате Prometheus
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ObserveRepository записывает длительность метода репозитория:
+//
+//	defer metrics.ObserveRepository("subscription", "GetSubscription", time.Now())
+func ObserveRepository(repository, method string, start time.Time) {
+	RepositoryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
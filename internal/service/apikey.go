@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyBytes = 32
+	// apiKeyMarker начало каждого ключа: по нему ключ легко найти в логах и сканерах секретов
+	apiKeyMarker = "sk_"
+	// apiKeyPrefixLength сколько первых символов ключа хранится открыто для списка ключей
+	apiKeyPrefixLength = len(apiKeyMarker) + 8
+)
+
+// APIKey выпуск и проверка API-ключей сервисов. Управлять ключами могут только администраторы.
+type APIKey struct {
+	logger     *slog.Logger
+	apiKeyRepo *repository.APIKey
+}
+
+func NewAPIKey(baseLogger *slog.Logger, apiKeyRepo *repository.APIKey) *APIKey {
+	logger := baseLogger.WithGroup("api key service")
+
+	return &APIKey{
+		logger:     logger,
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// IssueAPIKey выпускает ключ. Секрет возвращается только здесь, в базе хранится его хеш.
+func (a *APIKey) IssueAPIKey(ctx context.Context, params *domain.CreateAPIKeyParams) (*domain.IssuedAPIKey, error) {
+	if err := authorize(ctx, domain.PermissionAPIKeysManage); err != nil {
+		return nil, err
+	}
+
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &domain.APIKey{
+		UUID:      uuid.New(),
+		Name:      params.Name,
+		Prefix:    secret[:apiKeyPrefixLength],
+		Scopes:    params.Scopes,
+		ExpiresAt: params.ExpiresAt,
+	}
+	if err := a.apiKeyRepo.CreateAPIKey(ctx, key, hashAPIKey(secret)); err != nil {
+		return nil, err
+	}
+
+	requestLogger(ctx, a.logger).Info("API key issued",
+		slog.String("api_key_id", key.UUID.String()),
+		slog.String("name", key.Name),
+		slog.Any("scopes", key.Scopes),
+	)
+	return &domain.IssuedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+func (a *APIKey) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	if err := authorize(ctx, domain.PermissionAPIKeysManage); err != nil {
+		return nil, err
+	}
+
+	return a.apiKeyRepo.ListAPIKeys(ctx)
+}
+
+func (a *APIKey) GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	if err := authorize(ctx, domain.PermissionAPIKeysManage); err != nil {
+		return nil, err
+	}
+
+	return a.apiKeyRepo.GetAPIKey(ctx, id)
+}
+
+// RotateAPIKey выпускает новый секрет для того же ключа: имя, права и id не меняются
+func (a *APIKey) RotateAPIKey(ctx context.Context, id uuid.UUID, params *domain.RotateAPIKeyParams) (*domain.IssuedAPIKey, error) {
+	if err := authorize(ctx, domain.PermissionAPIKeysManage); err != nil {
+		return nil, err
+	}
+
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := a.apiKeyRepo.RotateAPIKey(ctx, id, secret[:apiKeyPrefixLength], hashAPIKey(secret), params.Grace)
+	if err != nil {
+		return nil, err
+	}
+
+	requestLogger(ctx, a.logger).Info("API key rotated",
+		slog.String("api_key_id", key.UUID.String()),
+		slog.Duration("grace", params.Grace),
+	)
+	return &domain.IssuedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+func (a *APIKey) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	if err := authorize(ctx, domain.PermissionAPIKeysManage); err != nil {
+		return nil, err
+	}
+
+	key, err := a.apiKeyRepo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	requestLogger(ctx, a.logger).Info("API key revoked", slog.String("api_key_id", key.UUID.String()))
+	return key, nil
+}
+
+// Authenticate проверяет ключ из заголовка Authorization: ApiKey <key>
+func (a *APIKey) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	if !strings.HasPrefix(token, apiKeyMarker) {
+		return nil, fmt.Errorf("%w: malformed api key", domain.ErrUnauthenticated)
+	}
+
+	key, err := a.apiKeyRepo.FindActiveAPIKey(ctx, hashAPIKey(token))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown, expired or revoked api key", domain.ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// время последнего использования не должно мешать запросу
+	if err := a.apiKeyRepo.TouchAPIKey(ctx, key.UUID); err != nil {
+		a.logger.Warn("Failed to update API key usage",
+			slog.String("api_key_id", key.UUID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return &domain.Principal{APIKeyUUID: &key.UUID, Scopes: key.Scopes, OrganizationID: &key.OrganizationID}, nil
+}
+
+func newAPIKeySecret() (string, error) {
+	raw := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return apiKeyMarker + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/pgtest"
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestNewAPIKeySecret(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 100 {
+		secret, err := newAPIKeySecret()
+		if err != nil {
+			t.Fatalf("newAPIKeySecret() error = %v", err)
+		}
+		if !strings.HasPrefix(secret, apiKeyMarker) {
+			t.Fatalf("secret %q does not start with %q", secret, apiKeyMarker)
+		}
+		// 32 байта в base64 без дополнения — 43 символа
+		if len(secret) != len(apiKeyMarker)+43 {
+			t.Fatalf("secret length = %d, want %d", len(secret), len(apiKeyMarker)+43)
+		}
+		if seen[secret] {
+			t.Fatalf("secret %q generated twice", secret)
+		}
+		seen[secret] = true
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	key := "sk_0123456789abcdef"
+	sum := sha256.Sum256([]byte(key))
+
+	hash := hashAPIKey(key)
+	if hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("hashAPIKey() = %s, want hex SHA-256", hash)
+	}
+	if hash != hashAPIKey(key) {
+		t.Error("hashAPIKey() is not deterministic")
+	}
+	if hash == hashAPIKey(key+"x") {
+		t.Error("different keys have the same hash")
+	}
+}
+
+func TestAPIKeyAuthenticateMalformed(t *testing.T) {
+	// ключ без маркера отклоняется без обращения к базе
+	apiKeys := NewAPIKey(slog.New(slog.DiscardHandler), nil)
+
+	for _, token := range []string{"", "Bearer sk_abc", "pk_abc", "eyJhbGciOiJIUzI1NiJ9.e30.sig"} {
+		if _, err := apiKeys.Authenticate(context.Background(), token); !errors.Is(err, domain.ErrUnauthenticated) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrUnauthenticated", token, err)
+		}
+	}
+}
+
+func TestAPIKeyManagementRequiresAdmin(t *testing.T) {
+	apiKeys := NewAPIKey(slog.New(slog.DiscardHandler), nil)
+	id := uuid.New()
+
+	tests := []struct {
+		name      string
+		principal *domain.Principal
+	}{
+		{name: "user", principal: &domain.Principal{Subject: uuid.New(), Roles: []domain.Role{domain.RoleUser}}},
+		{name: "support", principal: &domain.Principal{Subject: uuid.New(), Roles: []domain.Role{domain.RoleSupport}}},
+		{name: "api key", principal: &domain.Principal{APIKeyUUID: &id, Scopes: domain.Scopes}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := domain.WithPrincipal(context.Background(), tt.principal)
+
+			if _, err := apiKeys.IssueAPIKey(ctx, &domain.CreateAPIKeyParams{Name: "billing"}); !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("IssueAPIKey() error = %v, want ErrForbidden", err)
+			}
+			if _, err := apiKeys.RotateAPIKey(ctx, id, &domain.RotateAPIKeyParams{}); !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("RotateAPIKey() error = %v, want ErrForbidden", err)
+			}
+			if _, err := apiKeys.RevokeAPIKey(ctx, id); !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("RevokeAPIKey() error = %v, want ErrForbidden", err)
+			}
+			if _, err := apiKeys.ListAPIKeys(ctx); !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("ListAPIKeys() error = %v, want ErrForbidden", err)
+			}
+		})
+	}
+}
+
+// TestAPIKeyLifecycle выпуск, ротация с периодом действия прежнего ключа и отзыв
+func TestAPIKeyLifecycle(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := domain.WithTenant(context.Background(), uuid.New())
+	logger := slog.New(slog.DiscardHandler)
+	apiKeys := NewAPIKey(logger, repository.NewAPIKey(pool, logger))
+
+	authenticates := func(key string) bool {
+		t.Helper()
+		_, err := apiKeys.Authenticate(ctx, key)
+		if err != nil && !errors.Is(err, domain.ErrUnauthenticated) {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+		return err == nil
+	}
+
+	issued, err := apiKeys.IssueAPIKey(ctx, &domain.CreateAPIKeyParams{
+		Name:   "billing",
+		Scopes: []string{domain.ScopeSubscriptionsRead},
+	})
+	if err != nil {
+		t.Fatalf("IssueAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(issued.Key, issued.APIKey.Prefix) || len(issued.APIKey.Prefix) != apiKeyPrefixLength {
+		t.Errorf("prefix = %q, want the first %d characters of the key", issued.APIKey.Prefix, apiKeyPrefixLength)
+	}
+
+	var storedHash string
+	pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, `SELECT key_hash FROM api_keys WHERE id = $1`, issued.APIKey.UUID).Scan(&storedHash)
+	})
+	if storedHash != hashAPIKey(issued.Key) || strings.Contains(storedHash, issued.Key) {
+		t.Error("api_keys.key_hash is not the hash of the issued key")
+	}
+
+	principal, err := apiKeys.Authenticate(ctx, issued.Key)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if organizationID, _ := domain.TenantFromContext(ctx); principal.OrganizationID == nil || *principal.OrganizationID != organizationID {
+		t.Errorf("Authenticate() organization = %v, want the organization the key was issued in", principal.OrganizationID)
+	}
+	if principal.APIKeyUUID == nil || *principal.APIKeyUUID != issued.APIKey.UUID ||
+		!principal.CanAllUsers(domain.PermissionSubscriptionsRead) || principal.Has(domain.PermissionSubscriptionsWrite) {
+		t.Errorf("Authenticate() = %+v, want the key id with read scope only", principal)
+	}
+	if authenticates(issued.Key + "x") {
+		t.Error("a modified key authenticated")
+	}
+
+	rotated, err := apiKeys.RotateAPIKey(ctx, issued.APIKey.UUID, &domain.RotateAPIKeyParams{Grace: time.Hour})
+	if err != nil {
+		t.Fatalf("RotateAPIKey() error = %v", err)
+	}
+	if rotated.APIKey.UUID != issued.APIKey.UUID || rotated.Key == issued.Key {
+		t.Fatalf("RotateAPIKey() = %+v, want the same id with a new secret", rotated.APIKey)
+	}
+	if !authenticates(rotated.Key) || !authenticates(issued.Key) {
+		t.Error("both keys must authenticate during the grace period")
+	}
+
+	rotatedAgain, err := apiKeys.RotateAPIKey(ctx, issued.APIKey.UUID, &domain.RotateAPIKeyParams{})
+	if err != nil {
+		t.Fatalf("RotateAPIKey() without grace error = %v", err)
+	}
+	if authenticates(rotated.Key) || authenticates(issued.Key) {
+		t.Error("previous keys authenticate after a rotation without grace")
+	}
+	if !authenticates(rotatedAgain.Key) {
+		t.Error("the current key does not authenticate")
+	}
+
+	revoked, err := apiKeys.RevokeAPIKey(ctx, issued.APIKey.UUID)
+	if err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+	if revoked.RevokedAt == nil {
+		t.Error("revoked_at is not set")
+	}
+	if authenticates(rotatedAgain.Key) {
+		t.Error("a revoked key authenticated")
+	}
+
+	again, err := apiKeys.RevokeAPIKey(ctx, issued.APIKey.UUID)
+	if err != nil || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("repeated RevokeAPIKey() = %v, %v; want the original revocation time", again.RevokedAt, err)
+	}
+	if _, err := apiKeys.RotateAPIKey(ctx, issued.APIKey.UUID, &domain.RotateAPIKeyParams{}); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("RotateAPIKey() of a revoked key error = %v, want ErrAPIKeyNotFound", err)
+	}
+}
+
+func TestAPIKeyExpired(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := domain.WithTenant(context.Background(), uuid.New())
+	logger := slog.New(slog.DiscardHandler)
+	apiKeys := NewAPIKey(logger, repository.NewAPIKey(pool, logger))
+
+	expiresAt := time.Now().Add(-time.Minute)
+	issued, err := apiKeys.IssueAPIKey(ctx, &domain.CreateAPIKeyParams{Name: "expired", Scopes: domain.Scopes, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("IssueAPIKey() error = %v", err)
+	}
+
+	if _, err := apiKeys.Authenticate(ctx, issued.Key); !errors.Is(err, domain.ErrUnauthenticated) {
+		t.Errorf("Authenticate() error = %v, want ErrUnauthenticated", err)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Audit чтение журнала изменений подписок
+type Audit struct {
+	logger    *slog.Logger
+	auditRepo *repository.Audit
+}
+
+func NewAudit(baseLogger *slog.Logger, auditRepo *repository.Audit) *Audit {
+	logger := baseLogger.WithGroup("audit service")
+
+	return &Audit{
+		logger:    logger,
+		auditRepo: auditRepo,
+	}
+}
+
+// SubscriptionHistory возвращает историю подписки, в том числе удаленной. Вызывающему
+// без права на чтение всех подписок видна история только своих: для чужой подписки
+// история пуста, и возвращается ErrSubscriptionNotFound.
+func (a *Audit) SubscriptionHistory(ctx context.Context, subscriptionID uuid.UUID, params *domain.SearchAuditParams) (*domain.AuditPage, error) {
+	params.Filter = domain.AuditFilter{SubscriptionUUID: &subscriptionID}
+	if err := scopeUserID(ctx, domain.PermissionSubscriptionsRead, &params.Filter.UserUUID); err != nil {
+		return nil, err
+	}
+
+	page, err := a.auditRepo.SearchAudit(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Records) == 0 && params.Cursor == "" {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+
+	return page, nil
+}
+
+// SearchAudit поиск по журналу всех подписок организации, доступен администраторам
+func (a *Audit) SearchAudit(ctx context.Context, params *domain.SearchAuditParams) (*domain.AuditPage, error) {
+	if err := authorize(ctx, domain.PermissionAuditRead); err != nil {
+		return nil, err
+	}
+
+	return a.auditRepo.SearchAudit(ctx, params)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// authorizeUser проверяет право вызывающего на действие с данными пользователя.
+// Без вызывающего в контексте (внутренние вызовы, аутентификация выключена) доступ открыт.
+func authorizeUser(ctx context.Context, permission domain.Permission, userID uuid.UUID) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.Can(permission, userID) {
+		return nil
+	}
+
+	return domain.ErrForbidden
+}
+
+// authorize проверяет право, не привязанное к конкретному пользователю: управление
+// вебхуками, ключами и ролями
+func authorize(ctx context.Context, permission domain.Permission) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.CanAllUsers(permission) {
+		return nil
+	}
+
+	return domain.ErrForbidden
+}
+
+// authorizeSubscription скрывает подписки, которые вызывающему нельзя читать: для него
+// они не существуют, поэтому возвращается ErrSubscriptionNotFound. Видимая подписка без
+// права на действие — ErrForbidden.
+func authorizeSubscription(ctx context.Context, permission domain.Permission, subscription *domain.Subscription) error {
+	if authorizeUser(ctx, permission, subscription.UserUUID) == nil {
+		return nil
+	}
+	if authorizeUser(ctx, domain.PermissionSubscriptionsRead, subscription.UserUUID) != nil {
+		return domain.ErrSubscriptionNotFound
+	}
+
+	return domain.ErrForbidden
+}
+
+// scopeUserID ограничивает выборку подписками вызывающего, если право не распространяется
+// на всех пользователей. Явно запрошенный чужой пользователь — ErrForbidden, не указанный
+// подставляется из токена.
+func scopeUserID(ctx context.Context, permission domain.Permission, userID **uuid.UUID) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.CanAllUsers(permission) {
+		return nil
+	}
+	if !principal.Has(permission) || principal.APIKeyUUID != nil {
+		return domain.ErrForbidden
+	}
+	if *userID != nil && **userID != principal.Subject {
+		return domain.ErrForbidden
+	}
+
+	subject := principal.Subject
+	*userID = &subject
+	return nil
+}
+
+func scopeFilter(ctx context.Context, permission domain.Permission, filter *domain.SubscriptionFilter) error {
+	for _, userID := range filter.UserIDs {
+		if err := authorizeUser(ctx, permission, userID); err != nil {
+			return err
+		}
+	}
+
+	return scopeUserID(ctx, permission, &filter.UserID)
+}
+
+// subscriptionCheck проверка права на действие с подпиской, которую репозиторий выполняет
+// над заблокированной строкой в транзакции самого действия. nil, если право вызывающего
+// распространяется на подписки всех пользователей.
+func subscriptionCheck(ctx context.Context, permission domain.Permission) func(*domain.Subscription) error {
+	if principal, ok := domain.PrincipalFromContext(ctx); !ok || principal.CanAllUsers(permission) {
+		return nil
+	}
+
+	return func(subscription *domain.Subscription) error {
+		return authorizeSubscription(ctx, permission, subscription)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestScopeUserID(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	asAlice := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: alice, Roles: []domain.Role{domain.RoleUser}})
+	asSupport := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: bob, Roles: []domain.Role{domain.RoleSupport}})
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		permission domain.Permission
+		userID     *uuid.UUID
+		want       *uuid.UUID
+		wantErr    error
+	}{
+		{name: "internal call keeps filter", ctx: context.Background(), permission: domain.PermissionSubscriptionsRead},
+		{name: "support reads another user", ctx: asSupport, permission: domain.PermissionSubscriptionsRead, userID: &alice, want: &alice},
+		{name: "support reads all users", ctx: asSupport, permission: domain.PermissionSubscriptionsRead},
+		{name: "support writes only own", ctx: asSupport, permission: domain.PermissionSubscriptionsWrite, userID: &alice, wantErr: domain.ErrForbidden},
+		{name: "user is scoped to own subscriptions", ctx: asAlice, permission: domain.PermissionSubscriptionsRead, want: &alice},
+		{name: "user requests own subscriptions", ctx: asAlice, permission: domain.PermissionSubscriptionsRead, userID: &alice, want: &alice},
+		{name: "user requests another user", ctx: asAlice, permission: domain.PermissionSubscriptionsRead, userID: &bob, wantErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := tt.userID
+			err := scopeUserID(tt.ctx, tt.permission, &userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("scopeUserID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if (userID == nil) != (tt.want == nil) || userID != nil && *userID != *tt.want {
+				t.Errorf("scopeUserID() user = %v, want %v", userID, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionCheck(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	own := &domain.Subscription{UUID: uuid.New(), UserUUID: alice}
+	foreign := &domain.Subscription{UUID: uuid.New(), UserUUID: bob}
+
+	if check := subscriptionCheck(context.Background(), domain.PermissionSubscriptionsWrite); check != nil {
+		t.Error("subscriptionCheck() for an internal call is not nil")
+	}
+	asAdmin := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: bob, Roles: []domain.Role{domain.RoleAdmin}})
+	if check := subscriptionCheck(asAdmin, domain.PermissionSubscriptionsDelete); check != nil {
+		t.Error("subscriptionCheck() for an admin is not nil")
+	}
+
+	asAlice := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: alice, Roles: []domain.Role{domain.RoleUser}})
+	check := subscriptionCheck(asAlice, domain.PermissionSubscriptionsWrite)
+	if check == nil {
+		t.Fatal("subscriptionCheck() for a user is nil")
+	}
+	if err := check(own); err != nil {
+		t.Errorf("check(own subscription) error = %v", err)
+	}
+	// чужая подписка для вызывающего не существует
+	if err := check(foreign); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Errorf("check(foreign subscription) error = %v, want ErrSubscriptionNotFound", err)
+	}
+
+	// поддержка видит подписку пользователя, но изменить ее не может
+	asSupport := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: bob, Roles: []domain.Role{domain.RoleSupport}})
+	if err := subscriptionCheck(asSupport, domain.PermissionSubscriptionsWrite)(own); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("support check error = %v, want ErrForbidden", err)
+	}
+}
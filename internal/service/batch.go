@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ExecuteBatch выполняет пакет операций. В режиме atomic все операции идут в одной
+// транзакции и первая ошибка откатывает пакет целиком (возвращается *domain.BatchItemError).
+// В режиме best_effort каждая операция выполняется независимо, а ошибки
+// возвращаются в результатах по своим индексам.
+func (s *Subscription) ExecuteBatch(ctx context.Context, params *domain.BatchParams) (results []*domain.BatchItemResult, err error) {
+	ctx, span := startSpan(ctx, "Subscription.ExecuteBatch",
+		attribute.String("batch.mode", string(params.Mode)),
+		attribute.Int("batch.operations", len(params.Operations)),
+	)
+	defer endSpan(span, &err)
+
+	logger := requestLogger(ctx, s.logger).With(
+		slog.String("mode", string(params.Mode)),
+		slog.Int("operations", len(params.Operations)),
+	)
+	logger.Info("Executing batch")
+
+	results = make([]*domain.BatchItemResult, 0, len(params.Operations))
+	if params.Mode == domain.BatchModeAtomic {
+		err = s.subscriptionRepo.WithTx(ctx, func(repo *repository.Subscription) error {
+			for _, operation := range params.Operations {
+				subscription, err := s.applyOperation(ctx, repo, operation)
+				if err != nil {
+					return &domain.BatchItemError{Index: operation.Index, Err: err}
+				}
+				results = append(results, &domain.BatchItemResult{Index: operation.Index, Subscription: subscription})
+			}
+
+			return nil
+		})
+		if err != nil {
+			logger.Warn("Batch rolled back", slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		logger.Info("Finish batch")
+		return results, nil
+	}
+
+	for _, operation := range params.Operations {
+		subscription, err := s.applyOperation(ctx, s.subscriptionRepo, operation)
+		if err != nil {
+			logger.Warn("Batch operation failed",
+				slog.Int("index", operation.Index),
+				slog.String("error", err.Error()),
+			)
+		}
+		results = append(results, &domain.BatchItemResult{Index: operation.Index, Subscription: subscription, Err: err})
+	}
+
+	logger.Info("Finish batch")
+	return results, nil
+}
+
+func (s *Subscription) applyOperation(ctx context.Context, repo *repository.Subscription, operation *domain.BatchOperation) (*domain.Subscription, error) {
+	switch operation.Type {
+	case domain.BatchOperationCreate:
+		if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, operation.Create.UserUUID); err != nil {
+			return nil, err
+		}
+		subscription := newSubscription(operation.Create)
+		if err := repo.CreateSubscription(ctx, subscription); err != nil {
+			return nil, err
+		}
+		return subscription, nil
+	case domain.BatchOperationUpdate:
+		return repo.UpdateSubscription(ctx, operation.UUID, operation.Update, subscriptionCheck(ctx, domain.PermissionSubscriptionsWrite))
+	case domain.BatchOperationDelete:
+		_, err := repo.DeleteSubscription(ctx, operation.UUID, subscriptionCheck(ctx, domain.PermissionSubscriptionsDelete))
+		return nil, err
+	default:
+		return nil, fmt.Errorf("unknown operation %q", operation.Type)
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const feedTokenBytes = 32
+
+type Calendar struct {
+	logger           *slog.Logger
+	tokenRepo        *repository.CalendarToken
+	subscriptionRepo *repository.Subscription
+	candidateRepo    *repository.SubscriptionCandidate
+}
+
+func NewCalendar(
+	baseLogger *slog.Logger,
+	tokenRepo *repository.CalendarToken,
+	subscriptionRepo *repository.Subscription,
+	candidateRepo *repository.SubscriptionCandidate,
+) *Calendar {
+	logger := baseLogger.WithGroup("calendar service")
+
+	return &Calendar{
+		logger:           logger,
+		tokenRepo:        tokenRepo,
+		subscriptionRepo: subscriptionRepo,
+		candidateRepo:    candidateRepo,
+	}
+}
+
+// IssueFeedToken выпускает новый токен ссылки на календарь. Старый токен
+// перестает действовать.
+func (c *Calendar) IssueFeedToken(ctx context.Context, userID uuid.UUID) (*domain.CalendarFeedToken, error) {
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, userID); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := c.tokenRepo.SaveTokenHash(ctx, userID, hashFeedToken(token)); err != nil {
+		c.logger.Error("Failed to save calendar token", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Issued calendar token", slog.String("user_id", userID.String()))
+	return &domain.CalendarFeedToken{
+		UserUUID:  userID,
+		Token:     token,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func (c *Calendar) RevokeFeedToken(ctx context.Context, userID uuid.UUID) error {
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, userID); err != nil {
+		return err
+	}
+
+	return c.tokenRepo.DeleteToken(ctx, userID)
+}
+
+// FeedSubscriptions проверяет токен и возвращает все подписки пользователя в организации,
+// где выпущен токен: календарные клиенты не передают заголовок организации. Цена подписки
+// месячная, поэтому подписка продлевается ежемесячно, если только она не подтверждена из
+// кандидата выписки с другим периодом.
+func (c *Calendar) FeedSubscriptions(ctx context.Context, userID uuid.UUID, token string) ([]*domain.CalendarSubscription, error) {
+	if token == "" {
+		return nil, domain.ErrInvalidFeedToken
+	}
+	organizationID, err := c.tokenRepo.FindTokenOrganization(ctx, userID, hashFeedToken(token))
+	if err != nil {
+		return nil, err
+	}
+	ctx = domain.WithTenant(ctx, organizationID)
+
+	periods, err := c.candidateRepo.ConfirmedPeriods(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptions []*domain.CalendarSubscription
+	params := &domain.ExportSubscriptionsParams{
+		Filter: domain.SubscriptionFilter{UserID: &userID},
+		Sort:   []domain.SortField{{Field: "start_date"}},
+	}
+	err = c.subscriptionRepo.StreamSubscriptions(ctx, params, func(subscription *domain.Subscription) error {
+		period, ok := periods[subscription.UUID]
+		if !ok {
+			period = domain.RecurrenceMonthly
+		}
+		subscriptions = append(subscriptions, &domain.CalendarSubscription{Subscription: subscription, Period: period})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
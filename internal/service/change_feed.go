@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+const (
+	changeFeedPageSize        = 500
+	changeFeedCleanupInterval = 10 * time.Minute
+)
+
+// ChangeFeed раздает изменения подписок открытым SSE-потокам. Один экземпляр держит
+// LISTEN на subscription_changes, по уведомлению дочитывает новые строки и рассылает
+// их потокам, которым они подходят по фильтру. Пропущенное при переподключении
+// поток дочитывает из таблицы сам.
+type ChangeFeed struct {
+	logger     *slog.Logger
+	cfg        config.EventsConfig
+	changeRepo *repository.SubscriptionChange
+
+	mu      sync.Mutex
+	streams map[*ChangeStream]struct{}
+	stopped bool
+}
+
+func NewChangeFeed(baseLogger *slog.Logger, cfg config.EventsConfig, changeRepo *repository.SubscriptionChange) *ChangeFeed {
+	logger := baseLogger.WithGroup("change feed service")
+
+	return &ChangeFeed{
+		logger:     logger,
+		cfg:        cfg,
+		changeRepo: changeRepo,
+		streams:    make(map[*ChangeStream]struct{}),
+	}
+}
+
+// Run рассылает изменения, пока не отменен ctx, после чего закрывает все потоки.
+// Заодно удаляет изменения старше Retention.
+func (f *ChangeFeed) Run(ctx context.Context) {
+	f.logger.Info("Change feed started")
+	defer f.stop()
+
+	lastID, err := f.changeRepo.LastChangeID(ctx)
+	for err != nil {
+		f.logger.Error("Failed to get last change id", slog.String("error", err.Error()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.cfg.PollInterval):
+		}
+		lastID, err = f.changeRepo.LastChangeID(ctx)
+	}
+
+	cleanup := time.NewTicker(changeFeedCleanupInterval)
+	defer cleanup.Stop()
+
+	var listener *repository.Listener
+	defer func() {
+		if listener != nil {
+			listener.Close()
+		}
+	}()
+
+	for ctx.Err() == nil {
+		if listener == nil {
+			if listener, err = f.changeRepo.Listen(ctx); err != nil && ctx.Err() == nil {
+				f.logger.Warn("Failed to listen for subscription changes, falling back to polling", slog.String("error", err.Error()))
+			}
+		}
+
+		// изменения читаются после LISTEN, чтобы не потерять те, что пришли между проходами
+		lastID = f.dispatch(ctx, lastID)
+
+		select {
+		case <-cleanup.C:
+			f.deleteExpired(ctx)
+		default:
+		}
+
+		if listener == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(f.cfg.PollInterval):
+			}
+			continue
+		}
+
+		if err := listener.Wait(ctx, f.cfg.PollInterval); err != nil && ctx.Err() == nil {
+			f.logger.Warn("Change listener failed", slog.String("error", err.Error()))
+			listener.Close()
+			listener = nil
+		}
+	}
+
+	f.logger.Info("Change feed stopped")
+}
+
+// dispatch рассылает изменения после lastID и возвращает id последнего разосланного
+func (f *ChangeFeed) dispatch(ctx context.Context, lastID int64) int64 {
+	for ctx.Err() == nil {
+		changes, err := f.changeRepo.ListChangesAfter(ctx, lastID, nil, changeFeedPageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				f.logger.Error("Failed to read subscription changes", slog.String("error", err.Error()))
+			}
+			return lastID
+		}
+		if len(changes) == 0 {
+			return lastID
+		}
+
+		f.broadcast(changes)
+		lastID = changes[len(changes)-1].ID
+
+		if len(changes) < changeFeedPageSize {
+			return lastID
+		}
+	}
+
+	return lastID
+}
+
+func (f *ChangeFeed) broadcast(changes []*domain.SubscriptionChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for stream := range f.streams {
+		// поток, который дочитывает таблицу, получит эти изменения из нее же
+		if stream.replaying {
+			continue
+		}
+
+		for _, change := range changes {
+			if !stream.filter.Matches(change) {
+				continue
+			}
+
+			select {
+			case stream.live <- change:
+				continue
+			default:
+			}
+
+			f.logger.Warn("Closing lagged change stream", slog.Int("buffer_size", f.cfg.BufferSize))
+			f.closeStreamLocked(stream, domain.ErrChangeStreamLagged)
+			break
+		}
+	}
+}
+
+func (f *ChangeFeed) deleteExpired(ctx context.Context) {
+	deleted, err := f.changeRepo.DeleteChangesBefore(ctx, time.Now().Add(-f.cfg.Retention))
+	if err != nil {
+		f.logger.Error("Failed to delete expired subscription changes", slog.String("error", err.Error()))
+		return
+	}
+
+	if deleted > 0 {
+		f.logger.Info("Deleted expired subscription changes", slog.Int64("count", deleted))
+	}
+}
+
+func (f *ChangeFeed) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stopped = true
+	for stream := range f.streams {
+		f.closeStreamLocked(stream, domain.ErrChangeStreamClosed)
+	}
+}
+
+func (f *ChangeFeed) closeStreamLocked(stream *ChangeStream, err error) {
+	if _, ok := f.streams[stream]; !ok {
+		return
+	}
+
+	delete(f.streams, stream)
+	stream.err = err
+	close(stream.live)
+}
+
+// Subscribe открывает поток изменений. Если передан lastEventID, поток сначала
+// отдает сохраненные изменения после него, затем новые. Пока поток дочитывает
+// таблицу, рассылка его пропускает, поэтому долгое дочитывание не переполняет
+// буфер и не закрывает поток как отставший. Вызывающему без права на
+// чтение данных всех пользователей доступны только изменения своих подписок. Поток
+// ограничен организацией запроса.
+func (f *ChangeFeed) Subscribe(ctx context.Context, filter domain.ChangeFilter, lastEventID *int64) (*ChangeStream, error) {
+	if err := scopeUserID(ctx, domain.PermissionSubscriptionsRead, &filter.UserUUID); err != nil {
+		return nil, err
+	}
+	organizationID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrTenantRequired
+	}
+	filter.OrganizationID = &organizationID
+
+	stream := &ChangeStream{
+		feed:   f,
+		filter: filter,
+		live:   make(chan *domain.SubscriptionChange, f.cfg.BufferSize),
+	}
+	if lastEventID != nil {
+		stream.replaying = true
+		stream.lastID = *lastEventID
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stopped {
+		return nil, domain.ErrChangeStreamClosed
+	}
+	f.streams[stream] = struct{}{}
+
+	requestLogger(ctx, f.logger).Info("Change stream opened",
+		slog.Int("streams", len(f.streams)),
+		slog.Bool("resumed", lastEventID != nil),
+	)
+	return stream, nil
+}
+
+// ChangeStream поток изменений одного клиента, читается из одной горутины
+type ChangeStream struct {
+	feed   *ChangeFeed
+	filter domain.ChangeFilter
+	live   chan *domain.SubscriptionChange
+	err    error
+	// replaying поток дочитывает изменения из таблицы и не получает рассылку,
+	// меняется под feed.mu
+	replaying bool
+	// lastPageShort предыдущая выборка из таблицы была неполной
+	lastPageShort bool
+	pending       []*domain.SubscriptionChange
+	lastID        int64
+}
+
+// Next возвращает следующее изменение или nil, если за timeout изменений не было.
+// Изменения, уже отданные при дочитывании из таблицы, повторно не возвращаются.
+func (s *ChangeStream) Next(ctx context.Context, timeout time.Duration) (*domain.SubscriptionChange, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		if len(s.pending) > 0 {
+			change := s.pending[0]
+			s.pending = s.pending[1:]
+			s.lastID = change.ID
+			return change, nil
+		}
+
+		if s.isReplaying() {
+			if err := s.replay(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		select {
+		case change, ok := <-s.live:
+			if !ok {
+				return nil, s.err
+			}
+			if change.ID <= s.lastID {
+				continue
+			}
+			s.lastID = change.ID
+			return change, nil
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// replay читает из таблицы следующую страницу изменений после lastID. Когда страница
+// неполная, поток включается в рассылку и читает таблицу еще раз: изменения, разосланные
+// до включения, попадут в эту выборку, а их копии из рассылки отбросятся по id.
+// Если и повторная выборка заполнила страницу, поток снова дочитывает таблицу.
+func (s *ChangeStream) replay(ctx context.Context) error {
+	catchingUp := s.lastPageShort
+	if catchingUp {
+		s.setReplaying(false)
+	}
+
+	changes, err := s.feed.changeRepo.ListChangesAfter(ctx, s.lastID, &s.filter, changeFeedPageSize)
+	if err != nil {
+		return err
+	}
+	s.pending = changes
+
+	s.lastPageShort = len(changes) < changeFeedPageSize
+	if catchingUp && !s.lastPageShort {
+		s.setReplaying(true)
+	}
+
+	return nil
+}
+
+func (s *ChangeStream) isReplaying() bool {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	return s.replaying
+}
+
+// setReplaying переключает поток между дочитыванием таблицы и рассылкой. При
+// возврате к дочитыванию буфер очищается: все из него поток прочитает из таблицы.
+func (s *ChangeStream) setReplaying(replaying bool) {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	s.replaying = replaying
+	if !replaying {
+		return
+	}
+
+	for {
+		select {
+		case _, ok := <-s.live:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *ChangeStream) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	s.feed.closeStreamLocked(s, domain.ErrChangeStreamClosed)
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/pgtest"
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func testChange(id int64, userID uuid.UUID, serviceName string) *domain.SubscriptionChange {
+	return &domain.SubscriptionChange{
+		ID:   id,
+		Type: domain.EventSubscriptionUpdated,
+		Subscription: &domain.Subscription{
+			UUID:        uuid.New(),
+			ServiceName: serviceName,
+			UserUUID:    userID,
+		},
+	}
+}
+
+func TestChangeFeedBroadcastFilter(t *testing.T) {
+	ctx := domain.WithTenant(context.Background(), domain.DefaultOrganizationID)
+	alice, bob := uuid.New(), uuid.New()
+	netflix := "NETFLIX"
+
+	feed := NewChangeFeed(slog.New(slog.DiscardHandler), config.EventsConfig{BufferSize: 10}, nil)
+	aliceStream, err := feed.Subscribe(ctx, domain.ChangeFilter{UserUUID: &alice}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	netflixStream, err := feed.Subscribe(ctx, domain.ChangeFilter{ServiceName: &netflix}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	otherTenantStream, err := feed.Subscribe(domain.WithTenant(ctx, uuid.New()), domain.ChangeFilter{}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	feed.broadcast([]*domain.SubscriptionChange{
+		testChange(1, alice, "Spotify"),
+		testChange(2, bob, "Netflix"),
+		testChange(3, alice, "netflix"),
+	})
+
+	tests := []struct {
+		name    string
+		stream  *ChangeStream
+		wantIDs []int64
+	}{
+		{name: "user filter", stream: aliceStream, wantIDs: []int64{1, 3}},
+		{name: "service name filter ignores case", stream: netflixStream, wantIDs: []int64{2, 3}},
+		{name: "other organization", stream: otherTenantStream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, wantID := range tt.wantIDs {
+				change, err := tt.stream.Next(ctx, time.Second)
+				if err != nil || change == nil || change.ID != wantID {
+					t.Fatalf("Next() = %v, %v; want change %d", change, err, wantID)
+				}
+			}
+			if change, err := tt.stream.Next(ctx, 10*time.Millisecond); change != nil || err != nil {
+				t.Errorf("Next() = %v, %v; want no more changes", change, err)
+			}
+		})
+	}
+}
+
+func TestChangeFeedClosesLaggedStream(t *testing.T) {
+	ctx := domain.WithTenant(context.Background(), domain.DefaultOrganizationID)
+	userID := uuid.New()
+
+	feed := NewChangeFeed(slog.New(slog.DiscardHandler), config.EventsConfig{BufferSize: 2}, nil)
+	stream, err := feed.Subscribe(ctx, domain.ChangeFilter{}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	feed.broadcast([]*domain.SubscriptionChange{
+		testChange(1, userID, "Netflix"),
+		testChange(2, userID, "Netflix"),
+		testChange(3, userID, "Netflix"),
+	})
+
+	if _, ok := feed.streams[stream]; ok {
+		t.Fatal("lagged stream is still registered")
+	}
+
+	// изменения, попавшие в буфер до переполнения, отдаются, затем поток сообщает об отставании
+	for _, wantID := range []int64{1, 2} {
+		change, err := stream.Next(ctx, time.Second)
+		if err != nil || change == nil || change.ID != wantID {
+			t.Fatalf("Next() = %v, %v; want change %d", change, err, wantID)
+		}
+	}
+	if _, err := stream.Next(ctx, time.Second); !errors.Is(err, domain.ErrChangeStreamLagged) {
+		t.Errorf("Next() error = %v, want ErrChangeStreamLagged", err)
+	}
+}
+
+func TestChangeFeedSkipsReplayingStream(t *testing.T) {
+	ctx := domain.WithTenant(context.Background(), domain.DefaultOrganizationID)
+	userID := uuid.New()
+	lastEventID := int64(0)
+
+	feed := NewChangeFeed(slog.New(slog.DiscardHandler), config.EventsConfig{BufferSize: 1}, nil)
+	stream, err := feed.Subscribe(ctx, domain.ChangeFilter{}, &lastEventID)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	changes := make([]*domain.SubscriptionChange, 0, 5)
+	for id := int64(1); id <= 5; id++ {
+		changes = append(changes, testChange(id, userID, "Netflix"))
+	}
+	feed.broadcast(changes)
+
+	if _, ok := feed.streams[stream]; !ok {
+		t.Fatal("replaying stream was closed by broadcast")
+	}
+	if len(stream.live) != 0 {
+		t.Errorf("replaying stream buffered %d changes, want 0", len(stream.live))
+	}
+}
+
+// TestChangeStreamResume поток с Last-Event-ID дочитывает из таблицы больше страницы
+// изменений, пока рассылка идет с маленьким буфером, и не закрывается как отставший
+func TestChangeStreamResume(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := domain.WithTenant(context.Background(), domain.DefaultOrganizationID)
+	logger := slog.New(slog.DiscardHandler)
+	userID := uuid.New()
+
+	insertChanges := func(count int) {
+		t.Helper()
+		pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+			query := `INSERT INTO subscription_changes (event_type, subscription_id, organization_id, service_name, price, user_id, start_date, notes, created_at, update_at)
+				SELECT 'subscription.updated', gen_random_uuid(), $2, $3, g, $4, '2025-01-01', '', NOW(), NOW()
+				FROM generate_series(1, $1::int) g`
+			_, err := tx.Exec(ctx, query, count, domain.DefaultOrganizationID, "Netflix", userID)
+			return err
+		})
+	}
+	insertChanges(changeFeedPageSize*2 + 100)
+
+	var lastEventID int64
+	pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, `SELECT id FROM subscription_changes ORDER BY id OFFSET 99 LIMIT 1`).Scan(&lastEventID)
+	})
+
+	feed := NewChangeFeed(logger, config.EventsConfig{BufferSize: 5}, repository.NewSubscriptionChange(pool, logger))
+	stream, err := feed.Subscribe(ctx, domain.ChangeFilter{}, &lastEventID)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	dispatched := feed.dispatch(ctx, 0)
+
+	want := lastEventID + 1
+	for want <= dispatched {
+		change, err := stream.Next(ctx, time.Second)
+		if err != nil || change == nil {
+			t.Fatalf("Next() = %v, %v; want change %d", change, err, want)
+		}
+		if change.ID != want {
+			t.Fatalf("Next() returned change %d, want %d", change.ID, want)
+		}
+		want++
+
+		// новые изменения рассылаются, пока поток еще дочитывает таблицу
+		if want%100 == 0 {
+			insertChanges(3)
+			dispatched = feed.dispatch(ctx, dispatched)
+		}
+	}
+
+	if change, err := stream.Next(ctx, 10*time.Millisecond); change != nil || err != nil {
+		t.Fatalf("Next() after catching up = %v, %v; want no changes", change, err)
+	}
+
+	insertChanges(1)
+	feed.dispatch(ctx, dispatched)
+	change, err := stream.Next(ctx, time.Second)
+	if err != nil || change == nil || change.ID != dispatched+1 {
+		t.Errorf("Next() = %v, %v; want live change %d", change, err, dispatched+1)
+	}
+}
+
+// TestChangeStreamResumeFilter название сервиса в фильтре сравнивается целиком, символы
+// шаблона LIKE в нем ничего не подставляют
+func TestChangeStreamResumeFilter(t *testing.T) {
+	pool := pgtest.Pool(t)
+	ctx := domain.WithTenant(context.Background(), domain.DefaultOrganizationID)
+	logger := slog.New(slog.DiscardHandler)
+	userID := uuid.New()
+
+	pgtest.AllTenants(t, pool, func(tx pgx.Tx) error {
+		query := `INSERT INTO subscription_changes (event_type, subscription_id, organization_id, service_name, price, user_id, start_date, notes, created_at, update_at)
+			VALUES ('subscription.created', gen_random_uuid(), $1, $2, 100, $3, '2025-01-01', '', NOW(), NOW())`
+		for _, name := range []string{"50% off", "50 percent off", "50% OFF", "Netflix"} {
+			if _, err := tx.Exec(ctx, query, domain.DefaultOrganizationID, name, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	filter := "50% Off"
+	lastEventID := int64(0)
+	feed := NewChangeFeed(logger, config.EventsConfig{BufferSize: 10}, repository.NewSubscriptionChange(pool, logger))
+	stream, err := feed.Subscribe(ctx, domain.ChangeFilter{ServiceName: &filter}, &lastEventID)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	var names []string
+	for {
+		change, err := stream.Next(ctx, 10*time.Millisecond)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if change == nil {
+			break
+		}
+		names = append(names, change.Subscription.ServiceName)
+	}
+
+	if len(names) != 2 || names[0] != "50% off" || names[1] != "50% OFF" {
+		t.Errorf("replayed %q, want [50%% off 50%% OFF]", names)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+// healthDB проверки базы, реализуется postgres.DB
+type healthDB interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (int64, error)
+}
+
+// Health проверки готовности сервиса для оркестратора и балансировщиков
+type Health struct {
+	logger *slog.Logger
+	cfg    config.HealthConfig
+	db     healthDB
+	// migrationVersion последняя миграция, встроенная в бинарник
+	migrationVersion int64
+	shuttingDown     atomic.Bool
+}
+
+func NewHealth(baseLogger *slog.Logger, cfg config.HealthConfig, db healthDB, migrationVersion int64) *Health {
+	logger := baseLogger.WithGroup("health service")
+
+	return &Health{
+		logger:           logger,
+		cfg:              cfg,
+		db:               db,
+		migrationVersion: migrationVersion,
+	}
+}
+
+// StartShutdown переводит сервис в неготовое состояние в начале остановки, чтобы
+// балансировщики перестали направлять на него новые запросы
+func (h *Health) StartShutdown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *Health) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// Check проверяет зависимости, на каждую отводится не больше health.check_timeout
+func (h *Health) Check(ctx context.Context) *domain.Health {
+	health := &domain.Health{
+		Status:       domain.HealthStatusUp,
+		ShuttingDown: h.ShuttingDown(),
+		Dependencies: []*domain.DependencyHealth{
+			h.check(ctx, "database", h.checkDatabase),
+			h.check(ctx, "migrations", h.checkMigrations),
+		},
+	}
+
+	if health.ShuttingDown {
+		health.Status = domain.HealthStatusDown
+	}
+	for _, dependency := range health.Dependencies {
+		if dependency.Status != domain.HealthStatusUp {
+			health.Status = domain.HealthStatusDown
+		}
+	}
+
+	return health
+}
+
+func (h *Health) check(ctx context.Context, name string, fn func(context.Context, *domain.DependencyHealth) error) *domain.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.CheckTimeout)
+	defer cancel()
+
+	dependency := &domain.DependencyHealth{Name: name, Status: domain.HealthStatusUp}
+	start := time.Now()
+	err := fn(ctx, dependency)
+	dependency.Latency = time.Since(start)
+	if err != nil {
+		h.logger.Warn("Dependency is unhealthy", slog.String("dependency", name), slog.String("error", err.Error()))
+		dependency.Status = domain.HealthStatusDown
+		dependency.Error = err.Error()
+	}
+
+	return dependency
+}
+
+func (h *Health) checkDatabase(ctx context.Context, _ *domain.DependencyHealth) error {
+	return h.db.Ping(ctx)
+}
+
+// checkMigrations база должна быть обновлена хотя бы до последней миграции бинарника.
+// Более новая версия допустима: при выкатке миграции применяются до замены экземпляров.
+func (h *Health) checkMigrations(ctx context.Context, dependency *domain.DependencyHealth) error {
+	version, err := h.db.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	dependency.Details = map[string]any{
+		"version":          version,
+		"expected_version": h.migrationVersion,
+	}
+	if version < h.migrationVersion {
+		return fmt.Errorf("database is at migration %d, expected %d", version, h.migrationVersion)
+	}
+
+	return nil
+}
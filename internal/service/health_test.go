@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+type healthDatabase struct {
+	pingErr    error
+	version    int64
+	versionErr error
+	// hang ждать отмены контекста вместо ответа
+	hang bool
+}
+
+func (d healthDatabase) Ping(ctx context.Context) error {
+	if d.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return d.pingErr
+}
+
+func (d healthDatabase) MigrationVersion(context.Context) (int64, error) {
+	return d.version, d.versionErr
+}
+
+func TestHealthCheck(t *testing.T) {
+	const expected = 20261019210000
+
+	tests := []struct {
+		name         string
+		db           healthDatabase
+		shuttingDown bool
+		wantStatus   domain.HealthStatus
+		wantDown     map[string]string
+	}{
+		{name: "up", db: healthDatabase{version: expected}, wantStatus: domain.HealthStatusUp},
+		{name: "database is ahead of the binary", db: healthDatabase{version: expected + 1}, wantStatus: domain.HealthStatusUp},
+		{name: "database is unreachable", db: healthDatabase{version: expected, pingErr: errors.New("connection refused")},
+			wantStatus: domain.HealthStatusDown, wantDown: map[string]string{"database": "connection refused"}},
+		{name: "database does not answer in time", db: healthDatabase{version: expected, hang: true},
+			wantStatus: domain.HealthStatusDown, wantDown: map[string]string{"database": context.DeadlineExceeded.Error()}},
+		{name: "migrations are behind", db: healthDatabase{version: expected - 1},
+			wantStatus: domain.HealthStatusDown, wantDown: map[string]string{"migrations": "database is at migration 20261019209999, expected 20261019210000"}},
+		{name: "migration version is unavailable", db: healthDatabase{versionErr: errors.New("relation \"goose_db_version\" does not exist")},
+			wantStatus: domain.HealthStatusDown, wantDown: map[string]string{"migrations": "goose_db_version"}},
+		{name: "shutting down", db: healthDatabase{version: expected}, shuttingDown: true, wantStatus: domain.HealthStatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealth(slog.New(slog.DiscardHandler), config.HealthConfig{CheckTimeout: 10 * time.Millisecond}, tt.db, expected)
+			if tt.shuttingDown {
+				health.StartShutdown()
+			}
+
+			got := health.Check(context.Background())
+			if got.Status != tt.wantStatus || got.ShuttingDown != tt.shuttingDown {
+				t.Errorf("Check() status = %s, shutting down = %t; want %s, %t", got.Status, got.ShuttingDown, tt.wantStatus, tt.shuttingDown)
+			}
+			if len(got.Dependencies) != 2 {
+				t.Fatalf("Check() returned %d dependencies, want database and migrations", len(got.Dependencies))
+			}
+			for _, dependency := range got.Dependencies {
+				wantErr, down := tt.wantDown[dependency.Name]
+				if down != (dependency.Status == domain.HealthStatusDown) || !strings.Contains(dependency.Error, wantErr) {
+					t.Errorf("%s = %s with error %q, want down %t with %q", dependency.Name, dependency.Status, dependency.Error, down, wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+const outboxCleanupInterval = time.Hour
+
+// Outbox relay: читает события, записанные в outbox вместе с изменениями подписок,
+// и передает их всем получателям. Событие помечается опубликованным, только если
+// его приняли все получатели, иначе оно отправляется повторно с экспоненциальной
+// задержкой. Relay просыпается по NOTIFY от триггера outbox, а если уведомление
+// потерялось, перечитывает таблицу раз в PollInterval.
+//
+// Доставка «хотя бы один раз»: получатели вызываются по очереди, и если событие
+// не принял один из них, повторная попытка снова отправит его всем, в том числе тем,
+// кто уже принял. Получатели должны отбрасывать дубли по event_id, который при
+// повторах не меняется: NATS — по Nats-Msg-Id, вебхуки — по паре вебхук и event_id,
+// потребители Kafka и stdout — по заголовку или полю id события.
+type Outbox struct {
+	logger           *slog.Logger
+	cfg              config.OutboxConfig
+	outboxRepo       *repository.Outbox
+	subscriptionRepo *repository.Subscription
+	sinks            []domain.EventSink
+}
+
+func NewOutbox(
+	baseLogger *slog.Logger,
+	cfg config.OutboxConfig,
+	outboxRepo *repository.Outbox,
+	subscriptionRepo *repository.Subscription,
+	sinks ...domain.EventSink,
+) *Outbox {
+	logger := baseLogger.WithGroup("outbox relay")
+
+	return &Outbox{
+		logger:           logger,
+		cfg:              cfg,
+		outboxRepo:       outboxRepo,
+		subscriptionRepo: subscriptionRepo,
+		sinks:            sinks,
+	}
+}
+
+// Run рассылает события, пока не отменен ctx. Заодно раз в EndingSoonInterval
+// записывает события subscription.ending_soon и удаляет старые опубликованные события.
+func (o *Outbox) Run(ctx context.Context) {
+	names := make([]string, 0, len(o.sinks))
+	for _, sink := range o.sinks {
+		names = append(names, sink.Name())
+	}
+	o.logger.Info("Outbox relay started", slog.Any("sinks", names))
+
+	endingSoon := time.NewTicker(o.cfg.EndingSoonInterval)
+	defer endingSoon.Stop()
+	cleanup := time.NewTicker(outboxCleanupInterval)
+	defer cleanup.Stop()
+
+	var listener *repository.Listener
+	defer func() {
+		if listener != nil {
+			listener.Close()
+		}
+	}()
+
+	o.claimEndingSoon(ctx)
+	for ctx.Err() == nil {
+		o.relay(ctx)
+
+		select {
+		case <-endingSoon.C:
+			o.claimEndingSoon(ctx)
+		case <-cleanup.C:
+			o.deletePublished(ctx)
+		default:
+		}
+
+		if listener == nil {
+			var err error
+			if listener, err = o.outboxRepo.Listen(ctx); err != nil && ctx.Err() == nil {
+				o.logger.Warn("Failed to listen for outbox notifications, falling back to polling", slog.String("error", err.Error()))
+			}
+		}
+
+		if listener == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(o.cfg.PollInterval):
+			}
+			continue
+		}
+
+		if err := listener.Wait(ctx, o.cfg.PollInterval); err != nil && ctx.Err() == nil {
+			o.logger.Warn("Outbox listener failed", slog.String("error", err.Error()))
+			listener.Close()
+			listener = nil
+		}
+	}
+
+	o.logger.Info("Outbox relay stopped")
+}
+
+// relay разбирает outbox пачками, пока очередная пачка заполнена целиком
+func (o *Outbox) relay(ctx context.Context) {
+	nextAttempt := func(failed int) time.Time {
+		return time.Now().Add(retryDelay(failed, o.cfg.RetryBaseDelay, o.cfg.RetryMaxDelay))
+	}
+
+	for ctx.Err() == nil {
+		processed, err := o.outboxRepo.ProcessBatch(ctx, o.cfg.BatchSize, nextAttempt, o.send)
+		if err != nil {
+			if ctx.Err() == nil {
+				o.logger.Error("Failed to relay outbox events", slog.String("error", err.Error()))
+			}
+			return
+		}
+		if processed < o.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// send передает событие получателям по порядку и останавливается на первой ошибке:
+// следующие получатели его не получат, а предыдущие получат повторно при новой попытке
+func (o *Outbox) send(ctx context.Context, event *domain.OutboxEvent) error {
+	for _, sink := range o.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			o.logger.Warn("Failed to send outbox event",
+				slog.String("sink", sink.Name()),
+				slog.String("event_id", event.EventID.String()),
+				slog.String("event_type", string(event.Type)),
+				slog.Int("attempt", event.Attempts+1),
+				slog.String("error", err.Error()),
+			)
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (o *Outbox) claimEndingSoon(ctx context.Context) {
+	claimed, err := o.subscriptionRepo.ClaimEndingSoon(ctx, o.cfg.EndingSoonDays)
+	if err != nil {
+		if ctx.Err() == nil {
+			o.logger.Error("Failed to find subscriptions ending soon", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	if claimed > 0 {
+		o.logger.Info("Recorded ending soon events", slog.Int("count", claimed))
+	}
+}
+
+func (o *Outbox) deletePublished(ctx context.Context) {
+	deleted, err := o.outboxRepo.DeletePublished(ctx, time.Now().Add(-o.cfg.Retention))
+	if err != nil {
+		o.logger.Error("Failed to delete published outbox events", slog.String("error", err.Error()))
+		return
+	}
+
+	if deleted > 0 {
+		o.logger.Info("Deleted published outbox events", slog.Int64("count", deleted))
+	}
+}
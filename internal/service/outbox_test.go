@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// recordingSink запоминает принятые события и возвращает ошибки из errs по очереди
+type recordingSink struct {
+	name     string
+	errs     []error
+	received []uuid.UUID
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Send(_ context.Context, event *domain.OutboxEvent) error {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+
+	s.received = append(s.received, event.EventID)
+	return nil
+}
+
+func TestOutboxSendPartialFailure(t *testing.T) {
+	first := &recordingSink{name: "first"}
+	failing := &recordingSink{name: "failing", errs: []error{errors.New("broker unavailable"), nil}}
+	last := &recordingSink{name: "last"}
+
+	outbox := NewOutbox(slog.New(slog.DiscardHandler), config.OutboxConfig{}, nil, nil, first, failing, last)
+	event := &domain.OutboxEvent{Position: 1, EventID: uuid.New(), Type: domain.EventSubscriptionCreated}
+
+	err := outbox.send(context.Background(), event)
+	if err == nil || err.Error() != "failing: broker unavailable" {
+		t.Fatalf("first attempt error = %v, want failing: broker unavailable", err)
+	}
+	if len(first.received) != 1 || len(failing.received) != 0 || len(last.received) != 0 {
+		t.Fatalf("after failed attempt received = %d, %d, %d; want 1, 0, 0",
+			len(first.received), len(failing.received), len(last.received))
+	}
+
+	// Событие не опубликовано, поэтому relay отправляет его снова всем получателям
+	event.Attempts++
+	if err := outbox.send(context.Background(), event); err != nil {
+		t.Fatalf("retry error = %v", err)
+	}
+
+	if len(first.received) != 2 {
+		t.Errorf("first sink received %d events, want redelivery", len(first.received))
+	}
+	for _, id := range first.received {
+		if id != event.EventID {
+			t.Errorf("redelivered event id = %s, want stable %s for deduplication", id, event.EventID)
+		}
+	}
+	if len(failing.received) != 1 || len(last.received) != 1 {
+		t.Errorf("after retry received = %d, %d; want 1, 1", len(failing.received), len(last.received))
+	}
+}
+
+func TestOutboxSendStopsOnFirstError(t *testing.T) {
+	failing := &recordingSink{name: "failing", errs: []error{errors.New("timeout")}}
+	next := &recordingSink{name: "next"}
+
+	outbox := NewOutbox(slog.New(slog.DiscardHandler), config.OutboxConfig{}, nil, nil, failing, next)
+	event := &domain.OutboxEvent{Position: 1, EventID: uuid.New(), Type: domain.EventSubscriptionDeleted}
+
+	if err := outbox.send(context.Background(), event); err == nil {
+		t.Fatal("send() error = nil, want error")
+	}
+	if len(next.received) != 0 {
+		t.Errorf("next sink received %d events after an earlier sink failed, want 0", len(next.received))
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// amountTolerance допустимое отклонение суммы внутри одной группы списаний
+	amountTolerance = 0.1
+	// minRecurrenceConfidence доля интервалов, которые должны совпасть с периодом
+	minRecurrenceConfidence = 0.75
+	maxServiceNameLength    = 64
+	maxMerchantWords        = 3
+)
+
+// recurrenceRule описывает, как распознать период по интервалам между списаниями
+type recurrenceRule struct {
+	period         domain.RecurrencePeriod
+	minDays        int
+	maxDays        int
+	minOccurrences int
+	// graceDays через сколько дней после последнего списания подписка считается прекращенной
+	graceDays int
+	months    int
+}
+
+var recurrenceRules = []recurrenceRule{
+	{period: domain.RecurrenceMonthly, minDays: 25, maxDays: 35, minOccurrences: 3, graceDays: 45, months: 1},
+	{period: domain.RecurrenceYearly, minDays: 350, maxDays: 380, minOccurrences: 2, graceDays: 400, months: 12},
+}
+
+// merchantStopWords служебные слова из назначения платежа, не относящиеся к получателю
+var merchantStopWords = map[string]bool{
+	"www": true, "com": true, "ru": true, "net": true, "org": true, "io": true, "tv": true,
+	"pos": true, "card": true, "payment": true, "purchase": true, "debit": true, "recurring": true,
+	"inc": true, "ltd": true, "llc": true, "bill": true,
+	"оплата": true, "покупка": true, "списание": true, "карта": true, "ооо": true, "ип": true,
+}
+
+// normalizeMerchant приводит описание операции к ключу получателя: убирает
+// цифры, знаки, домены и служебные слова. "NETFLIX.COM 866-579" -> "netflix".
+func normalizeMerchant(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	key := make([]string, 0, maxMerchantWords)
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < 2 || merchantStopWords[word] {
+			continue
+		}
+		key = append(key, word)
+		if len(key) == maxMerchantWords {
+			break
+		}
+	}
+
+	return strings.Join(key, " ")
+}
+
+// detectRecurringCharges группирует списания по получателю и сумме и ищет
+// среди групп ежемесячные и ежегодные повторения
+func detectRecurringCharges(userID uuid.UUID, transactions []domain.BankTransaction) []*domain.SubscriptionCandidate {
+	charges := selectCharges(transactions)
+	if len(charges) == 0 {
+		return nil
+	}
+
+	statementEnd := charges[0].Date
+	byMerchant := make(map[string][]domain.BankTransaction)
+	for _, charge := range charges {
+		if charge.Date.After(statementEnd) {
+			statementEnd = charge.Date
+		}
+		merchant := normalizeMerchant(charge.Description)
+		if merchant == "" {
+			continue
+		}
+		byMerchant[merchant] = append(byMerchant[merchant], charge)
+	}
+
+	var candidates []*domain.SubscriptionCandidate
+	for merchant, merchantCharges := range byMerchant {
+		for _, cluster := range clusterByAmount(merchantCharges) {
+			candidate := detectRecurrence(cluster, statementEnd)
+			if candidate == nil {
+				continue
+			}
+			candidate.UUID = uuid.New()
+			candidate.UserUUID = userID
+			candidate.Merchant = merchant
+			candidate.ServiceName = serviceNameFromMerchant(merchant)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b *domain.SubscriptionCandidate) int {
+		if a.Confidence != b.Confidence {
+			if a.Confidence > b.Confidence {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	})
+
+	return candidates
+}
+
+// selectCharges оставляет списания с положительной суммой. Если в выписке нет
+// отрицательных сумм, все операции считаются списаниями.
+func selectCharges(transactions []domain.BankTransaction) []domain.BankTransaction {
+	hasNegative := slices.ContainsFunc(transactions, func(t domain.BankTransaction) bool { return t.Amount < 0 })
+
+	charges := make([]domain.BankTransaction, 0, len(transactions))
+	for _, t := range transactions {
+		switch {
+		case hasNegative && t.Amount < 0:
+			t.Amount = -t.Amount
+		case hasNegative || t.Amount <= 0:
+			continue
+		}
+		charges = append(charges, t)
+	}
+
+	return charges
+}
+
+// clusterByAmount делит списания одного получателя на группы с близкой суммой
+func clusterByAmount(charges []domain.BankTransaction) [][]domain.BankTransaction {
+	slices.SortFunc(charges, func(a, b domain.BankTransaction) int { return cmp.Compare(a.Amount, b.Amount) })
+
+	var clusters [][]domain.BankTransaction
+	var base int64
+	for _, charge := range charges {
+		if len(clusters) == 0 || float64(charge.Amount) > float64(base)*(1+amountTolerance) {
+			clusters = append(clusters, nil)
+			base = charge.Amount
+		}
+		clusters[len(clusters)-1] = append(clusters[len(clusters)-1], charge)
+	}
+
+	return clusters
+}
+
+func detectRecurrence(charges []domain.BankTransaction, statementEnd time.Time) *domain.SubscriptionCandidate {
+	slices.SortFunc(charges, func(a, b domain.BankTransaction) int { return a.Date.Compare(b.Date) })
+
+	for _, rule := range recurrenceRules {
+		if len(charges) < rule.minOccurrences {
+			continue
+		}
+
+		matched := 0
+		for i := 1; i < len(charges); i++ {
+			days := int(charges[i].Date.Sub(charges[i-1].Date).Hours() / 24)
+			if days >= rule.minDays && days <= rule.maxDays {
+				matched++
+			}
+		}
+		confidence := float64(matched) / float64(len(charges)-1)
+		if confidence < minRecurrenceConfidence {
+			continue
+		}
+
+		first, last := charges[0], charges[len(charges)-1]
+		chargeAmount := int(math.Round(float64(last.Amount) / 100))
+		candidate := &domain.SubscriptionCandidate{
+			Price:          int(math.Round(float64(chargeAmount) / float64(rule.months))),
+			ChargeAmount:   chargeAmount,
+			Period:         rule.period,
+			StartDate:      monthStart(first.Date),
+			Occurrences:    len(charges),
+			LastChargeDate: last.Date,
+			Confidence:     math.Round(confidence*100) / 100,
+		}
+		if statementEnd.Sub(last.Date) > time.Duration(rule.graceDays)*24*time.Hour {
+			endDate := monthStart(last.Date)
+			candidate.EndDate = &endDate
+		}
+
+		return candidate
+	}
+
+	return nil
+}
+
+func serviceNameFromMerchant(merchant string) string {
+	words := strings.Fields(merchant)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	name := []rune(strings.Join(words, " "))
+	if len(name) > maxServiceNameLength {
+		name = name[:maxServiceNameLength]
+	}
+
+	return string(name)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
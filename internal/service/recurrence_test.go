@@ -0,0 +1,219 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// charges списания одного получателя на одну сумму в указанные даты
+func charges(description string, amount int64, dates ...time.Time) []domain.BankTransaction {
+	transactions := make([]domain.BankTransaction, 0, len(dates))
+	for _, date := range dates {
+		transactions = append(transactions, domain.BankTransaction{Date: date, Description: description, Amount: amount})
+	}
+
+	return transactions
+}
+
+func TestNormalizeMerchant(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{description: "NETFLIX.COM 866-579", want: "netflix"},
+		{description: "Оплата Яндекс Плюс", want: "яндекс плюс"},
+		{description: "POS PURCHASE Spotify AB Stockholm SE", want: "spotify ab stockholm"},
+		{description: "ООО \"Кинопоиск\" карта *1234", want: "кинопоиск"},
+		{description: "www.ivi.ru", want: "ivi"},
+		{description: "12345 / 678", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeMerchant(tt.description); got != tt.want {
+			t.Errorf("normalizeMerchant(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestServiceNameFromMerchant(t *testing.T) {
+	tests := []struct {
+		merchant string
+		want     string
+	}{
+		{merchant: "netflix", want: "Netflix"},
+		{merchant: "яндекс плюс", want: "Яндекс Плюс"},
+		{merchant: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := serviceNameFromMerchant(tt.merchant); got != tt.want {
+			t.Errorf("serviceNameFromMerchant(%q) = %q, want %q", tt.merchant, got, tt.want)
+		}
+	}
+}
+
+func TestSelectCharges(t *testing.T) {
+	date := day(2025, time.July, 1)
+
+	tests := []struct {
+		name        string
+		amounts     []int64
+		wantAmounts []int64
+	}{
+		{name: "negative amounts are charges", amounts: []int64{-99900, 150000, -29900}, wantAmounts: []int64{99900, 29900}},
+		{name: "positive amounts when there are no negative", amounts: []int64{99900, 0, 29900}, wantAmounts: []int64{99900, 29900}},
+		{name: "empty", amounts: nil, wantAmounts: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var transactions []domain.BankTransaction
+			for _, amount := range tt.amounts {
+				transactions = append(transactions, domain.BankTransaction{Date: date, Amount: amount})
+			}
+
+			got := make([]int64, 0)
+			for _, charge := range selectCharges(transactions) {
+				got = append(got, charge.Amount)
+			}
+			if !reflect.DeepEqual(got, tt.wantAmounts) {
+				t.Fatalf("selectCharges() amounts = %v, want %v", got, tt.wantAmounts)
+			}
+		})
+	}
+}
+
+func TestClusterByAmount(t *testing.T) {
+	var transactions []domain.BankTransaction
+	for _, amount := range []int64{29900, 99900, 31000, 104900, 29900} {
+		transactions = append(transactions, domain.BankTransaction{Amount: amount})
+	}
+
+	var got [][]int64
+	for _, cluster := range clusterByAmount(transactions) {
+		var amounts []int64
+		for _, charge := range cluster {
+			amounts = append(amounts, charge.Amount)
+		}
+		got = append(got, amounts)
+	}
+
+	want := [][]int64{{29900, 29900, 31000}, {99900, 104900}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("clusterByAmount() = %v, want %v", got, want)
+	}
+}
+
+func TestDetectRecurringCharges(t *testing.T) {
+	userID := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	march := day(2025, time.March, 1)
+
+	tests := []struct {
+		name         string
+		transactions []domain.BankTransaction
+		want         []*domain.SubscriptionCandidate
+	}{
+		{
+			name: "monthly",
+			transactions: charges("NETFLIX.COM 866-579", -99900,
+				day(2025, time.January, 5), day(2025, time.February, 5), day(2025, time.March, 5), day(2025, time.April, 5)),
+			want: []*domain.SubscriptionCandidate{{
+				Merchant: "netflix", ServiceName: "Netflix", Price: 999, ChargeAmount: 999,
+				Period: domain.RecurrenceMonthly, StartDate: day(2025, time.January, 1),
+				Occurrences: 4, LastChargeDate: day(2025, time.April, 5), Confidence: 1,
+			}},
+		},
+		{
+			name:         "yearly price is spread over months",
+			transactions: charges("Яндекс Плюс", -239900, day(2023, time.March, 10), day(2024, time.March, 10)),
+			want: []*domain.SubscriptionCandidate{{
+				Merchant: "яндекс плюс", ServiceName: "Яндекс Плюс", Price: 200, ChargeAmount: 2399,
+				Period: domain.RecurrenceYearly, StartDate: day(2023, time.March, 1),
+				Occurrences: 2, LastChargeDate: day(2024, time.March, 10), Confidence: 1,
+			}},
+		},
+		{
+			name: "stopped subscription gets an end date",
+			transactions: append(
+				charges("Spotify", -29900, day(2025, time.January, 12), day(2025, time.February, 12), day(2025, time.March, 12)),
+				charges("Перекресток", -154300, day(2025, time.June, 30))...,
+			),
+			want: []*domain.SubscriptionCandidate{{
+				Merchant: "spotify", ServiceName: "Spotify", Price: 299, ChargeAmount: 299,
+				Period: domain.RecurrenceMonthly, StartDate: day(2025, time.January, 1), EndDate: &march,
+				Occurrences: 3, LastChargeDate: day(2025, time.March, 12), Confidence: 1,
+			}},
+		},
+		{
+			name: "irregular intervals",
+			transactions: charges("Spotify", -29900,
+				day(2025, time.January, 12), day(2025, time.February, 12), day(2025, time.March, 12), day(2025, time.March, 19)),
+		},
+		{
+			name:         "too few charges",
+			transactions: charges("Spotify", -29900, day(2025, time.January, 12), day(2025, time.February, 12)),
+		},
+		{
+			name: "different amounts are not mixed",
+			transactions: append(
+				charges("Spotify", -29900, day(2025, time.January, 12), day(2025, time.March, 12)),
+				charges("Spotify", -59900, day(2025, time.February, 12))...,
+			),
+		},
+		{
+			name: "incoming payments are ignored",
+			transactions: append(
+				charges("Spotify", -29900, day(2025, time.January, 12)),
+				charges("Salary", 10000000, day(2025, time.January, 10), day(2025, time.February, 10), day(2025, time.March, 10))...,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectRecurringCharges(userID, tt.transactions)
+			if len(got) != len(tt.want) {
+				t.Fatalf("detectRecurringCharges() returned %d candidates, want %d: %+v", len(got), len(tt.want), got)
+			}
+
+			for i, candidate := range got {
+				if candidate.UUID == uuid.Nil || candidate.UserUUID != userID {
+					t.Errorf("candidate %d ids = %s, %s", i, candidate.UUID, candidate.UserUUID)
+				}
+				candidate.UUID, candidate.UserUUID = uuid.Nil, uuid.Nil
+				if !reflect.DeepEqual(candidate, tt.want[i]) {
+					t.Errorf("candidate %d = %+v, want %+v", i, candidate, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectRecurringChargesOrder(t *testing.T) {
+	transactions := append(
+		charges("Spotify", -29900, day(2025, time.January, 12), day(2025, time.February, 12),
+			day(2025, time.March, 12), day(2025, time.April, 12), day(2025, time.April, 20)),
+		charges("Netflix", -99900, day(2025, time.January, 5), day(2025, time.February, 5), day(2025, time.March, 5))...,
+	)
+	transactions = append(transactions,
+		charges("Apple", -14900, day(2025, time.January, 20), day(2025, time.February, 20), day(2025, time.March, 20))...)
+
+	var got []string
+	for _, candidate := range detectRecurringCharges(uuid.New(), transactions) {
+		got = append(got, candidate.ServiceName)
+	}
+
+	// сначала более уверенные кандидаты, при равной уверенности — по названию
+	if want := []string{"Apple", "Netflix", "Spotify"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Role назначение ролей пользователям. Роли из токена дополняются назначенными здесь,
+// управлять назначениями могут только администраторы.
+type Role struct {
+	logger   *slog.Logger
+	roleRepo *repository.Role
+}
+
+func NewRole(baseLogger *slog.Logger, roleRepo *repository.Role) *Role {
+	logger := baseLogger.WithGroup("role service")
+
+	return &Role{
+		logger:   logger,
+		roleRepo: roleRepo,
+	}
+}
+
+func (r *Role) AssignRole(ctx context.Context, userID uuid.UUID, role domain.Role) (*domain.RoleAssignment, error) {
+	if err := authorize(ctx, domain.PermissionRolesManage); err != nil {
+		return nil, err
+	}
+
+	var grantedBy *uuid.UUID
+	if principal, ok := domain.PrincipalFromContext(ctx); ok && principal.APIKeyUUID == nil {
+		grantedBy = &principal.Subject
+	}
+
+	assignment, err := r.roleRepo.AssignRole(ctx, userID, role, grantedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	requestLogger(ctx, r.logger).Info("Role assigned",
+		slog.String("user_id", userID.String()),
+		slog.String("role", string(role)),
+	)
+	return assignment, nil
+}
+
+func (r *Role) RevokeRole(ctx context.Context, userID uuid.UUID, role domain.Role) error {
+	if err := authorize(ctx, domain.PermissionRolesManage); err != nil {
+		return err
+	}
+
+	if err := r.roleRepo.RevokeRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	requestLogger(ctx, r.logger).Info("Role revoked",
+		slog.String("user_id", userID.String()),
+		slog.String("role", string(role)),
+	)
+	return nil
+}
+
+// ListRoles возвращает роли, назначенные через API. Роли из токена здесь не видны.
+func (r *Role) ListRoles(ctx context.Context, userID uuid.UUID) ([]*domain.RoleAssignment, error) {
+	if err := authorize(ctx, domain.PermissionRolesManage); err != nil {
+		return nil, err
+	}
+
+	return r.roleRepo.ListRoles(ctx, userID)
+}
+
+// WithAssignedRoles дополняет роли пользователей из next назначенными через API в их
+// организации. Роли читаются на каждый запрос, поэтому отзыв действует сразу.
+// API-ключей это не касается.
+func (r *Role) WithAssignedRoles(next domain.Authenticator) domain.Authenticator {
+	return &roleAuthenticator{next: next, roleRepo: r.roleRepo}
+}
+
+type roleAuthenticator struct {
+	next     domain.Authenticator
+	roleRepo *repository.Role
+}
+
+func (a *roleAuthenticator) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	principal, err := a.next.Authenticate(ctx, token)
+	if err != nil || principal.APIKeyUUID != nil {
+		return principal, err
+	}
+
+	// аутентификация идет до выбора организации запроса, поэтому роли читаются
+	// в организации токена; без нее вызывающий работает только в организации по умолчанию
+	organizationID := domain.DefaultOrganizationID
+	if principal.OrganizationID != nil {
+		organizationID = *principal.OrganizationID
+	}
+	assignments, err := a.roleRepo.ListRoles(domain.WithTenant(ctx, organizationID), principal.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("load assigned roles: %w", err)
+	}
+	for _, assignment := range assignments {
+		if !slices.Contains(principal.Roles, assignment.Role) {
+			principal.Roles = append(principal.Roles, assignment.Role)
+		}
+	}
+
+	return principal, nil
+}
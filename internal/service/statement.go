@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type Statement struct {
+	logger              *slog.Logger
+	candidateRepo       *repository.SubscriptionCandidate
+	subscriptionService *Subscription
+}
+
+func NewStatement(baseLogger *slog.Logger, candidateRepo *repository.SubscriptionCandidate, subscriptionService *Subscription) *Statement {
+	logger := baseLogger.WithGroup("statement service")
+
+	return &Statement{
+		logger:              logger,
+		candidateRepo:       candidateRepo,
+		subscriptionService: subscriptionService,
+	}
+}
+
+// AnalyzeStatement ищет в выписке повторяющиеся списания и сохраняет их как
+// кандидатов в подписки. Получатели, которые у пользователя уже заведены
+// подписками, пропускаются. Предыдущие неподтвержденные кандидаты заменяются.
+func (s *Statement) AnalyzeStatement(ctx context.Context, userID uuid.UUID, transactions []domain.BankTransaction) ([]*domain.SubscriptionCandidate, error) {
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, userID); err != nil {
+		return nil, err
+	}
+
+	logger := requestLogger(ctx, s.logger)
+
+	logger.Info("Analyzing bank statement",
+		slog.String("user_id", userID.String()),
+		slog.Int("transactions", len(transactions)),
+	)
+
+	known := make(map[string]bool)
+	params := &domain.ExportSubscriptionsParams{Filter: domain.SubscriptionFilter{UserID: &userID}}
+	err := s.subscriptionService.ExportSubscriptions(ctx, params, func(subscription *domain.Subscription) error {
+		known[normalizeMerchant(subscription.ServiceName)] = true
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to load user subscriptions", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	detected := detectRecurringCharges(userID, transactions)
+	candidates := make([]*domain.SubscriptionCandidate, 0, len(detected))
+	for _, candidate := range detected {
+		if !known[candidate.Merchant] {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if err := s.candidateRepo.ReplacePendingCandidates(ctx, userID, candidates); err != nil {
+		logger.Error("Failed to save subscription candidates", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("Finish analyze bank statement",
+		slog.Int("detected", len(detected)),
+		slog.Int("candidates", len(candidates)),
+	)
+	return candidates, nil
+}
+
+func (s *Statement) ListCandidates(ctx context.Context, userID uuid.UUID) ([]*domain.SubscriptionCandidate, error) {
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsRead, userID); err != nil {
+		return nil, err
+	}
+
+	return s.candidateRepo.ListPendingCandidates(ctx, userID)
+}
+
+// ConfirmCandidates создает подписки по выбранным кандидатам одной транзакцией: кандидаты
+// блокируются, поэтому повторное или параллельное подтверждение не создаст дубликатов, а при
+// любой ошибке, в том числе из-за опечатки в id, не создается ни одна подписка.
+func (s *Statement) ConfirmCandidates(ctx context.Context, userID uuid.UUID, params []*domain.ConfirmCandidateParams) ([]*domain.Subscription, error) {
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, userID); err != nil {
+		return nil, err
+	}
+
+	logger := requestLogger(ctx, s.logger)
+
+	var subscriptions []*domain.Subscription
+	err := s.subscriptionService.subscriptionRepo.WithTx(ctx, func(repo *repository.Subscription) error {
+		candidateRepo := s.candidateRepo.InTx(repo)
+		subscriptions = make([]*domain.Subscription, 0, len(params))
+		for _, p := range params {
+			candidate, err := candidateRepo.GetPendingCandidate(ctx, userID, p.UUID)
+			if err != nil {
+				return fmt.Errorf("candidate %s: %w", p.UUID, err)
+			}
+
+			subscription := newSubscription(toCandidateSubscriptionParams(candidate, p))
+			if err := repo.CreateSubscription(ctx, subscription); err != nil {
+				return err
+			}
+			if err := candidateRepo.MarkConfirmed(ctx, p.UUID, subscription.UUID); err != nil {
+				return fmt.Errorf("candidate %s: %w", p.UUID, err)
+			}
+			subscriptions = append(subscriptions, subscription)
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to confirm subscription candidates", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("Confirmed subscription candidates", slog.Int("count", len(subscriptions)))
+	return subscriptions, nil
+}
+
+func (s *Statement) DismissCandidate(ctx context.Context, userID, candidateID uuid.UUID) error {
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, userID); err != nil {
+		return err
+	}
+
+	return s.candidateRepo.DeleteCandidate(ctx, userID, candidateID)
+}
+
+func toCandidateSubscriptionParams(candidate *domain.SubscriptionCandidate, params *domain.ConfirmCandidateParams) *domain.CreateSubscriptionParams {
+	create := &domain.CreateSubscriptionParams{
+		ServiceName: candidate.ServiceName,
+		Price:       candidate.Price,
+		UserUUID:    candidate.UserUUID,
+		StartDate:   candidate.StartDate,
+		EndDate:     candidate.EndDate,
+	}
+	if candidate.Period == domain.RecurrenceYearly {
+		create.Notes = fmt.Sprintf("годовой платеж %d", candidate.ChargeAmount)
+	}
+
+	if params.ServiceName != nil {
+		create.ServiceName = *params.ServiceName
+	}
+	if params.Price != nil {
+		create.Price = *params.Price
+	}
+	if params.StartDate != nil {
+		create.StartDate = *params.StartDate
+	}
+	if params.EndDate != nil {
+		create.EndDate = params.EndDate
+	}
+
+	return create
+}
@@ -7,11 +7,13 @@ import (
 
 	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
 	"github.com/ent1k1377/subscriptions/internal/domain"
-	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Subscription сервис подписок. События об изменениях записываются репозиторием
+// в outbox той же транзакцией, что и сами изменения, и рассылаются relay.
 type Subscription struct {
 	logger           *slog.Logger
 	subscriptionRepo *repository.Subscription
@@ -26,56 +28,210 @@ func NewSubscription(baseLogger *slog.Logger, subscriptionRepo *repository.Subsc
 	}
 }
 
-func (s *Subscription) CreateSubscription(ctx context.Context, params *domain.CreateSubscriptionParams) error {
-	logger := s.logger.With("request_id", ctx.Value(middleware.RequestIDKey).(string))
+func (s *Subscription) CreateSubscription(ctx context.Context, params *domain.CreateSubscriptionParams) (subscription *domain.Subscription, err error) {
+	ctx, span := startSpan(ctx, "Subscription.CreateSubscription")
+	defer endSpan(span, &err)
 
-	subscription := &domain.Subscription{
-		UUID:        uuid.New(),
-		ServiceName: params.ServiceName,
-		Price:       params.Price,
-		UserUUID:    params.UserUUID,
-		StartDate:   params.StartDate,
-		EndDate:     params.EndDate,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	logger := requestLogger(ctx, s.logger)
+
+	if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, params.UserUUID); err != nil {
+		logger.Warn("Creating subscription for another user is forbidden", slog.Any("user_id", params.UserUUID))
+		return nil, err
 	}
 
+	subscription = newSubscription(params)
+
 	logger.Info("Creating subscription",
 		slog.Any("user_id", subscription.UserUUID),
 		slog.String("service_name", subscription.ServiceName),
 		slog.Int("price", subscription.Price),
 	)
 
-	err := s.subscriptionRepo.CreateSubscription(ctx, subscription)
+	err = s.subscriptionRepo.CreateSubscription(ctx, subscription)
 	if err != nil {
 		logger.Error("Failed to create subscription",
 			slog.String("error", err.Error()),
 		)
-		return err
+		return nil, err
 	}
 
 	logger.Info("Finish create subscription",
 		slog.Any("user_id", subscription.UserUUID),
 	)
-	return nil
+	return subscription, nil
+}
+
+// ImportSubscriptions создает подписки одной транзакцией через COPY
+func (s *Subscription) ImportSubscriptions(ctx context.Context, params []*domain.CreateSubscriptionParams) (subscriptions []*domain.Subscription, err error) {
+	ctx, span := startSpan(ctx, "Subscription.ImportSubscriptions", attribute.Int("subscriptions.count", len(params)))
+	defer endSpan(span, &err)
+
+	logger := requestLogger(ctx, s.logger)
+
+	subscriptions = make([]*domain.Subscription, 0, len(params))
+	for _, p := range params {
+		if err := authorizeUser(ctx, domain.PermissionSubscriptionsWrite, p.UserUUID); err != nil {
+			logger.Warn("Importing subscriptions for another user is forbidden", slog.Any("user_id", p.UserUUID))
+			return nil, err
+		}
+		subscriptions = append(subscriptions, newSubscription(p))
+	}
+
+	logger.Info("Importing subscriptions", slog.Int("count", len(subscriptions)))
+	err = s.subscriptionRepo.WithTx(ctx, func(repo *repository.Subscription) error {
+		_, err := repo.CopySubscriptions(ctx, subscriptions)
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to import subscriptions", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("Finish import subscriptions", slog.Int("count", len(subscriptions)))
+	return subscriptions, nil
+}
+
+// GetSubscription возвращает подписку, при asOf — в состоянии на этот момент
+func (s *Subscription) GetSubscription(ctx context.Context, uuid uuid.UUID, asOf *time.Time) (subscription *domain.Subscription, err error) {
+	ctx, span := startSpan(ctx, "Subscription.GetSubscription", attribute.String("subscription.id", uuid.String()))
+	defer endSpan(span, &err)
+
+	subscription, err = s.subscriptionRepo.GetSubscription(ctx, uuid, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeSubscription(ctx, domain.PermissionSubscriptionsRead, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *Subscription) UpdateSubscription(ctx context.Context, uuid uuid.UUID, params *domain.UpdateSubscriptionParams) (subscription *domain.Subscription, err error) {
+	ctx, span := startSpan(ctx, "Subscription.UpdateSubscription", attribute.String("subscription.id", uuid.String()))
+	defer endSpan(span, &err)
+
+	return s.subscriptionRepo.UpdateSubscription(ctx, uuid, params, subscriptionCheck(ctx, domain.PermissionSubscriptionsWrite))
+}
+
+func (s *Subscription) DeleteSubscription(ctx context.Context, uuid uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "Subscription.DeleteSubscription", attribute.String("subscription.id", uuid.String()))
+	defer endSpan(span, &err)
+
+	_, err = s.subscriptionRepo.DeleteSubscription(ctx, uuid, subscriptionCheck(ctx, domain.PermissionSubscriptionsDelete))
+	return err
+}
+
+// RestoreSubscription восстанавливает удаленную подписку в последнем состоянии перед удалением.
+// Право на восстановление такое же, как на изменение подписки.
+func (s *Subscription) RestoreSubscription(ctx context.Context, uuid uuid.UUID) (subscription *domain.Subscription, err error) {
+	ctx, span := startSpan(ctx, "Subscription.RestoreSubscription", attribute.String("subscription.id", uuid.String()))
+	defer endSpan(span, &err)
+
+	logger := requestLogger(ctx, s.logger)
+
+	err = s.subscriptionRepo.WithTx(ctx, func(repo *repository.Subscription) error {
+		deleted, err := repo.GetDeletedSubscription(ctx, uuid)
+		if err != nil {
+			return err
+		}
+		if err := authorizeSubscription(ctx, domain.PermissionSubscriptionsWrite, deleted); err != nil {
+			return err
+		}
+
+		subscription = deleted
+		return repo.RestoreSubscription(ctx, subscription)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Restored subscription", slog.String("uuid", uuid.String()))
+	return subscription, nil
 }
 
-func (s *Subscription) GetSubscription(uuid uuid.UUID) (*domain.Subscription, error) {
-	return s.subscriptionRepo.GetSubscription(uuid)
+// ListSubscriptions возвращает страницу подписок. Вызывающему без права на чтение
+// данных всех пользователей доступны только свои подписки.
+func (s *Subscription) ListSubscriptions(ctx context.Context, params *domain.ListSubscriptionParams) (page *domain.SubscriptionPage, err error) {
+	ctx, span := startSpan(ctx, "Subscription.ListSubscriptions")
+	defer endSpan(span, &err)
+
+	if err := scopeFilter(ctx, domain.PermissionSubscriptionsRead, &params.Filter); err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepo.ListSubscriptions(ctx, params)
 }
 
-func (s *Subscription) UpdateSubscription(uuid uuid.UUID, params *domain.UpdateSubscriptionParams) error {
-	return s.subscriptionRepo.UpdateSubscription(uuid, params)
+func (s *Subscription) ExportSubscriptions(ctx context.Context, params *domain.ExportSubscriptionsParams, fn func(*domain.Subscription) error) (err error) {
+	ctx, span := startSpan(ctx, "Subscription.ExportSubscriptions")
+	defer endSpan(span, &err)
+
+	if err := scopeFilter(ctx, domain.PermissionSubscriptionsExport, &params.Filter); err != nil {
+		return err
+	}
+
+	return s.subscriptionRepo.StreamSubscriptions(ctx, params, fn)
 }
 
-func (s *Subscription) DeleteSubscription(uuid uuid.UUID) error {
-	return s.subscriptionRepo.DeleteSubscription(uuid)
+// ListUsersSubscriptions загружает подписки нескольких пользователей одним запросом
+// и раскладывает их по пользователям
+func (s *Subscription) ListUsersSubscriptions(ctx context.Context, userIDs []uuid.UUID) (result map[uuid.UUID][]*domain.Subscription, err error) {
+	ctx, span := startSpan(ctx, "Subscription.ListUsersSubscriptions", attribute.Int("users.count", len(userIDs)))
+	defer endSpan(span, &err)
+
+	params := &domain.ExportSubscriptionsParams{
+		Filter: domain.SubscriptionFilter{UserIDs: userIDs},
+		Sort:   []domain.SortField{{Field: "start_date"}},
+	}
+	if err := scopeFilter(ctx, domain.PermissionSubscriptionsRead, &params.Filter); err != nil {
+		return nil, err
+	}
+
+	result = make(map[uuid.UUID][]*domain.Subscription, len(userIDs))
+	err = s.subscriptionRepo.StreamSubscriptions(ctx, params, func(subscription *domain.Subscription) error {
+		result[subscription.UserUUID] = append(result[subscription.UserUUID], subscription)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-func (s *Subscription) ListSubscriptions(params *domain.ListSubscriptionParams) ([]*domain.Subscription, error) {
-	return s.subscriptionRepo.ListSubscriptions(params)
+func (s *Subscription) TotalCostSubscriptions(ctx context.Context, params *domain.TotalCostSubscriptionsParams) (total int, err error) {
+	ctx, span := startSpan(ctx, "Subscription.TotalCostSubscriptions")
+	defer endSpan(span, &err)
+
+	if err := scopeFilter(ctx, domain.PermissionReportsRead, &params.Filter); err != nil {
+		return 0, err
+	}
+
+	return s.subscriptionRepo.TotalCostSubscriptions(ctx, params)
 }
 
-func (s *Subscription) TotalCostSubscriptions(params *domain.TotalCostSubscriptionsParams) (int, error) {
-	return s.subscriptionRepo.TotalCostSubscriptions(params)
+func (s *Subscription) SearchSubscriptions(ctx context.Context, params *domain.SearchSubscriptionsParams) (results []*domain.SearchResult, err error) {
+	ctx, span := startSpan(ctx, "Subscription.SearchSubscriptions")
+	defer endSpan(span, &err)
+
+	if err := scopeUserID(ctx, domain.PermissionSubscriptionsRead, &params.UserID); err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepo.SearchSubscriptions(ctx, params)
+}
+
+func newSubscription(params *domain.CreateSubscriptionParams) *domain.Subscription {
+	return &domain.Subscription{
+		UUID:        uuid.New(),
+		ServiceName: params.ServiceName,
+		Price:       params.Price,
+		UserUUID:    params.UserUUID,
+		StartDate:   params.StartDate,
+		EndDate:     params.EndDate,
+		Notes:       params.Notes,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
 }
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// SummarizeSubscriptions считает сводку по уже загруженным подпискам пользователя.
+// Подписка активна в месяце, если началась не позже него и не закончилась раньше.
+func SummarizeSubscriptions(userID uuid.UUID, subscriptions []*domain.Subscription, month time.Time) *domain.UserSummary {
+	month = monthStart(month)
+	summary := &domain.UserSummary{
+		UserUUID:     userID,
+		TotalCount:   len(subscriptions),
+		ServiceNames: []string{},
+	}
+
+	for _, subscription := range subscriptions {
+		if !activeIn(subscription, month) {
+			continue
+		}
+
+		summary.ActiveCount++
+		summary.MonthlyCost += subscription.Price
+		if !slices.Contains(summary.ServiceNames, subscription.ServiceName) {
+			summary.ServiceNames = append(summary.ServiceNames, subscription.ServiceName)
+		}
+	}
+	slices.Sort(summary.ServiceNames)
+
+	return summary
+}
+
+// ForecastCost прогноз расходов на months месяцев начиная с from по действующим подпискам
+func ForecastCost(subscriptions []*domain.Subscription, from time.Time, months int) []*domain.MonthCost {
+	from = monthStart(from)
+	forecast := make([]*domain.MonthCost, 0, months)
+
+	for i := range months {
+		month := from.AddDate(0, i, 0)
+		cost := 0
+		for _, subscription := range subscriptions {
+			if activeIn(subscription, month) {
+				cost += subscription.Price
+			}
+		}
+		forecast = append(forecast, &domain.MonthCost{Month: month, Cost: cost})
+	}
+
+	return forecast
+}
+
+// TotalCost повторяет условие TotalCostSubscriptions репозитория для уже загруженных
+// подписок: подписка учитывается, если началась не раньше startDate и закончилась
+// не позже endDate или бессрочна
+func TotalCost(subscriptions []*domain.Subscription, startDate, endDate time.Time) int {
+	total := 0
+	for _, subscription := range subscriptions {
+		if subscription.StartDate.Before(startDate) {
+			continue
+		}
+		if subscription.EndDate != nil && subscription.EndDate.After(endDate) {
+			continue
+		}
+		total += subscription.Price
+	}
+
+	return total
+}
+
+func activeIn(subscription *domain.Subscription, month time.Time) bool {
+	if subscription.StartDate.After(month) {
+		return false
+	}
+
+	return subscription.EndDate == nil || !subscription.EndDate.Before(month)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestSpan спан метода сервиса становится потомком спана вызывающего, а ошибка
+// метода записывается в спан событием и статусом
+func TestSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+		wantEvents int
+	}{
+		{name: "success", wantStatus: codes.Unset},
+		{name: "error", err: errors.New("subscription not found"), wantStatus: codes.Error, wantEvents: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /api/subscriptions/:uuid")
+
+			method := func(ctx context.Context) (err error) {
+				_, span := startSpan(ctx, "Subscription.GetSubscription", attribute.String("subscription.id", "6ba7b810"))
+				defer endSpan(span, &err)
+
+				return tt.err
+			}
+			if err := method(ctx); !errors.Is(err, tt.err) {
+				t.Fatalf("method error = %v, want %v", err, tt.err)
+			}
+			parent.End()
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("recorded %d spans, want 2", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "Subscription.GetSubscription" || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("span %q has parent %s, want a child of the request span", span.Name(), span.Parent().SpanID())
+			}
+			if span.Status().Code != tt.wantStatus || len(span.Events()) != tt.wantEvents {
+				t.Errorf("span status = %v with %d events, want %v with %d", span.Status().Code, len(span.Events()), tt.wantStatus, tt.wantEvents)
+			}
+			if tt.err != nil && span.Status().Description != tt.err.Error() {
+				t.Errorf("span status description = %q, want %q", span.Status().Description, tt.err.Error())
+			}
+		})
+	}
+}
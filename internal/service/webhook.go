@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/database/postgres/repository"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/tracing"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSecretBytes = 32
+	// maxResponseErrorBytes сколько байт тела ответа сохранять в журнал при ошибке
+	maxResponseErrorBytes = 512
+
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Webhook управляет вебхуками и отправляет им события. Как получатель outbox он
+// только ставит отправки в очередь, сами запросы выполняет Run.
+type Webhook struct {
+	logger      *slog.Logger
+	cfg         config.WebhookConfig
+	webhookRepo *repository.Webhook
+	client      *http.Client
+}
+
+func NewWebhook(baseLogger *slog.Logger, cfg config.WebhookConfig, webhookRepo *repository.Webhook) *Webhook {
+	logger := baseLogger.WithGroup("webhook service")
+
+	return &Webhook{
+		logger:      logger,
+		cfg:         cfg,
+		webhookRepo: webhookRepo,
+		client:      newWebhookClient(cfg.Timeout, cfg.AllowPrivateNetworks),
+	}
+}
+
+// CreateWebhook регистрирует вебхук. Если секрет не задан, он генерируется.
+func (w *Webhook) CreateWebhook(ctx context.Context, params *domain.CreateWebhookParams) (*domain.Webhook, error) {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookURL(params.URL, w.cfg.AllowPrivateNetworks); err != nil {
+		return nil, err
+	}
+
+	secret := params.Secret
+	if secret == "" {
+		raw := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(raw)
+	}
+
+	webhook := &domain.Webhook{
+		UUID:   uuid.New(),
+		URL:    params.URL,
+		Secret: secret,
+		Events: params.Events,
+		Active: true,
+	}
+	if err := w.webhookRepo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	requestLogger(ctx, w.logger).Info("Webhook created",
+		slog.String("webhook_id", webhook.UUID.String()),
+		slog.String("url", webhook.URL),
+	)
+	return webhook, nil
+}
+
+func (w *Webhook) GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return nil, err
+	}
+
+	return w.webhookRepo.GetWebhook(ctx, id)
+}
+
+func (w *Webhook) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return nil, err
+	}
+
+	return w.webhookRepo.ListWebhooks(ctx)
+}
+
+func (w *Webhook) UpdateWebhook(ctx context.Context, id uuid.UUID, params *domain.UpdateWebhookParams) (*domain.Webhook, error) {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookURL(params.URL, w.cfg.AllowPrivateNetworks); err != nil {
+		return nil, err
+	}
+
+	return w.webhookRepo.UpdateWebhook(ctx, id, params)
+}
+
+func (w *Webhook) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return err
+	}
+
+	return w.webhookRepo.DeleteWebhook(ctx, id)
+}
+
+func (w *Webhook) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.webhookRepo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return w.webhookRepo.ListDeliveries(ctx, webhookID, limit)
+}
+
+// Redeliver ставит в очередь повторную отправку того же события с тем же телом.
+// Исходная отправка в журнале не меняется.
+func (w *Webhook) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	if err := authorize(ctx, domain.PermissionWebhooksManage); err != nil {
+		return nil, err
+	}
+
+	original, err := w.webhookRepo.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{
+		UUID:          uuid.New(),
+		WebhookUUID:   original.WebhookUUID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := w.webhookRepo.CreateDeliveries(ctx, []*domain.WebhookDelivery{delivery}); err != nil {
+		return nil, err
+	}
+
+	requestLogger(ctx, w.logger).Info("Webhook delivery requeued",
+		slog.String("delivery_id", original.UUID.String()),
+		slog.String("new_delivery_id", delivery.UUID.String()),
+	)
+	return delivery, nil
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send ставит событие из outbox в очередь отправки подходящим вебхукам. Повторная
+// передача того же события не создает новых отправок.
+func (w *Webhook) Send(ctx context.Context, event *domain.OutboxEvent) error {
+	return w.webhookRepo.EnqueueEvent(ctx, event)
+}
+
+// Run выполняет отправки из очереди, пока не отменен ctx
+func (w *Webhook) Run(ctx context.Context) {
+	w.logger.Info("Webhook dispatcher started")
+
+	poll := time.NewTicker(w.cfg.PollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Webhook dispatcher stopped")
+			return
+		case <-poll.C:
+			w.dispatchDue(ctx)
+		}
+	}
+}
+
+func (w *Webhook) dispatchDue(ctx context.Context) {
+	// lease с запасом покрывает все попытки прохода, чтобы отправку не забрал другой экземпляр
+	due, err := w.webhookRepo.ClaimDueDeliveries(ctx, w.cfg.BatchSize, 2*w.cfg.Timeout)
+	if err != nil {
+		w.logger.Error("Failed to claim webhook deliveries", slog.String("error", err.Error()))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, item := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.deliver(ctx, item)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Webhook) deliver(ctx context.Context, item *domain.DueDelivery) {
+	delivery := item.Delivery
+	logger := w.logger.With(
+		slog.String("delivery_id", delivery.UUID.String()),
+		slog.String("webhook_id", delivery.WebhookUUID.String()),
+		slog.String("event_type", string(delivery.EventType)),
+	)
+
+	statusCode, sendErr := w.send(ctx, item)
+	if ctx.Err() != nil {
+		// сервис останавливается: результат не сохраняем, отправка повторится после lease
+		return
+	}
+
+	attempt := &domain.DeliveryAttempt{
+		DeliveryUUID: delivery.UUID,
+		Status:       domain.DeliverySucceeded,
+		StatusCode:   statusCode,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		attempt.Status = domain.DeliveryPending
+		if delivery.Attempts+1 >= w.cfg.MaxAttempts {
+			attempt.Status = domain.DeliveryFailed
+		} else {
+			next := time.Now().Add(retryDelay(delivery.Attempts+1, w.cfg.RetryBaseDelay, w.cfg.RetryMaxDelay))
+			attempt.NextAttemptAt = &next
+		}
+		logger.Warn("Webhook delivery failed",
+			slog.Int("attempt", delivery.Attempts+1),
+			slog.String("status", string(attempt.Status)),
+			slog.String("error", sendErr.Error()),
+		)
+	}
+
+	if err := w.webhookRepo.RecordAttempt(ctx, attempt); err != nil {
+		logger.Error("Failed to record webhook delivery attempt", slog.String("error", err.Error()))
+	}
+}
+
+// send отправляет событие. Тело подписывается HMAC-SHA256 от "<timestamp>.<body>"
+// секретом вебхука, подпись передается в заголовке X-Webhook-Signature: t=<timestamp>,v1=<hex>.
+func (w *Webhook) send(ctx context.Context, item *domain.DueDelivery) (*int, error) {
+	delivery := item.Delivery
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, item.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "subscriptions-webhooks/1.0")
+	request.Header.Set("X-Webhook-Id", delivery.WebhookUUID.String())
+	request.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	request.Header.Set("X-Webhook-Event-Id", delivery.EventID.String())
+	request.Header.Set("X-Webhook-Delivery", delivery.UUID.String())
+	request.Header.Set(SignatureHeader, "t="+timestamp+",v1="+SignPayload(item.Secret, timestamp, delivery.Payload))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	statusCode := response.StatusCode
+	if statusCode < 200 || statusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseErrorBytes))
+		return &statusCode, fmt.Errorf("unexpected status %d: %s", statusCode, body)
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	return &statusCode, nil
+}
+
+// SignPayload вычисляет подпись тела вебхука. Получатель должен посчитать ее так же
+// и сравнить со значением v1 из заголовка.
+func SignPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay экспоненциальная задержка после failed неудачных попыток подряд:
+// base, 2*base, 4*base... не больше maxDelay
+func retryDelay(failed int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < failed && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
+// requestLogger добавляет request id, если вызов пришел из HTTP-запроса, и идентификаторы
+// трассы. Фоновые задачи вызывают сервис без request id.
+func requestLogger(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if requestID, ok := ctx.Value(middleware.RequestIDKey).(string); ok {
+		logger = logger.With("request_id", requestID)
+	}
+
+	return logger.With(tracing.LogAttrs(ctx)...)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+// blockedPrefixes диапазоны, не покрытые проверками netip.Addr: общий адрес провайдера,
+// служебные и зарезервированные сети IANA
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// validateWebhookURL отклоняет адреса, через которые вебхук мог бы обращаться к внутренним
+// сервисам: схема только http или https, хост не localhost и не адрес из закрытых сетей.
+// Имя хоста здесь не резолвится — адрес, в который оно разрешится при отправке,
+// проверяет webhookDialControl.
+func validateWebhookURL(raw string, allowPrivate bool) error {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidWebhookURL, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be http or https", domain.ErrInvalidWebhookURL)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: host is required", domain.ErrInvalidWebhookURL)
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: host %s is not allowed", domain.ErrInvalidWebhookURL, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && isBlockedAddr(addr) {
+		return fmt.Errorf("%w: address %s is not allowed", domain.ErrInvalidWebhookURL, host)
+	}
+
+	return nil
+}
+
+// isBlockedAddr адреса loopback, частных и link-local сетей (в том числе метаданные
+// облака 169.254.169.254), multicast и зарезервированные
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webhookDialControl проверяет адрес непосредственно перед соединением, уже после
+// разрешения имени: так не помогают ни DNS-записи на внутренние адреса, ни смена
+// записи после проверки URL, ни перенаправления
+func webhookDialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if isBlockedAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: address %s is not allowed", domain.ErrInvalidWebhookURL, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// newWebhookClient HTTP-клиент отправки вебхуков. Прокси из окружения не используется,
+// иначе проверялся бы адрес прокси, а не получателя.
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = webhookDialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{name: "public https", url: "https://billing.example.com/hooks"},
+		{name: "public http with port", url: "http://203.0.113.10:8080/hooks"},
+		{name: "ftp scheme", url: "ftp://example.com/hooks", wantErr: true},
+		{name: "no scheme", url: "example.com/hooks", wantErr: true},
+		{name: "no host", url: "https:///hooks", wantErr: true},
+		{name: "localhost", url: "http://localhost:8080/hooks", wantErr: true},
+		{name: "localhost subdomain", url: "http://api.localhost/hooks", wantErr: true},
+		{name: "localhost with trailing dot", url: "http://LOCALHOST./hooks", wantErr: true},
+		{name: "loopback", url: "http://127.0.0.1/hooks", wantErr: true},
+		{name: "private network", url: "http://10.1.2.3/hooks", wantErr: true},
+		{name: "cloud metadata", url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{name: "ipv6 loopback", url: "http://[::1]/hooks", wantErr: true},
+		{name: "ipv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/hooks", wantErr: true},
+		{name: "unique local ipv6", url: "http://[fd00:ec2::254]/hooks", wantErr: true},
+		{name: "carrier-grade nat", url: "http://100.64.0.1/hooks", wantErr: true},
+		{name: "unspecified", url: "http://0.0.0.0/hooks", wantErr: true},
+		{name: "private allowed", url: "http://127.0.0.1:8080/hooks", allowPrivate: true},
+		{name: "scheme checked when private allowed", url: "file:///etc/passwd", allowPrivate: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookURL(tt.url, tt.allowPrivate)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidWebhookURL) {
+					t.Fatalf("validateWebhookURL(%q) = %v, want ErrInvalidWebhookURL", tt.url, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateWebhookURL(%q) = %v, want nil", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestIsBlockedAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "8.8.8.8", want: false},
+		{addr: "203.0.113.10", want: false},
+		{addr: "2001:4860:4860::8888", want: false},
+		{addr: "127.0.0.53", want: true},
+		{addr: "192.168.1.1", want: true},
+		{addr: "172.16.0.1", want: true},
+		{addr: "169.254.169.254", want: true},
+		{addr: "224.0.0.1", want: true},
+		{addr: "198.18.0.1", want: true},
+		{addr: "255.255.255.255", want: true},
+		{addr: "fe80::1", want: true},
+		{addr: "::ffff:10.0.0.1", want: true},
+		{addr: "64:ff9b::a00:1", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isBlockedAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Fatalf("isBlockedAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	response, err := newWebhookClient(time.Second, false).Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err == nil {
+		response.Body.Close()
+		t.Fatal("request to loopback address succeeded, want error")
+	}
+	if !errors.Is(err, domain.ErrInvalidWebhookURL) {
+		t.Fatalf("request error = %v, want ErrInvalidWebhookURL", err)
+	}
+
+	response, err = newWebhookClient(time.Second, true).Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request with private networks allowed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	const (
+		base     = 30 * time.Second
+		maxDelay = 6 * time.Hour
+	)
+
+	tests := []struct {
+		failed int
+		want   time.Duration
+	}{
+		{failed: 0, want: base},
+		{failed: 1, want: base},
+		{failed: 2, want: time.Minute},
+		{failed: 3, want: 2 * time.Minute},
+		{failed: 5, want: 8 * time.Minute},
+		{failed: 9, want: 256 * base},
+		{failed: 10, want: 512 * base},
+		{failed: 11, want: maxDelay},
+		{failed: 1000, want: maxDelay},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.failed, base, maxDelay); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.failed, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelayBaseAboveMax(t *testing.T) {
+	if got := retryDelay(1, time.Hour, time.Minute); got != time.Minute {
+		t.Fatalf("retryDelay() = %s, want %s", got, time.Minute)
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "known signature",
+			secret:    "secret",
+			timestamp: "1700000000",
+			payload:   `{"type":"subscription.created"}`,
+			want:      "47f3aae239e28833793f56a336ae9184436bee03811c7f7db736ff401b2659d8",
+		},
+		{
+			name:      "other secret",
+			secret:    "other",
+			timestamp: "1700000000",
+			payload:   `{"type":"subscription.created"}`,
+			want:      "3fd5f67def4a381d43696dc336fd640590180eded15c19c1ff6d6ab62b9a2762",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SignPayload(tt.secret, tt.timestamp, []byte(tt.payload))
+			if got != tt.want {
+				t.Fatalf("SignPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogHandler добавляет в записи trace_id и span_id из контекста записи. Контекст
+// доступен для вызовов вида InfoContext и для логгеров, созданных через LogAttrs.
+type LogHandler struct {
+	next slog.Handler
+}
+
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{next: next}
+}
+
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{next: h.next.WithGroup(name)}
+}
+
+// LogAttrs trace_id и span_id текущего спана для логгера запроса, который пишет без
+// контекста: logger.With(tracing.LogAttrs(ctx)...)
+func LogAttrs(ctx context.Context) []any {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	return []any{
+		slog.String("trace_id", spanContext.TraceID().String()),
+		slog.String("span_id", spanContext.SpanID().String()),
+	}
+}
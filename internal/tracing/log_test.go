@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func testSpanContext(t *testing.T) context.Context {
+	t.Helper()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929b0e0e4736")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+// TestLogHandler идентификаторы трассы берутся из контекста записи и сохраняются
+// в производных логгерах
+func TestLogHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantTrace string
+		wantSpan  string
+	}{
+		{name: "with span", ctx: testSpanContext(t), wantTrace: "4bf92f3577b34da6a3ce929b0e0e4736", wantSpan: "00f067aa0ba902b7"},
+		{name: "without span", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewLogHandler(slog.NewJSONHandler(&buf, nil))).
+				With("request_id", "req-1").WithGroup("handler")
+
+			logger.InfoContext(tt.ctx, "Request handled")
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("unmarshal log record %q: %v", buf.String(), err)
+			}
+			if record["request_id"] != "req-1" {
+				t.Errorf("request_id = %v, want req-1", record["request_id"])
+			}
+
+			group, _ := record["handler"].(map[string]any)
+			traceID, _ := group["trace_id"].(string)
+			spanID, _ := group["span_id"].(string)
+			if traceID != tt.wantTrace || spanID != tt.wantSpan {
+				t.Errorf("trace_id = %q, span_id = %q; want %q, %q", traceID, spanID, tt.wantTrace, tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestLogAttrs(t *testing.T) {
+	if attrs := LogAttrs(context.Background()); attrs != nil {
+		t.Errorf("LogAttrs() without span = %v, want nil", attrs)
+	}
+
+	attrs := LogAttrs(testSpanContext(t))
+	want := []any{
+		slog.String("trace_id", "4bf92f3577b34da6a3ce929b0e0e4736"),
+		slog.String("span_id", "00f067aa0ba902b7"),
+	}
+	if len(attrs) != len(want) {
+		t.Fatalf("LogAttrs() = %v, want %v", attrs, want)
+	}
+	for i := range want {
+		if !attrs[i].(slog.Attr).Equal(want[i].(slog.Attr)) {
+			t.Errorf("LogAttrs()[%d] = %v, want %v", i, attrs[i], want[i])
+		}
+	}
+}
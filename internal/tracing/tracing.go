@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+// Setup устанавливает глобальные TracerProvider и распространение контекста W3C Trace
+// Context и Baggage. При выключенной трассировке возвращает nil: спаны не записываются,
+// но trace_id вызывающего из traceparent по-прежнему попадает в логи.
+// Провайдер нужно остановить через Shutdown, чтобы отправить накопленные спаны.
+func Setup(ctx context.Context, cfg config.TracingConfig, baseLogger *slog.Logger) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating tracing config: %w", err)
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		baseLogger.Warn("OpenTelemetry error", slog.String("error", err.Error()))
+	}))
+
+	return provider, nil
+}
+
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	if cfg.Exporter == config.TracingExporterStdout {
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	}
+
+	if cfg.OTLP.Protocol == config.OTLPProtocolHTTP {
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.OTLP.Endpoint),
+			otlptracehttp.WithHeaders(cfg.OTLP.Headers),
+		}
+		if cfg.OTLP.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	}
+
+	options := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.OTLP.Endpoint),
+		otlptracegrpc.WithHeaders(cfg.OTLP.Headers),
+	}
+	if cfg.OTLP.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, options...)
+}
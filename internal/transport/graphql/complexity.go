@@ -0,0 +1,97 @@
+package graphql
+
+import (
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// userSubscriptionsEstimate сколько подписок у пользователя закладывается в оценку:
+// заранее их число неизвестно
+const userSubscriptionsEstimate = 20
+
+// listMultipliers во сколько раз список умножает стоимость вложенных полей.
+// Остальные поля стоят 1 плюс стоимость вложенных.
+var listMultipliers = map[string]func(args map[string]any) int{
+	"Query.subscriptions": func(args map[string]any) int { return intArg(args["first"], defaultListLimit) },
+	"Query.users": func(args map[string]any) int {
+		ids, _ := args["ids"].([]any)
+		return len(ids)
+	},
+	"User.subscriptions": func(map[string]any) int { return userSubscriptionsEstimate },
+	"User.forecast":      func(args map[string]any) int { return intArg(args["months"], defaultForecastMonths) },
+}
+
+// complexityAnalyzer оценивает стоимость запроса до выполнения, чтобы отклонить
+// запросы, которые разворачиваются в тысячи обращений к базе
+type complexityAnalyzer struct {
+	schema *ast.Schema
+}
+
+func newComplexityAnalyzer(sdl string) (*complexityAnalyzer, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, err
+	}
+
+	return &complexityAnalyzer{schema: schema}, nil
+}
+
+// Complexity возвращает стоимость операции или ошибки разбора и валидации запроса
+func (a *complexityAnalyzer) Complexity(query, operationName string, variables map[string]any) (int, gqlerror.List) {
+	doc, errs := gqlparser.LoadQuery(a.schema, query)
+	if len(errs) > 0 {
+		return 0, errs
+	}
+
+	operation := doc.Operations.ForName(operationName)
+	if operation == nil {
+		return 0, gqlerror.List{gqlerror.Errorf("operation %q not found", operationName)}
+	}
+
+	return selectionComplexity(operation.SelectionSet, variables), nil
+}
+
+func selectionComplexity(selections ast.SelectionSet, variables map[string]any) int {
+	total := 0
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			total += fieldComplexity(selection, variables)
+		case *ast.InlineFragment:
+			total += selectionComplexity(selection.SelectionSet, variables)
+		case *ast.FragmentSpread:
+			total += selectionComplexity(selection.Definition.SelectionSet, variables)
+		}
+	}
+
+	return total
+}
+
+func fieldComplexity(field *ast.Field, variables map[string]any) int {
+	children := selectionComplexity(field.SelectionSet, variables)
+	if field.ObjectDefinition == nil {
+		return 1 + children
+	}
+
+	multiplier := 1
+	if listMultiplier, ok := listMultipliers[field.ObjectDefinition.Name+"."+field.Name]; ok {
+		multiplier = max(listMultiplier(field.ArgumentMap(variables)), 1)
+	}
+
+	return 1 + multiplier*children
+}
+
+// intArg значение аргумента приходит как int64 из текста запроса или float64 из JSON переменных
+func intArg(value any, fallback int) int {
+	switch value := value.(type) {
+	case int64:
+		return int(value)
+	case float64:
+		return int(value)
+	case int:
+		return value
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+const (
+	codeBadUserInput    = "BAD_USER_INPUT"
+	codeNotFound        = "NOT_FOUND"
+	codeForbidden       = "FORBIDDEN"
+	codeInternal        = "INTERNAL_SERVER_ERROR"
+	codeComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+)
+
+// Error ошибка резолвера с кодом в extensions.code, по которому клиент отличает
+// ошибки ввода от внутренних
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]any {
+	return map[string]any{"code": e.Code}
+}
+
+func badUserInput(format string, args ...any) *Error {
+	return &Error{Code: codeBadUserInput, Message: fmt.Sprintf(format, args...)}
+}
+
+// requirePermission объявляет право на мутацию или отчет: маршрут /api/graphql требует
+// только subscriptions:read. Чьи данные доступны, проверяет сервисный слой.
+func requirePermission(ctx context.Context, permission domain.Permission) error {
+	if principal, ok := domain.PrincipalFromContext(ctx); ok && !principal.Has(permission) {
+		return &Error{Code: codeForbidden, Message: "the " + string(permission) + " permission is required"}
+	}
+
+	return nil
+}
+
+// toError скрывает от клиента текст внутренних ошибок, как HTTP-обработчики
+func toError(err error, message string) *Error {
+	switch {
+	case errors.Is(err, domain.ErrSubscriptionNotFound):
+		return &Error{Code: codeNotFound, Message: "subscription not found"}
+	case errors.Is(err, domain.ErrForbidden):
+		return &Error{Code: codeForbidden, Message: "access to another user is forbidden"}
+	case errors.Is(err, domain.ErrInvalidSort), errors.Is(err, domain.ErrInvalidCursor):
+		return &Error{Code: codeBadUserInput, Message: err.Error()}
+	default:
+		return &Error{Code: codeInternal, Message: message}
+	}
+}
@@ -0,0 +1,49 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait сколько ждать остальные ключи пакета. Резолверы полей одного списка
+// запускаются параллельно и успевают встать в один пакет.
+const loaderWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders создаются на каждый запрос, поэтому кэш не живет дольше запроса и не
+// отдает устаревшие данные после мутаций в следующих запросах
+type loaders struct {
+	userSubscriptions *dataloader.Loader[uuid.UUID, []*domain.Subscription]
+}
+
+func withLoaders(ctx context.Context, subscriptionService *service.Subscription) context.Context {
+	batch := func(ctx context.Context, userIDs []uuid.UUID) []*dataloader.Result[[]*domain.Subscription] {
+		results := make([]*dataloader.Result[[]*domain.Subscription], len(userIDs))
+
+		byUser, err := subscriptionService.ListUsersSubscriptions(ctx, userIDs)
+		for i, userID := range userIDs {
+			if err != nil {
+				results[i] = &dataloader.Result[[]*domain.Subscription]{Error: err}
+				continue
+			}
+			results[i] = &dataloader.Result[[]*domain.Subscription]{Data: byUser[userID]}
+		}
+
+		return results
+	}
+
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		userSubscriptions: dataloader.NewBatchedLoader(batch, dataloader.WithWait[uuid.UUID, []*domain.Subscription](loaderWait)),
+	})
+}
+
+func loadUserSubscriptions(ctx context.Context, userID uuid.UUID) ([]*domain.Subscription, error) {
+	return ctx.Value(loadersKey{}).(*loaders).userSubscriptions.Load(ctx, userID)()
+}
@@ -0,0 +1,353 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	defaultListLimit  = subscription.DefaultListLimit
+	maxListLimit      = subscription.MaxListLimit
+	maxUsers          = 100
+	maxForecastMonths = 60
+)
+
+// Resolver корневой резолвер. Query и Mutation разнесены по отдельным типам:
+// graphql-go ищет у корневого резолвера метод Subscription для одноименной
+// операции, а в схеме так называется тип подписки.
+type Resolver struct {
+	logger              *slog.Logger
+	subscriptionService *service.Subscription
+}
+
+type queryResolver struct{ *Resolver }
+
+type mutationResolver struct{ *Resolver }
+
+func (r *Resolver) Query() *queryResolver {
+	return &queryResolver{r}
+}
+
+func (r *Resolver) Mutation() *mutationResolver {
+	return &mutationResolver{r}
+}
+
+type subscriptionFilterInput struct {
+	ServiceNames *[]string
+	UserID       *graphql.ID
+	PriceMin     *int32
+	PriceMax     *int32
+	StartFrom    *MonthYear
+	StartTo      *MonthYear
+	EndFrom      *MonthYear
+	EndTo        *MonthYear
+	ActiveAt     *MonthYear
+	OpenEnded    *bool
+	CreatedSince *graphql.Time
+	UpdatedSince *graphql.Time
+}
+
+type totalCostInput struct {
+	UserID      *graphql.ID
+	ServiceName *string
+	StartDate   MonthYear
+	EndDate     MonthYear
+}
+
+type createSubscriptionInput struct {
+	ServiceName string
+	Price       int32
+	UserID      graphql.ID
+	StartDate   MonthYear
+	EndDate     *MonthYear
+	Notes       *string
+}
+
+type updateSubscriptionInput struct {
+	ServiceName string
+	Price       int32
+	EndDate     *MonthYear
+	Notes       *string
+}
+
+func (r *queryResolver) Subscription(ctx context.Context, args struct{ ID graphql.ID }) (*subscriptionResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := r.subscriptionService.GetSubscription(ctx, id, nil)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get subscription", slog.String("error", err.Error()))
+		return nil, toError(err, "failed to get the subscription")
+	}
+
+	return &subscriptionResolver{subscription: subscription}, nil
+}
+
+func (r *queryResolver) Subscriptions(ctx context.Context, args struct {
+	Filter *subscriptionFilterInput
+	First  *int32
+	After  *string
+	Sort   *string
+}) (*subscriptionConnectionResolver, error) {
+	filter, err := toSubscriptionFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := defaultListLimit
+	if args.First != nil {
+		limit = int(*args.First)
+	}
+	if limit < 1 || limit > maxListLimit {
+		return nil, badUserInput("first must be between 1 and %d", maxListLimit)
+	}
+
+	params := &domain.ListSubscriptionParams{
+		Filter: filter,
+		Limit:  limit,
+		// COUNT(*) по всей выборке дорогой, считаем только если поле запрошено
+		WithTotal: graphql.HasSelectedField(ctx, "totalCount"),
+	}
+	if args.After != nil {
+		params.Cursor = *args.After
+	}
+	if args.Sort != nil {
+		if params.Sort, err = subscription.ParseSort(*args.Sort); err != nil {
+			return nil, badUserInput("%s", err.Error())
+		}
+	}
+
+	page, err := r.subscriptionService.ListSubscriptions(ctx, params)
+	if err != nil {
+		r.logger.Warn("Failed to list subscriptions", slog.String("error", err.Error()))
+		return nil, toError(err, "failed to list the subscriptions")
+	}
+
+	return &subscriptionConnectionResolver{page: page}, nil
+}
+
+func (r *queryResolver) User(args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userResolver{userID: id}, nil
+}
+
+func (r *queryResolver) Users(args struct{ IDs []graphql.ID }) ([]*userResolver, error) {
+	if len(args.IDs) > maxUsers {
+		return nil, badUserInput("ids must contain at most %d items", maxUsers)
+	}
+
+	users := make([]*userResolver, 0, len(args.IDs))
+	for _, rawID := range args.IDs {
+		id, err := parseID("ids", rawID)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &userResolver{userID: id})
+	}
+
+	return users, nil
+}
+
+func (r *queryResolver) TotalCost(ctx context.Context, args struct{ Input totalCostInput }) (int32, error) {
+	if err := requirePermission(ctx, domain.PermissionReportsRead); err != nil {
+		return 0, err
+	}
+
+	params := &domain.TotalCostSubscriptionsParams{
+		StartDate: args.Input.StartDate.Time,
+		EndDate:   args.Input.EndDate.Time,
+	}
+	if args.Input.ServiceName != nil {
+		params.Filter.ServiceNames = []string{*args.Input.ServiceName}
+	}
+	if args.Input.UserID != nil {
+		id, err := parseID("userId", *args.Input.UserID)
+		if err != nil {
+			return 0, err
+		}
+		params.Filter.UserID = &id
+	}
+
+	total, err := r.subscriptionService.TotalCostSubscriptions(ctx, params)
+	if err != nil {
+		r.logger.Error("Failed to get total cost", slog.String("error", err.Error()))
+		return 0, toError(err, "failed to get the total cost subscriptions")
+	}
+
+	return int32(total), nil
+}
+
+func (r *mutationResolver) CreateSubscription(ctx context.Context, args struct{ Input createSubscriptionInput }) (*subscriptionResolver, error) {
+	if err := requirePermission(ctx, domain.PermissionSubscriptionsWrite); err != nil {
+		return nil, err
+	}
+
+	input := args.Input
+	if err := validateSubscriptionInput(input.ServiceName, input.Price); err != nil {
+		return nil, err
+	}
+
+	userID, err := parseID("userId", input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &domain.CreateSubscriptionParams{
+		ServiceName: input.ServiceName,
+		Price:       int(input.Price),
+		UserUUID:    userID,
+		StartDate:   input.StartDate.Time,
+		EndDate:     fromMonthYear(input.EndDate),
+		Notes:       stringValue(input.Notes),
+	}
+
+	created, err := r.subscriptionService.CreateSubscription(ctx, params)
+	if err != nil {
+		return nil, toError(err, "failed to create the subscription")
+	}
+
+	return &subscriptionResolver{subscription: created}, nil
+}
+
+func (r *mutationResolver) UpdateSubscription(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateSubscriptionInput
+}) (*subscriptionResolver, error) {
+	if err := requirePermission(ctx, domain.PermissionSubscriptionsWrite); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	input := args.Input
+	if err := validateSubscriptionInput(input.ServiceName, input.Price); err != nil {
+		return nil, err
+	}
+
+	params := &domain.UpdateSubscriptionParams{
+		ServiceName: input.ServiceName,
+		Price:       int(input.Price),
+		EndDate:     fromMonthYear(input.EndDate),
+		Notes:       stringValue(input.Notes),
+	}
+
+	updated, err := r.subscriptionService.UpdateSubscription(ctx, id, params)
+	if err != nil {
+		r.logger.Warn("Failed to update subscription", slog.String("error", err.Error()))
+		return nil, toError(err, "failed to update the subscription")
+	}
+
+	return &subscriptionResolver{subscription: updated}, nil
+}
+
+func (r *mutationResolver) DeleteSubscription(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := requirePermission(ctx, domain.PermissionSubscriptionsDelete); err != nil {
+		return false, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.subscriptionService.DeleteSubscription(ctx, id); err != nil {
+		r.logger.Warn("Failed to delete subscription", slog.String("error", err.Error()))
+		return false, toError(err, "failed to delete the subscription")
+	}
+
+	return true, nil
+}
+
+func toSubscriptionFilter(input *subscriptionFilterInput) (domain.SubscriptionFilter, error) {
+	filter := domain.SubscriptionFilter{ServiceNameMatch: domain.ServiceNameIgnoreCase}
+	if input == nil {
+		return filter, nil
+	}
+
+	if input.ServiceNames != nil {
+		filter.ServiceNames = *input.ServiceNames
+	}
+	if input.UserID != nil {
+		id, err := parseID("filter.userId", *input.UserID)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = &id
+	}
+	filter.PriceMin = toIntPtr(input.PriceMin)
+	filter.PriceMax = toIntPtr(input.PriceMax)
+	if filter.PriceMin != nil && filter.PriceMax != nil && *filter.PriceMin > *filter.PriceMax {
+		return filter, badUserInput("filter.priceMin must be less than or equal to priceMax")
+	}
+	filter.StartFrom = fromMonthYear(input.StartFrom)
+	filter.StartTo = fromMonthYear(input.StartTo)
+	filter.EndFrom = fromMonthYear(input.EndFrom)
+	filter.EndTo = fromMonthYear(input.EndTo)
+	filter.ActiveAt = fromMonthYear(input.ActiveAt)
+	filter.OpenEnded = input.OpenEnded
+	if input.CreatedSince != nil {
+		filter.CreatedSince = &input.CreatedSince.Time
+	}
+	if input.UpdatedSince != nil {
+		filter.UpdatedSince = &input.UpdatedSince.Time
+	}
+
+	return filter, nil
+}
+
+func validateSubscriptionInput(serviceName string, price int32) error {
+	if serviceName == "" {
+		return badUserInput("serviceName is required")
+	}
+	if price < 0 {
+		return badUserInput("price must be greater than or equal to zero")
+	}
+
+	return nil
+}
+
+func parseID(field string, id graphql.ID) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.Nil, badUserInput("%s must be a valid UUID", field)
+	}
+
+	return parsed, nil
+}
+
+func toIntPtr(value *int32) *int {
+	if value == nil {
+		return nil
+	}
+
+	v := int(*value)
+	return &v
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
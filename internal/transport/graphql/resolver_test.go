@@ -0,0 +1,23 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+// TestToSubscriptionFilterServiceNames список сравнивает названия без учета регистра,
+// как REST и gRPC
+func TestToSubscriptionFilterServiceNames(t *testing.T) {
+	names := []string{"netflix"}
+
+	for _, input := range []*subscriptionFilterInput{nil, {ServiceNames: &names}} {
+		filter, err := toSubscriptionFilter(input)
+		if err != nil {
+			t.Fatalf("toSubscriptionFilter() error = %v", err)
+		}
+		if filter.ServiceNameMatch != domain.ServiceNameIgnoreCase {
+			t.Errorf("ServiceNameMatch = %v, want ServiceNameIgnoreCase", filter.ServiceNameMatch)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const monthYearLayout = "01-2006"
+
+// MonthYear скаляр MonthYear: месяц в формате MM-YYYY, первое число месяца в UTC
+type MonthYear struct {
+	time.Time
+}
+
+func (MonthYear) ImplementsGraphQLType(name string) bool {
+	return name == "MonthYear"
+}
+
+func (m *MonthYear) UnmarshalGraphQL(input any) error {
+	value, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("wrong type for MonthYear: %T", input)
+	}
+
+	t, err := time.Parse(monthYearLayout, value)
+	if err != nil {
+		return fmt.Errorf("MonthYear must be in MM-YYYY format")
+	}
+
+	m.Time = t
+	return nil
+}
+
+func (m MonthYear) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Format(monthYearLayout))
+}
+
+func toMonthYear(t *time.Time) *MonthYear {
+	if t == nil {
+		return nil
+	}
+
+	return &MonthYear{Time: *t}
+}
+
+func fromMonthYear(m *MonthYear) *time.Time {
+	if m == nil {
+		return nil
+	}
+
+	return &m.Time
+}
@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/service"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Schema исполняемая GraphQL-схема API подписок
+type Schema struct {
+	logger              *slog.Logger
+	schema              *graphql.Schema
+	complexity          *complexityAnalyzer
+	complexityLimit     int
+	subscriptionService *service.Subscription
+}
+
+func NewSchema(cfg config.GraphQLConfig, baseLogger *slog.Logger, subscriptionService *service.Subscription) (*Schema, error) {
+	logger := baseLogger.WithGroup("graphql")
+
+	resolver := &Resolver{
+		logger:              logger,
+		subscriptionService: subscriptionService,
+	}
+
+	schema, err := graphql.ParseSchema(schemaSDL, resolver,
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(cfg.MaxDepth),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("parse graphql schema: %w", err)
+	}
+
+	complexity, err := newComplexityAnalyzer(schemaSDL)
+	if err != nil {
+		return nil, fmt.Errorf("load graphql schema for complexity analysis: %w", err)
+	}
+
+	return &Schema{
+		logger:              logger,
+		schema:              schema,
+		complexity:          complexity,
+		complexityLimit:     cfg.ComplexityLimit,
+		subscriptionService: subscriptionService,
+	}, nil
+}
+
+// Exec проверяет сложность запроса и выполняет его. Ошибки запроса возвращаются
+// в Response.Errors, как того требует GraphQL.
+func (s *Schema) Exec(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response {
+	complexity, parseErrors := s.complexity.Complexity(query, operationName, variables)
+	if len(parseErrors) > 0 {
+		response := &graphql.Response{}
+		for _, parseError := range parseErrors {
+			queryError := &errors.QueryError{Message: parseError.Message}
+			for _, location := range parseError.Locations {
+				queryError.Locations = append(queryError.Locations, errors.Location{Line: location.Line, Column: location.Column})
+			}
+			response.Errors = append(response.Errors, queryError)
+		}
+		return response
+	}
+
+	if complexity > s.complexityLimit {
+		return &graphql.Response{Errors: []*errors.QueryError{{
+			Message:    fmt.Sprintf("query complexity %d exceeds the limit %d", complexity, s.complexityLimit),
+			Extensions: map[string]any{"code": codeComplexityLimit, "complexity": complexity, "limit": s.complexityLimit},
+		}}}
+	}
+
+	return s.schema.Exec(withLoaders(ctx, s.subscriptionService), query, operationName, variables)
+}
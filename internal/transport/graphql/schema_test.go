@@ -0,0 +1,253 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func newTestSchema(t *testing.T, cfg config.GraphQLConfig) *Schema {
+	t.Helper()
+
+	schema, err := NewSchema(cfg, slog.New(slog.DiscardHandler), nil)
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+
+	return schema
+}
+
+func TestComplexity(t *testing.T) {
+	analyzer, err := newComplexityAnalyzer(schemaSDL)
+	if err != nil {
+		t.Fatalf("newComplexityAnalyzer() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      int
+	}{
+		{
+			name:  "scalar fields",
+			query: `{ user(id: "1") { id } }`,
+			want:  2,
+		},
+		{
+			name:  "list multiplies nested fields",
+			query: `{ subscriptions(first: 10) { nodes { id serviceName } } }`,
+			want:  1 + 10*(1+2),
+		},
+		{
+			name:      "first from variables",
+			query:     `query ($first: Int) { subscriptions(first: $first) { nodes { id } } }`,
+			variables: map[string]any{"first": float64(50)},
+			want:      1 + 50*(1+1),
+		},
+		{
+			name:  "default list limit",
+			query: `{ subscriptions { nodes { id } } }`,
+			want:  1 + defaultListLimit*(1+1),
+		},
+		{
+			name:  "users by ids",
+			query: `{ users(ids: ["1", "2", "3"]) { subscriptions { id } } }`,
+			want:  1 + 3*(1+userSubscriptionsEstimate*1),
+		},
+		{
+			name:  "fragments",
+			query: `{ user(id: "1") { ...fields } } fragment fields on User { id forecast(months: 12) { cost } }`,
+			want:  1 + 1 + (1 + 12*1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := analyzer.Complexity(tt.query, "", tt.variables)
+			if len(errs) > 0 {
+				t.Fatalf("Complexity() errors = %v", errs)
+			}
+			if got != tt.want {
+				t.Errorf("Complexity() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaExecComplexityLimit(t *testing.T) {
+	schema := newTestSchema(t, config.GraphQLConfig{ComplexityLimit: 1000, MaxDepth: 15})
+
+	// 100 пользователей по 20 подписок с пользователем подписки превышают лимит,
+	// запрос отклоняется без обращения к сервису
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%q", fmt.Sprintf("00000000-0000-0000-0000-%012d", i))
+	}
+	query := fmt.Sprintf(`{ users(ids: [%s]) { subscriptions { user { id } } } }`, strings.Join(ids, ", "))
+
+	response := schema.Exec(context.Background(), query, "", nil)
+	if len(response.Errors) != 1 {
+		t.Fatalf("Exec() errors = %v, want one error", response.Errors)
+	}
+	if code := response.Errors[0].Extensions["code"]; code != codeComplexityLimit {
+		t.Errorf("error code = %v, want %s", code, codeComplexityLimit)
+	}
+	if response.Data != nil {
+		t.Errorf("Exec() data = %s, want none", response.Data)
+	}
+}
+
+func TestSchemaExecMaxDepth(t *testing.T) {
+	schema := newTestSchema(t, config.GraphQLConfig{ComplexityLimit: 1000, MaxDepth: 3})
+
+	response := schema.Exec(context.Background(), `{ user(id: "1") { subscriptions { user { id } } } }`, "", nil)
+	if len(response.Errors) == 0 {
+		t.Fatal("Exec() returned no errors for a query deeper than max depth")
+	}
+}
+
+func TestSchemaExecBadUserInput(t *testing.T) {
+	schema := newTestSchema(t, config.GraphQLConfig{ComplexityLimit: 100000, MaxDepth: 15})
+
+	ids := make([]string, maxUsers+1)
+	for i := range ids {
+		ids[i] = `"1"`
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantMessage string
+	}{
+		{
+			name:        "invalid id",
+			query:       `{ user(id: "1") { id } }`,
+			wantMessage: "id must be a valid UUID",
+		},
+		{
+			name:        "too many users",
+			query:       fmt.Sprintf(`{ users(ids: [%s]) { id } }`, strings.Join(ids, ", ")),
+			wantMessage: fmt.Sprintf("ids must contain at most %d items", maxUsers),
+		},
+		{
+			name:        "first out of range",
+			query:       `{ subscriptions(first: 0) { nodes { id } } }`,
+			wantMessage: fmt.Sprintf("first must be between 1 and %d", maxListLimit),
+		},
+		{
+			name:        "price range",
+			query:       `{ subscriptions(filter: {priceMin: 10, priceMax: 1}) { nodes { id } } }`,
+			wantMessage: "filter.priceMin must be less than or equal to priceMax",
+		},
+		{
+			name:        "negative price",
+			query:       `mutation { createSubscription(input: {serviceName: "Netflix", price: -1, userId: "1", startDate: "07-2025"}) { id } }`,
+			wantMessage: "price must be greater than or equal to zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := schema.Exec(context.Background(), tt.query, "", nil)
+			if len(response.Errors) != 1 {
+				t.Fatalf("Exec() errors = %v, want one error", response.Errors)
+			}
+			if response.Errors[0].Message != tt.wantMessage {
+				t.Errorf("error message = %q, want %q", response.Errors[0].Message, tt.wantMessage)
+			}
+			if code := response.Errors[0].Extensions["code"]; code != codeBadUserInput {
+				t.Errorf("error code = %v, want %s", code, codeBadUserInput)
+			}
+		})
+	}
+}
+
+func TestSchemaExecPermissions(t *testing.T) {
+	schema := newTestSchema(t, config.GraphQLConfig{ComplexityLimit: 100000, MaxDepth: 15})
+
+	alice, bob := uuid.New(), uuid.New()
+	keyID := uuid.New()
+	asAlice := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: alice, Roles: []domain.Role{domain.RoleUser}})
+	asSupport := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: bob, Roles: []domain.Role{domain.RoleSupport}})
+	asReadKey := domain.WithPrincipal(context.Background(), &domain.Principal{
+		APIKeyUUID: &keyID,
+		Scopes:     []string{domain.ScopeSubscriptionsRead},
+	})
+
+	createMutation := fmt.Sprintf(`mutation { createSubscription(input: {serviceName: "Netflix", price: 100, userId: %q, startDate: "07-2025"}) { id } }`, alice)
+	updateMutation := fmt.Sprintf(`mutation { updateSubscription(id: %q, input: {serviceName: "Netflix", price: 100}) { id } }`, uuid.New())
+	deleteMutation := fmt.Sprintf(`mutation { deleteSubscription(id: %q) }`, uuid.New())
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		query       string
+		wantMessage string
+	}{
+		{
+			name:        "read key cannot create",
+			ctx:         asReadKey,
+			query:       createMutation,
+			wantMessage: "the subscriptions:write permission is required",
+		},
+		{
+			name:        "support cannot update",
+			ctx:         asSupport,
+			query:       updateMutation,
+			wantMessage: "the subscriptions:write permission is required",
+		},
+		{
+			name:        "read key cannot delete",
+			ctx:         asReadKey,
+			query:       deleteMutation,
+			wantMessage: "the subscriptions:delete permission is required",
+		},
+		{
+			name:        "read key cannot read total cost",
+			ctx:         asReadKey,
+			query:       `{ totalCost(input: {startDate: "01-2025", endDate: "12-2025"}) }`,
+			wantMessage: "the reports:read permission is required",
+		},
+		{
+			name:        "read key cannot read user summary",
+			ctx:         asReadKey,
+			query:       fmt.Sprintf(`{ user(id: %q) { summary { totalCount } } }`, alice),
+			wantMessage: "the reports:read permission is required",
+		},
+		{
+			name:        "read key cannot read forecast",
+			ctx:         asReadKey,
+			query:       fmt.Sprintf(`{ user(id: %q) { forecast { cost } } }`, alice),
+			wantMessage: "the reports:read permission is required",
+		},
+		{
+			name:        "user cannot read another user",
+			ctx:         asAlice,
+			query:       fmt.Sprintf(`{ user(id: %q) { subscriptions { id } } }`, bob),
+			wantMessage: "access to another user is forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := schema.Exec(tt.ctx, tt.query, "", nil)
+			if len(response.Errors) != 1 {
+				t.Fatalf("Exec() errors = %v, want one error", response.Errors)
+			}
+			if response.Errors[0].Message != tt.wantMessage {
+				t.Errorf("error message = %q, want %q", response.Errors[0].Message, tt.wantMessage)
+			}
+			if code := response.Errors[0].Extensions["code"]; code != codeForbidden {
+				t.Errorf("error code = %v, want %s", code, codeForbidden)
+			}
+		})
+	}
+}
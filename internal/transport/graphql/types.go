@@ -0,0 +1,229 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const defaultForecastMonths = 12
+
+type subscriptionResolver struct {
+	subscription *domain.Subscription
+}
+
+func (r *subscriptionResolver) ID() graphql.ID {
+	return graphql.ID(r.subscription.UUID.String())
+}
+
+func (r *subscriptionResolver) ServiceName() string {
+	return r.subscription.ServiceName
+}
+
+func (r *subscriptionResolver) Price() int32 {
+	return int32(r.subscription.Price)
+}
+
+func (r *subscriptionResolver) UserID() graphql.ID {
+	return graphql.ID(r.subscription.UserUUID.String())
+}
+
+func (r *subscriptionResolver) User() *userResolver {
+	return &userResolver{userID: r.subscription.UserUUID}
+}
+
+func (r *subscriptionResolver) StartDate() MonthYear {
+	return MonthYear{Time: r.subscription.StartDate}
+}
+
+func (r *subscriptionResolver) EndDate() *MonthYear {
+	return toMonthYear(r.subscription.EndDate)
+}
+
+func (r *subscriptionResolver) Notes() string {
+	return r.subscription.Notes
+}
+
+func (r *subscriptionResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.subscription.CreatedAt}
+}
+
+func (r *subscriptionResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.subscription.UpdatedAt}
+}
+
+type subscriptionConnectionResolver struct {
+	page *domain.SubscriptionPage
+}
+
+func (r *subscriptionConnectionResolver) Nodes() []*subscriptionResolver {
+	return toSubscriptionResolvers(r.page.Subscriptions)
+}
+
+func (r *subscriptionConnectionResolver) NextCursor() *string {
+	return nonEmptyString(r.page.NextCursor)
+}
+
+func (r *subscriptionConnectionResolver) PrevCursor() *string {
+	return nonEmptyString(r.page.PrevCursor)
+}
+
+func (r *subscriptionConnectionResolver) TotalCount() *int32 {
+	if r.page.TotalCount == nil {
+		return nil
+	}
+
+	total := int32(*r.page.TotalCount)
+	return &total
+}
+
+// userResolver отдельной сущности пользователя нет: все поля считаются по его
+// подпискам, которые загружаются пакетно через DataLoader
+type userResolver struct {
+	userID uuid.UUID
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(r.userID.String())
+}
+
+func (r *userResolver) Subscriptions(ctx context.Context) ([]*subscriptionResolver, error) {
+	subscriptions, err := loadUserSubscriptions(ctx, r.userID)
+	if err != nil {
+		return nil, toError(err, "failed to load the subscriptions")
+	}
+
+	return toSubscriptionResolvers(subscriptions), nil
+}
+
+func (r *userResolver) Summary(ctx context.Context, args struct{ Month *MonthYear }) (*userSummaryResolver, error) {
+	if err := requirePermission(ctx, domain.PermissionReportsRead); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := loadUserSubscriptions(ctx, r.userID)
+	if err != nil {
+		return nil, toError(err, "failed to load the subscriptions")
+	}
+
+	month := time.Now()
+	if args.Month != nil {
+		month = args.Month.Time
+	}
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return &userSummaryResolver{
+		month:   MonthYear{Time: month},
+		summary: service.SummarizeSubscriptions(r.userID, subscriptions, month),
+	}, nil
+}
+
+func (r *userResolver) TotalCost(ctx context.Context, args struct {
+	StartDate MonthYear
+	EndDate   MonthYear
+}) (int32, error) {
+	if err := requirePermission(ctx, domain.PermissionReportsRead); err != nil {
+		return 0, err
+	}
+
+	subscriptions, err := loadUserSubscriptions(ctx, r.userID)
+	if err != nil {
+		return 0, toError(err, "failed to load the subscriptions")
+	}
+
+	return int32(service.TotalCost(subscriptions, args.StartDate.Time, args.EndDate.Time)), nil
+}
+
+func (r *userResolver) Forecast(ctx context.Context, args struct {
+	From   *MonthYear
+	Months *int32
+}) ([]*monthCostResolver, error) {
+	if err := requirePermission(ctx, domain.PermissionReportsRead); err != nil {
+		return nil, err
+	}
+
+	months := defaultForecastMonths
+	if args.Months != nil {
+		months = int(*args.Months)
+	}
+	if months < 1 || months > maxForecastMonths {
+		return nil, badUserInput("months must be between 1 and %d", maxForecastMonths)
+	}
+
+	subscriptions, err := loadUserSubscriptions(ctx, r.userID)
+	if err != nil {
+		return nil, toError(err, "failed to load the subscriptions")
+	}
+
+	from := time.Now()
+	if args.From != nil {
+		from = args.From.Time
+	}
+
+	forecast := service.ForecastCost(subscriptions, from, months)
+	resolvers := make([]*monthCostResolver, 0, len(forecast))
+	for _, monthCost := range forecast {
+		resolvers = append(resolvers, &monthCostResolver{monthCost: monthCost})
+	}
+
+	return resolvers, nil
+}
+
+type userSummaryResolver struct {
+	month   MonthYear
+	summary *domain.UserSummary
+}
+
+func (r *userSummaryResolver) Month() MonthYear {
+	return r.month
+}
+
+func (r *userSummaryResolver) TotalCount() int32 {
+	return int32(r.summary.TotalCount)
+}
+
+func (r *userSummaryResolver) ActiveCount() int32 {
+	return int32(r.summary.ActiveCount)
+}
+
+func (r *userSummaryResolver) MonthlyCost() int32 {
+	return int32(r.summary.MonthlyCost)
+}
+
+func (r *userSummaryResolver) ServiceNames() []string {
+	return r.summary.ServiceNames
+}
+
+type monthCostResolver struct {
+	monthCost *domain.MonthCost
+}
+
+func (r *monthCostResolver) Month() MonthYear {
+	return MonthYear{Time: r.monthCost.Month}
+}
+
+func (r *monthCostResolver) Cost() int32 {
+	return int32(r.monthCost.Cost)
+}
+
+func toSubscriptionResolvers(subscriptions []*domain.Subscription) []*subscriptionResolver {
+	resolvers := make([]*subscriptionResolver, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		resolvers = append(resolvers, &subscriptionResolver{subscription: subscription})
+	}
+
+	return resolvers
+}
+
+func nonEmptyString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
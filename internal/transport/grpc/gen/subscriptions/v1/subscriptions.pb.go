@@ -0,0 +1,943 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v5.29.3
+// source: subscriptions/v1/subscriptions.proto
+
+package subscriptionsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MonthYear месяц с точностью до года, аналог формата MM-YYYY в HTTP API
+type MonthYear struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MonthYear) Reset() {
+	*x = MonthYear{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MonthYear) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonthYear) ProtoMessage() {}
+
+func (x *MonthYear) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonthYear.ProtoReflect.Descriptor instead.
+func (*MonthYear) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MonthYear) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *MonthYear) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+type Subscription struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ServiceName string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price       int64                  `protobuf:"varint,3,opt,name=price,proto3" json:"price,omitempty"`
+	UserId      string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	StartDate   *MonthYear             `protobuf:"bytes,5,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	// end_date не задан у бессрочной подписки
+	EndDate       *MonthYear             `protobuf:"bytes,6,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Notes         string                 `protobuf:"bytes,7,opt,name=notes,proto3" json:"notes,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Subscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subscription) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *Subscription) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Subscription) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Subscription) GetStartDate() *MonthYear {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *Subscription) GetEndDate() *MonthYear {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *Subscription) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceName   string                 `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price         int64                  `protobuf:"varint,2,opt,name=price,proto3" json:"price,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	StartDate     *MonthYear             `protobuf:"bytes,4,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *MonthYear             `protobuf:"bytes,5,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Notes         string                 `protobuf:"bytes,6,opt,name=notes,proto3" json:"notes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSubscriptionRequest) Reset() {
+	*x = CreateSubscriptionRequest{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSubscriptionRequest) ProtoMessage() {}
+
+func (x *CreateSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateSubscriptionRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetStartDate() *MonthYear {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetEndDate() *MonthYear {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+type GetSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSubscriptionRequest) Reset() {
+	*x = GetSubscriptionRequest{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubscriptionRequest) ProtoMessage() {}
+
+func (x *GetSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*GetSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type UpdateSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ServiceName   string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price         int64                  `protobuf:"varint,3,opt,name=price,proto3" json:"price,omitempty"`
+	EndDate       *MonthYear             `protobuf:"bytes,4,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Notes         string                 `protobuf:"bytes,5,opt,name=notes,proto3" json:"notes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateSubscriptionRequest) Reset() {
+	*x = UpdateSubscriptionRequest{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateSubscriptionRequest) ProtoMessage() {}
+
+func (x *UpdateSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*UpdateSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateSubscriptionRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *UpdateSubscriptionRequest) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *UpdateSubscriptionRequest) GetEndDate() *MonthYear {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *UpdateSubscriptionRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+type DeleteSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSubscriptionRequest) Reset() {
+	*x = DeleteSubscriptionRequest{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSubscriptionRequest) ProtoMessage() {}
+
+func (x *DeleteSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSubscriptionResponse) Reset() {
+	*x = DeleteSubscriptionResponse{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSubscriptionResponse) ProtoMessage() {}
+
+func (x *DeleteSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{6}
+}
+
+// SubscriptionFilter пустые поля не участвуют в фильтрации, непустые объединяются через AND
+type SubscriptionFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceNames  []string               `protobuf:"bytes,1,rep,name=service_names,json=serviceNames,proto3" json:"service_names,omitempty"`
+	UserId        *string                `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	PriceMin      *int64                 `protobuf:"varint,3,opt,name=price_min,json=priceMin,proto3,oneof" json:"price_min,omitempty"`
+	PriceMax      *int64                 `protobuf:"varint,4,opt,name=price_max,json=priceMax,proto3,oneof" json:"price_max,omitempty"`
+	StartFrom     *MonthYear             `protobuf:"bytes,5,opt,name=start_from,json=startFrom,proto3" json:"start_from,omitempty"`
+	StartTo       *MonthYear             `protobuf:"bytes,6,opt,name=start_to,json=startTo,proto3" json:"start_to,omitempty"`
+	EndFrom       *MonthYear             `protobuf:"bytes,7,opt,name=end_from,json=endFrom,proto3" json:"end_from,omitempty"`
+	EndTo         *MonthYear             `protobuf:"bytes,8,opt,name=end_to,json=endTo,proto3" json:"end_to,omitempty"`
+	ActiveAt      *MonthYear             `protobuf:"bytes,9,opt,name=active_at,json=activeAt,proto3" json:"active_at,omitempty"`
+	OpenEnded     *bool                  `protobuf:"varint,10,opt,name=open_ended,json=openEnded,proto3,oneof" json:"open_ended,omitempty"`
+	CreatedSince  *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_since,json=createdSince,proto3" json:"created_since,omitempty"`
+	UpdatedSince  *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=updated_since,json=updatedSince,proto3" json:"updated_since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscriptionFilter) Reset() {
+	*x = SubscriptionFilter{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionFilter) ProtoMessage() {}
+
+func (x *SubscriptionFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionFilter.ProtoReflect.Descriptor instead.
+func (*SubscriptionFilter) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubscriptionFilter) GetServiceNames() []string {
+	if x != nil {
+		return x.ServiceNames
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetPriceMin() int64 {
+	if x != nil && x.PriceMin != nil {
+		return *x.PriceMin
+	}
+	return 0
+}
+
+func (x *SubscriptionFilter) GetPriceMax() int64 {
+	if x != nil && x.PriceMax != nil {
+		return *x.PriceMax
+	}
+	return 0
+}
+
+func (x *SubscriptionFilter) GetStartFrom() *MonthYear {
+	if x != nil {
+		return x.StartFrom
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetStartTo() *MonthYear {
+	if x != nil {
+		return x.StartTo
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetEndFrom() *MonthYear {
+	if x != nil {
+		return x.EndFrom
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetEndTo() *MonthYear {
+	if x != nil {
+		return x.EndTo
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetActiveAt() *MonthYear {
+	if x != nil {
+		return x.ActiveAt
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetOpenEnded() bool {
+	if x != nil && x.OpenEnded != nil {
+		return *x.OpenEnded
+	}
+	return false
+}
+
+func (x *SubscriptionFilter) GetCreatedSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedSince
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetUpdatedSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedSince
+	}
+	return nil
+}
+
+type ListSubscriptionsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *SubscriptionFilter    `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// sort поля через запятую, "-" перед полем означает сортировку по убыванию: "price,-start_date"
+	Sort          string `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsRequest) Reset() {
+	*x = ListSubscriptionsRequest{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListSubscriptionsRequest) GetFilter() *SubscriptionFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListSubscriptionsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+type TotalCostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *string                `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	ServiceName   *string                `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3,oneof" json:"service_name,omitempty"`
+	StartDate     *MonthYear             `protobuf:"bytes,3,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *MonthYear             `protobuf:"bytes,4,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TotalCostRequest) Reset() {
+	*x = TotalCostRequest{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TotalCostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TotalCostRequest) ProtoMessage() {}
+
+func (x *TotalCostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TotalCostRequest.ProtoReflect.Descriptor instead.
+func (*TotalCostRequest) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TotalCostRequest) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *TotalCostRequest) GetServiceName() string {
+	if x != nil && x.ServiceName != nil {
+		return *x.ServiceName
+	}
+	return ""
+}
+
+func (x *TotalCostRequest) GetStartDate() *MonthYear {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *TotalCostRequest) GetEndDate() *MonthYear {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+type TotalCostResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TotalCost     int64                  `protobuf:"varint,1,opt,name=total_cost,json=totalCost,proto3" json:"total_cost,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TotalCostResponse) Reset() {
+	*x = TotalCostResponse{}
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TotalCostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TotalCostResponse) ProtoMessage() {}
+
+func (x *TotalCostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_subscriptions_v1_subscriptions_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TotalCostResponse.ProtoReflect.Descriptor instead.
+func (*TotalCostResponse) Descriptor() ([]byte, []int) {
+	return file_subscriptions_v1_subscriptions_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TotalCostResponse) GetTotalCost() int64 {
+	if x != nil {
+		return x.TotalCost
+	}
+	return 0
+}
+
+var File_subscriptions_v1_subscriptions_proto protoreflect.FileDescriptor
+
+const file_subscriptions_v1_subscriptions_proto_rawDesc = "" +
+	"\n" +
+	"$subscriptions/v1/subscriptions.proto\x12\x10subscriptions.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"5\n" +
+	"\tMonthYear\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\"\xf0\x02\n" +
+	"\fSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12:\n" +
+	"\n" +
+	"start_date\x18\x05 \x01(\v2\x1b.subscriptions.v1.MonthYearR\tstartDate\x126\n" +
+	"\bend_date\x18\x06 \x01(\v2\x1b.subscriptions.v1.MonthYearR\aendDate\x12\x14\n" +
+	"\x05notes\x18\a \x01(\tR\x05notes\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xf7\x01\n" +
+	"\x19CreateSubscriptionRequest\x12!\n" +
+	"\fservice_name\x18\x01 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12:\n" +
+	"\n" +
+	"start_date\x18\x04 \x01(\v2\x1b.subscriptions.v1.MonthYearR\tstartDate\x126\n" +
+	"\bend_date\x18\x05 \x01(\v2\x1b.subscriptions.v1.MonthYearR\aendDate\x12\x14\n" +
+	"\x05notes\x18\x06 \x01(\tR\x05notes\"(\n" +
+	"\x16GetSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xb2\x01\n" +
+	"\x19UpdateSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x03R\x05price\x126\n" +
+	"\bend_date\x18\x04 \x01(\v2\x1b.subscriptions.v1.MonthYearR\aendDate\x12\x14\n" +
+	"\x05notes\x18\x05 \x01(\tR\x05notes\"+\n" +
+	"\x19DeleteSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1c\n" +
+	"\x1aDeleteSubscriptionResponse\"\x92\x05\n" +
+	"\x12SubscriptionFilter\x12#\n" +
+	"\rservice_names\x18\x01 \x03(\tR\fserviceNames\x12\x1c\n" +
+	"\auser_id\x18\x02 \x01(\tH\x00R\x06userId\x88\x01\x01\x12 \n" +
+	"\tprice_min\x18\x03 \x01(\x03H\x01R\bpriceMin\x88\x01\x01\x12 \n" +
+	"\tprice_max\x18\x04 \x01(\x03H\x02R\bpriceMax\x88\x01\x01\x12:\n" +
+	"\n" +
+	"start_from\x18\x05 \x01(\v2\x1b.subscriptions.v1.MonthYearR\tstartFrom\x126\n" +
+	"\bstart_to\x18\x06 \x01(\v2\x1b.subscriptions.v1.MonthYearR\astartTo\x126\n" +
+	"\bend_from\x18\a \x01(\v2\x1b.subscriptions.v1.MonthYearR\aendFrom\x122\n" +
+	"\x06end_to\x18\b \x01(\v2\x1b.subscriptions.v1.MonthYearR\x05endTo\x128\n" +
+	"\tactive_at\x18\t \x01(\v2\x1b.subscriptions.v1.MonthYearR\bactiveAt\x12\"\n" +
+	"\n" +
+	"open_ended\x18\n" +
+	" \x01(\bH\x03R\topenEnded\x88\x01\x01\x12?\n" +
+	"\rcreated_since\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\fcreatedSince\x12?\n" +
+	"\rupdated_since\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\fupdatedSinceB\n" +
+	"\n" +
+	"\b_user_idB\f\n" +
+	"\n" +
+	"_price_minB\f\n" +
+	"\n" +
+	"_price_maxB\r\n" +
+	"\v_open_ended\"l\n" +
+	"\x18ListSubscriptionsRequest\x12<\n" +
+	"\x06filter\x18\x01 \x01(\v2$.subscriptions.v1.SubscriptionFilterR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort\"\xe9\x01\n" +
+	"\x10TotalCostRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x88\x01\x01\x12&\n" +
+	"\fservice_name\x18\x02 \x01(\tH\x01R\vserviceName\x88\x01\x01\x12:\n" +
+	"\n" +
+	"start_date\x18\x03 \x01(\v2\x1b.subscriptions.v1.MonthYearR\tstartDate\x126\n" +
+	"\bend_date\x18\x04 \x01(\v2\x1b.subscriptions.v1.MonthYearR\aendDateB\n" +
+	"\n" +
+	"\b_user_idB\x0f\n" +
+	"\r_service_name\"2\n" +
+	"\x11TotalCostResponse\x12\x1d\n" +
+	"\n" +
+	"total_cost\x18\x01 \x01(\x03R\ttotalCost2\xe2\x04\n" +
+	"\x13SubscriptionService\x12a\n" +
+	"\x12CreateSubscription\x12+.subscriptions.v1.CreateSubscriptionRequest\x1a\x1e.subscriptions.v1.Subscription\x12[\n" +
+	"\x0fGetSubscription\x12(.subscriptions.v1.GetSubscriptionRequest\x1a\x1e.subscriptions.v1.Subscription\x12a\n" +
+	"\x12UpdateSubscription\x12+.subscriptions.v1.UpdateSubscriptionRequest\x1a\x1e.subscriptions.v1.Subscription\x12o\n" +
+	"\x12DeleteSubscription\x12+.subscriptions.v1.DeleteSubscriptionRequest\x1a,.subscriptions.v1.DeleteSubscriptionResponse\x12a\n" +
+	"\x11ListSubscriptions\x12*.subscriptions.v1.ListSubscriptionsRequest\x1a\x1e.subscriptions.v1.Subscription0\x01\x12T\n" +
+	"\tTotalCost\x12\".subscriptions.v1.TotalCostRequest\x1a#.subscriptions.v1.TotalCostResponseBaZ_github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1;subscriptionsv1b\x06proto3"
+
+var (
+	file_subscriptions_v1_subscriptions_proto_rawDescOnce sync.Once
+	file_subscriptions_v1_subscriptions_proto_rawDescData []byte
+)
+
+func file_subscriptions_v1_subscriptions_proto_rawDescGZIP() []byte {
+	file_subscriptions_v1_subscriptions_proto_rawDescOnce.Do(func() {
+		file_subscriptions_v1_subscriptions_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_subscriptions_v1_subscriptions_proto_rawDesc), len(file_subscriptions_v1_subscriptions_proto_rawDesc)))
+	})
+	return file_subscriptions_v1_subscriptions_proto_rawDescData
+}
+
+var file_subscriptions_v1_subscriptions_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_subscriptions_v1_subscriptions_proto_goTypes = []any{
+	(*MonthYear)(nil),                  // 0: subscriptions.v1.MonthYear
+	(*Subscription)(nil),               // 1: subscriptions.v1.Subscription
+	(*CreateSubscriptionRequest)(nil),  // 2: subscriptions.v1.CreateSubscriptionRequest
+	(*GetSubscriptionRequest)(nil),     // 3: subscriptions.v1.GetSubscriptionRequest
+	(*UpdateSubscriptionRequest)(nil),  // 4: subscriptions.v1.UpdateSubscriptionRequest
+	(*DeleteSubscriptionRequest)(nil),  // 5: subscriptions.v1.DeleteSubscriptionRequest
+	(*DeleteSubscriptionResponse)(nil), // 6: subscriptions.v1.DeleteSubscriptionResponse
+	(*SubscriptionFilter)(nil),         // 7: subscriptions.v1.SubscriptionFilter
+	(*ListSubscriptionsRequest)(nil),   // 8: subscriptions.v1.ListSubscriptionsRequest
+	(*TotalCostRequest)(nil),           // 9: subscriptions.v1.TotalCostRequest
+	(*TotalCostResponse)(nil),          // 10: subscriptions.v1.TotalCostResponse
+	(*timestamppb.Timestamp)(nil),      // 11: google.protobuf.Timestamp
+}
+var file_subscriptions_v1_subscriptions_proto_depIdxs = []int32{
+	0,  // 0: subscriptions.v1.Subscription.start_date:type_name -> subscriptions.v1.MonthYear
+	0,  // 1: subscriptions.v1.Subscription.end_date:type_name -> subscriptions.v1.MonthYear
+	11, // 2: subscriptions.v1.Subscription.created_at:type_name -> google.protobuf.Timestamp
+	11, // 3: subscriptions.v1.Subscription.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 4: subscriptions.v1.CreateSubscriptionRequest.start_date:type_name -> subscriptions.v1.MonthYear
+	0,  // 5: subscriptions.v1.CreateSubscriptionRequest.end_date:type_name -> subscriptions.v1.MonthYear
+	0,  // 6: subscriptions.v1.UpdateSubscriptionRequest.end_date:type_name -> subscriptions.v1.MonthYear
+	0,  // 7: subscriptions.v1.SubscriptionFilter.start_from:type_name -> subscriptions.v1.MonthYear
+	0,  // 8: subscriptions.v1.SubscriptionFilter.start_to:type_name -> subscriptions.v1.MonthYear
+	0,  // 9: subscriptions.v1.SubscriptionFilter.end_from:type_name -> subscriptions.v1.MonthYear
+	0,  // 10: subscriptions.v1.SubscriptionFilter.end_to:type_name -> subscriptions.v1.MonthYear
+	0,  // 11: subscriptions.v1.SubscriptionFilter.active_at:type_name -> subscriptions.v1.MonthYear
+	11, // 12: subscriptions.v1.SubscriptionFilter.created_since:type_name -> google.protobuf.Timestamp
+	11, // 13: subscriptions.v1.SubscriptionFilter.updated_since:type_name -> google.protobuf.Timestamp
+	7,  // 14: subscriptions.v1.ListSubscriptionsRequest.filter:type_name -> subscriptions.v1.SubscriptionFilter
+	0,  // 15: subscriptions.v1.TotalCostRequest.start_date:type_name -> subscriptions.v1.MonthYear
+	0,  // 16: subscriptions.v1.TotalCostRequest.end_date:type_name -> subscriptions.v1.MonthYear
+	2,  // 17: subscriptions.v1.SubscriptionService.CreateSubscription:input_type -> subscriptions.v1.CreateSubscriptionRequest
+	3,  // 18: subscriptions.v1.SubscriptionService.GetSubscription:input_type -> subscriptions.v1.GetSubscriptionRequest
+	4,  // 19: subscriptions.v1.SubscriptionService.UpdateSubscription:input_type -> subscriptions.v1.UpdateSubscriptionRequest
+	5,  // 20: subscriptions.v1.SubscriptionService.DeleteSubscription:input_type -> subscriptions.v1.DeleteSubscriptionRequest
+	8,  // 21: subscriptions.v1.SubscriptionService.ListSubscriptions:input_type -> subscriptions.v1.ListSubscriptionsRequest
+	9,  // 22: subscriptions.v1.SubscriptionService.TotalCost:input_type -> subscriptions.v1.TotalCostRequest
+	1,  // 23: subscriptions.v1.SubscriptionService.CreateSubscription:output_type -> subscriptions.v1.Subscription
+	1,  // 24: subscriptions.v1.SubscriptionService.GetSubscription:output_type -> subscriptions.v1.Subscription
+	1,  // 25: subscriptions.v1.SubscriptionService.UpdateSubscription:output_type -> subscriptions.v1.Subscription
+	6,  // 26: subscriptions.v1.SubscriptionService.DeleteSubscription:output_type -> subscriptions.v1.DeleteSubscriptionResponse
+	1,  // 27: subscriptions.v1.SubscriptionService.ListSubscriptions:output_type -> subscriptions.v1.Subscription
+	10, // 28: subscriptions.v1.SubscriptionService.TotalCost:output_type -> subscriptions.v1.TotalCostResponse
+	23, // [23:29] is the sub-list for method output_type
+	17, // [17:23] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_subscriptions_v1_subscriptions_proto_init() }
+func file_subscriptions_v1_subscriptions_proto_init() {
+	if File_subscriptions_v1_subscriptions_proto != nil {
+		return
+	}
+	file_subscriptions_v1_subscriptions_proto_msgTypes[7].OneofWrappers = []any{}
+	file_subscriptions_v1_subscriptions_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_subscriptions_v1_subscriptions_proto_rawDesc), len(file_subscriptions_v1_subscriptions_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_subscriptions_v1_subscriptions_proto_goTypes,
+		DependencyIndexes: file_subscriptions_v1_subscriptions_proto_depIdxs,
+		MessageInfos:      file_subscriptions_v1_subscriptions_proto_msgTypes,
+	}.Build()
+	File_subscriptions_v1_subscriptions_proto = out.File
+	file_subscriptions_v1_subscriptions_proto_goTypes = nil
+	file_subscriptions_v1_subscriptions_proto_depIdxs = nil
+}
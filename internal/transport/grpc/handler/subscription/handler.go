@@ -0,0 +1,204 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Handler реализация subscriptions.v1.SubscriptionService поверх сервиса подписок
+type Handler struct {
+	subscriptionsv1.UnimplementedSubscriptionServiceServer
+
+	logger              *slog.Logger
+	subscriptionService *service.Subscription
+}
+
+func NewHandler(baseLogger *slog.Logger, subscriptionService *service.Subscription) *Handler {
+	logger := baseLogger.WithGroup("subscription grpc handler")
+
+	return &Handler{
+		logger:              logger,
+		subscriptionService: subscriptionService,
+	}
+}
+
+func (h *Handler) CreateSubscription(ctx context.Context, request *subscriptionsv1.CreateSubscriptionRequest) (*subscriptionsv1.Subscription, error) {
+	logger := h.requestLogger(ctx, "CreateSubscription")
+
+	if err := ValidateCreateSubscriptionRequest(request); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	subscription, err := h.subscriptionService.CreateSubscription(ctx, ToCreateSubscriptionParams(request))
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Creating subscription for another user", slog.String("error", err.Error()))
+		return nil, status.Error(codes.PermissionDenied, "cannot create subscriptions for another user")
+	}
+	if err != nil {
+		logger.Warn("Failed to create subscription", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to create the subscription")
+	}
+
+	logger.Info("Create subscription successfully")
+	return ToSubscription(subscription), nil
+}
+
+func (h *Handler) GetSubscription(ctx context.Context, request *subscriptionsv1.GetSubscriptionRequest) (*subscriptionsv1.Subscription, error) {
+	logger := h.requestLogger(ctx, "GetSubscription")
+
+	id, err := ParseID(request.GetId())
+	if err != nil {
+		logger.Warn("Failed to parse the id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	subscription, err := h.subscriptionService.GetSubscription(ctx, id, nil)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", id.String()))
+		return nil, status.Error(codes.NotFound, "subscription not found")
+	}
+	if err != nil {
+		logger.Error("Failed to get subscription", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to get the subscription")
+	}
+
+	return ToSubscription(subscription), nil
+}
+
+func (h *Handler) UpdateSubscription(ctx context.Context, request *subscriptionsv1.UpdateSubscriptionRequest) (*subscriptionsv1.Subscription, error) {
+	logger := h.requestLogger(ctx, "UpdateSubscription")
+
+	if err := ValidateUpdateSubscriptionRequest(request); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id, _ := ParseID(request.GetId())
+	subscription, err := h.subscriptionService.UpdateSubscription(ctx, id, ToUpdateSubscriptionParams(request))
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", id.String()))
+		return nil, status.Error(codes.NotFound, "subscription not found")
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Updating subscription is forbidden", slog.String("uuid", id.String()))
+		return nil, status.Error(codes.PermissionDenied, "not allowed to update the subscription")
+	}
+	if err != nil {
+		logger.Error("Failed to update subscription", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to update the subscription")
+	}
+
+	logger.Info("Update subscription successfully")
+	return ToSubscription(subscription), nil
+}
+
+func (h *Handler) DeleteSubscription(ctx context.Context, request *subscriptionsv1.DeleteSubscriptionRequest) (*subscriptionsv1.DeleteSubscriptionResponse, error) {
+	logger := h.requestLogger(ctx, "DeleteSubscription")
+
+	id, err := ParseID(request.GetId())
+	if err != nil {
+		logger.Warn("Failed to parse the id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = h.subscriptionService.DeleteSubscription(ctx, id)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", id.String()))
+		return nil, status.Error(codes.NotFound, "subscription not found")
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Deleting subscription is forbidden", slog.String("uuid", id.String()))
+		return nil, status.Error(codes.PermissionDenied, "not allowed to delete the subscription")
+	}
+	if err != nil {
+		logger.Error("Failed to delete subscription", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to delete the subscription")
+	}
+
+	logger.Info("Delete subscription successfully")
+	return &subscriptionsv1.DeleteSubscriptionResponse{}, nil
+}
+
+// ListSubscriptions отправляет подписки по мере чтения из базы, как экспорт в HTTP API
+func (h *Handler) ListSubscriptions(request *subscriptionsv1.ListSubscriptionsRequest, stream grpc.ServerStreamingServer[subscriptionsv1.Subscription]) error {
+	ctx := stream.Context()
+	logger := h.requestLogger(ctx, "ListSubscriptions")
+
+	if err := ValidateSubscriptionFilter(request.GetFilter()); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	params, err := ToExportSubscriptionsParams(request)
+	if err != nil {
+		logger.Warn("Failed to convert the request to list params", slog.String("error", err.Error()))
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sent := 0
+	err = h.subscriptionService.ExportSubscriptions(ctx, params, func(subscription *domain.Subscription) error {
+		if err := stream.Send(ToSubscription(subscription)); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
+	if errors.Is(err, domain.ErrInvalidSort) {
+		logger.Warn("Invalid list parameters", slog.String("error", err.Error()))
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Listing another user's subscriptions", slog.String("error", err.Error()))
+		return status.Error(codes.PermissionDenied, "cannot list subscriptions of another user")
+	}
+	if ctx.Err() != nil {
+		logger.Info("Client cancelled the list stream", slog.Int("sent", sent))
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if err != nil {
+		logger.Error("Failed to list subscriptions", slog.String("error", err.Error()), slog.Int("sent", sent))
+		return status.Error(codes.Internal, "failed to list the subscriptions")
+	}
+
+	logger.Info("List subscriptions successfully", slog.Int("sent", sent))
+	return nil
+}
+
+func (h *Handler) TotalCost(ctx context.Context, request *subscriptionsv1.TotalCostRequest) (*subscriptionsv1.TotalCostResponse, error) {
+	logger := h.requestLogger(ctx, "TotalCost")
+
+	if err := ValidateTotalCostRequest(request); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sum, err := h.subscriptionService.TotalCostSubscriptions(ctx, ToTotalCostSubscriptionsParams(request))
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Total cost of another user's subscriptions", slog.String("error", err.Error()))
+		return nil, status.Error(codes.PermissionDenied, "cannot get the total cost of another user")
+	}
+	if err != nil {
+		logger.Error("Failed to get total cost", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to get the total cost subscriptions")
+	}
+
+	return &subscriptionsv1.TotalCostResponse{TotalCost: int64(sum)}, nil
+}
+
+func (h *Handler) requestLogger(ctx context.Context, method string) *slog.Logger {
+	return h.logger.With(
+		slog.String("request_id", ctx.Value(middleware.RequestIDKey).(string)),
+		slog.String("func", method),
+	)
+}
@@ -0,0 +1,68 @@
+package subscription
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestHandlerInvalidArgument некорректный запрос отклоняется до обращения к сервису
+func TestHandlerInvalidArgument(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	handler := NewHandler(slog.New(slog.DiscardHandler), nil)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "create",
+			call: func() error {
+				_, err := handler.CreateSubscription(ctx, &subscriptionsv1.CreateSubscriptionRequest{})
+				return err
+			},
+		},
+		{
+			name: "get",
+			call: func() error {
+				_, err := handler.GetSubscription(ctx, &subscriptionsv1.GetSubscriptionRequest{Id: "1"})
+				return err
+			},
+		},
+		{
+			name: "update",
+			call: func() error {
+				_, err := handler.UpdateSubscription(ctx, &subscriptionsv1.UpdateSubscriptionRequest{Id: "1", ServiceName: "Netflix"})
+				return err
+			},
+		},
+		{
+			name: "delete",
+			call: func() error {
+				_, err := handler.DeleteSubscription(ctx, &subscriptionsv1.DeleteSubscriptionRequest{})
+				return err
+			},
+		},
+		{
+			name: "total cost",
+			call: func() error {
+				_, err := handler.TotalCost(ctx, &subscriptionsv1.TotalCostRequest{StartDate: &subscriptionsv1.MonthYear{Year: 2025, Month: 13}})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("error = %v, want codes.InvalidArgument", err)
+			}
+		})
+	}
+}
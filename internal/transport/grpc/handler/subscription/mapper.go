@@ -0,0 +1,153 @@
+package subscription
+
+import (
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func ToCreateSubscriptionParams(request *subscriptionsv1.CreateSubscriptionRequest) *domain.CreateSubscriptionParams {
+	userID, _ := uuid.Parse(request.GetUserId())
+
+	return &domain.CreateSubscriptionParams{
+		ServiceName: request.GetServiceName(),
+		Price:       int(request.GetPrice()),
+		UserUUID:    userID,
+		StartDate:   *fromMonthYear(request.GetStartDate()),
+		EndDate:     fromMonthYear(request.GetEndDate()),
+		Notes:       request.GetNotes(),
+	}
+}
+
+func ToUpdateSubscriptionParams(request *subscriptionsv1.UpdateSubscriptionRequest) *domain.UpdateSubscriptionParams {
+	return &domain.UpdateSubscriptionParams{
+		ServiceName: request.GetServiceName(),
+		Price:       int(request.GetPrice()),
+		EndDate:     fromMonthYear(request.GetEndDate()),
+		Notes:       request.GetNotes(),
+	}
+}
+
+func ToSubscriptionFilter(request *subscriptionsv1.SubscriptionFilter) (domain.SubscriptionFilter, error) {
+	if request == nil {
+		return domain.SubscriptionFilter{ServiceNameMatch: domain.ServiceNameIgnoreCase}, nil
+	}
+
+	filter := domain.SubscriptionFilter{
+		ServiceNames:     request.GetServiceNames(),
+		ServiceNameMatch: domain.ServiceNameIgnoreCase,
+		PriceMin:         toIntPtr(request.PriceMin),
+		PriceMax:         toIntPtr(request.PriceMax),
+		StartFrom:        fromMonthYear(request.GetStartFrom()),
+		StartTo:          fromMonthYear(request.GetStartTo()),
+		EndFrom:          fromMonthYear(request.GetEndFrom()),
+		EndTo:            fromMonthYear(request.GetEndTo()),
+		ActiveAt:         fromMonthYear(request.GetActiveAt()),
+		OpenEnded:        request.OpenEnded,
+		CreatedSince:     fromTimestamp(request.GetCreatedSince()),
+		UpdatedSince:     fromTimestamp(request.GetUpdatedSince()),
+	}
+
+	if request.UserId != nil {
+		userID, err := uuid.Parse(request.GetUserId())
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = &userID
+	}
+
+	return filter, nil
+}
+
+func ToExportSubscriptionsParams(request *subscriptionsv1.ListSubscriptionsRequest) (*domain.ExportSubscriptionsParams, error) {
+	filter, err := ToSubscriptionFilter(request.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := subscription.ParseSort(request.GetSort())
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ExportSubscriptionsParams{
+		Filter: filter,
+		Sort:   sort,
+	}, nil
+}
+
+func ToTotalCostSubscriptionsParams(request *subscriptionsv1.TotalCostRequest) *domain.TotalCostSubscriptionsParams {
+	var filter domain.SubscriptionFilter
+	if request.ServiceName != nil {
+		filter.ServiceNames = []string{request.GetServiceName()}
+	}
+	if request.UserId != nil {
+		userID, _ := uuid.Parse(request.GetUserId())
+		filter.UserID = &userID
+	}
+
+	params := &domain.TotalCostSubscriptionsParams{Filter: filter}
+	if startDate := fromMonthYear(request.GetStartDate()); startDate != nil {
+		params.StartDate = *startDate
+	}
+	if endDate := fromMonthYear(request.GetEndDate()); endDate != nil {
+		params.EndDate = *endDate
+	}
+
+	return params
+}
+
+func ToSubscription(subscription *domain.Subscription) *subscriptionsv1.Subscription {
+	return &subscriptionsv1.Subscription{
+		Id:          subscription.UUID.String(),
+		ServiceName: subscription.ServiceName,
+		Price:       int64(subscription.Price),
+		UserId:      subscription.UserUUID.String(),
+		StartDate:   toMonthYear(&subscription.StartDate),
+		EndDate:     toMonthYear(subscription.EndDate),
+		Notes:       subscription.Notes,
+		CreatedAt:   timestamppb.New(subscription.CreatedAt),
+		UpdatedAt:   timestamppb.New(subscription.UpdatedAt),
+	}
+}
+
+// fromMonthYear первое число месяца в UTC, как у MM-YYYY в HTTP API
+func fromMonthYear(monthYear *subscriptionsv1.MonthYear) *time.Time {
+	if monthYear == nil {
+		return nil
+	}
+
+	t := time.Date(int(monthYear.GetYear()), time.Month(monthYear.GetMonth()), 1, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func toMonthYear(t *time.Time) *subscriptionsv1.MonthYear {
+	if t == nil {
+		return nil
+	}
+
+	return &subscriptionsv1.MonthYear{Year: int32(t.Year()), Month: int32(t.Month())}
+}
+
+func fromTimestamp(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+
+	t := timestamp.AsTime()
+	return &t
+}
+
+func toIntPtr(value *int64) *int {
+	if value == nil {
+		return nil
+	}
+
+	v := int(*value)
+	return &v
+}
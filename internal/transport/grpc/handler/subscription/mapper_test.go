@@ -0,0 +1,113 @@
+package subscription
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+
+	"github.com/google/uuid"
+)
+
+func TestToCreateSubscriptionParams(t *testing.T) {
+	request := &subscriptionsv1.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       999,
+		UserId:      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		StartDate:   &subscriptionsv1.MonthYear{Year: 2025, Month: 7},
+		EndDate:     &subscriptionsv1.MonthYear{Year: 2025, Month: 12},
+		Notes:       "семейный тариф",
+	}
+
+	endDate := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+	want := &domain.CreateSubscriptionParams{
+		ServiceName: "Netflix",
+		Price:       999,
+		UserUUID:    uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &endDate,
+		Notes:       "семейный тариф",
+	}
+
+	if got := ToCreateSubscriptionParams(request); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToCreateSubscriptionParams() = %+v, want %+v", got, want)
+	}
+}
+
+func TestToSubscriptionRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, time.June, 15, 10, 30, 0, 0, time.UTC)
+	subscription := &domain.Subscription{
+		UUID:        uuid.MustParse("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"),
+		ServiceName: "Netflix",
+		Price:       999,
+		UserUUID:    uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+
+	got := ToSubscription(subscription)
+	if got.GetId() != subscription.UUID.String() || got.GetPrice() != 999 || got.GetUserId() != subscription.UserUUID.String() {
+		t.Errorf("ToSubscription() = %v", got)
+	}
+	if got.GetStartDate().GetYear() != 2025 || got.GetStartDate().GetMonth() != 7 {
+		t.Errorf("start_date = %v, want 2025-07", got.GetStartDate())
+	}
+	if got.EndDate != nil {
+		t.Errorf("end_date = %v, want nil for an open-ended subscription", got.GetEndDate())
+	}
+	if !got.GetCreatedAt().AsTime().Equal(createdAt) {
+		t.Errorf("created_at = %v, want %v", got.GetCreatedAt().AsTime(), createdAt)
+	}
+}
+
+func TestToSubscriptionFilter(t *testing.T) {
+	userID := "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	openEnded := true
+
+	filter, err := ToSubscriptionFilter(&subscriptionsv1.SubscriptionFilter{
+		UserId:       &userID,
+		ServiceNames: []string{"Netflix"},
+		PriceMin:     int64Ptr(100),
+		ActiveAt:     &subscriptionsv1.MonthYear{Year: 2025, Month: 3},
+		OpenEnded:    &openEnded,
+	})
+	if err != nil {
+		t.Fatalf("ToSubscriptionFilter() error = %v", err)
+	}
+	if filter.UserID == nil || filter.UserID.String() != userID {
+		t.Errorf("UserID = %v, want %s", filter.UserID, userID)
+	}
+	if filter.PriceMin == nil || *filter.PriceMin != 100 || filter.PriceMax != nil {
+		t.Errorf("PriceMin = %v, PriceMax = %v; want 100, nil", filter.PriceMin, filter.PriceMax)
+	}
+	if filter.ServiceNameMatch != domain.ServiceNameIgnoreCase {
+		t.Errorf("ServiceNameMatch = %v, want ServiceNameIgnoreCase for list filters", filter.ServiceNameMatch)
+	}
+	if filter.ActiveAt == nil || !filter.ActiveAt.Equal(time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ActiveAt = %v, want 2025-03-01", filter.ActiveAt)
+	}
+
+	want := domain.SubscriptionFilter{ServiceNameMatch: domain.ServiceNameIgnoreCase}
+	if filter, err := ToSubscriptionFilter(nil); err != nil || !reflect.DeepEqual(filter, want) {
+		t.Errorf("ToSubscriptionFilter(nil) = %+v, %v; want an empty filter", filter, err)
+	}
+}
+
+func TestToExportSubscriptionsParams(t *testing.T) {
+	params, err := ToExportSubscriptionsParams(&subscriptionsv1.ListSubscriptionsRequest{Sort: "-price,service_name"})
+	if err != nil {
+		t.Fatalf("ToExportSubscriptionsParams() error = %v", err)
+	}
+	want := []domain.SortField{{Field: "price", Desc: true}, {Field: "service_name"}}
+	if !reflect.DeepEqual(params.Sort, want) {
+		t.Errorf("Sort = %+v, want %+v", params.Sort, want)
+	}
+
+	if _, err := ToExportSubscriptionsParams(&subscriptionsv1.ListSubscriptionsRequest{Sort: "price,"}); !errors.Is(err, domain.ErrInvalidSort) {
+		t.Errorf("ToExportSubscriptionsParams() error = %v, want ErrInvalidSort", err)
+	}
+}
@@ -0,0 +1,144 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+
+	"github.com/google/uuid"
+)
+
+// ValidationError собирает все ошибки запроса, чтобы вернуть их клиенту одним сообщением
+type ValidationError struct {
+	fields []string
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.fields = append(e.fields, fmt.Sprintf("%s: %s", field, message))
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.fields, ", ")
+}
+
+func (e *ValidationError) HasErrors() bool {
+	return len(e.fields) > 0
+}
+
+func (e *ValidationError) err() error {
+	if e.HasErrors() {
+		return e
+	}
+
+	return nil
+}
+
+func ValidateCreateSubscriptionRequest(request *subscriptionsv1.CreateSubscriptionRequest) error {
+	var vErr ValidationError
+
+	if request.GetServiceName() == "" {
+		vErr.Add("service_name", "required")
+	}
+	if request.GetPrice() < 0 {
+		vErr.Add("price", "must be greater than or equal to zero")
+	}
+	if _, err := uuid.Parse(request.GetUserId()); err != nil {
+		vErr.Add("user_id", "must be a valid UUID")
+	}
+	if request.GetStartDate() == nil {
+		vErr.Add("start_date", "required")
+	}
+	validateMonthYear(&vErr, "start_date", request.GetStartDate())
+	validateMonthYear(&vErr, "end_date", request.GetEndDate())
+
+	return vErr.err()
+}
+
+func ValidateUpdateSubscriptionRequest(request *subscriptionsv1.UpdateSubscriptionRequest) error {
+	var vErr ValidationError
+
+	if _, err := uuid.Parse(request.GetId()); err != nil {
+		vErr.Add("id", "must be a valid UUID")
+	}
+	if request.GetServiceName() == "" {
+		vErr.Add("service_name", "required")
+	}
+	if request.GetPrice() < 0 {
+		vErr.Add("price", "must be greater than or equal to zero")
+	}
+	validateMonthYear(&vErr, "end_date", request.GetEndDate())
+
+	return vErr.err()
+}
+
+func ValidateSubscriptionFilter(filter *subscriptionsv1.SubscriptionFilter) error {
+	if filter == nil {
+		return nil
+	}
+
+	var vErr ValidationError
+
+	if filter.UserId != nil {
+		if _, err := uuid.Parse(filter.GetUserId()); err != nil {
+			vErr.Add("filter.user_id", "must be a valid UUID")
+		}
+	}
+	if filter.PriceMin != nil && filter.PriceMax != nil && filter.GetPriceMin() > filter.GetPriceMax() {
+		vErr.Add("filter.price_min", "must be less than or equal to price_max")
+	}
+	validateMonthYear(&vErr, "filter.start_from", filter.GetStartFrom())
+	validateMonthYear(&vErr, "filter.start_to", filter.GetStartTo())
+	validateMonthYear(&vErr, "filter.end_from", filter.GetEndFrom())
+	validateMonthYear(&vErr, "filter.end_to", filter.GetEndTo())
+	validateMonthYear(&vErr, "filter.active_at", filter.GetActiveAt())
+	if isAfter(filter.GetStartFrom(), filter.GetStartTo()) {
+		vErr.Add("filter.start_from", "must not be after start_to")
+	}
+	if isAfter(filter.GetEndFrom(), filter.GetEndTo()) {
+		vErr.Add("filter.end_from", "must not be after end_to")
+	}
+
+	return vErr.err()
+}
+
+func ValidateTotalCostRequest(request *subscriptionsv1.TotalCostRequest) error {
+	var vErr ValidationError
+
+	if request.UserId != nil {
+		if _, err := uuid.Parse(request.GetUserId()); err != nil {
+			vErr.Add("user_id", "must be a valid UUID")
+		}
+	}
+	validateMonthYear(&vErr, "start_date", request.GetStartDate())
+	validateMonthYear(&vErr, "end_date", request.GetEndDate())
+
+	return vErr.err()
+}
+
+func ParseID(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errors.New("id: must be a valid UUID")
+	}
+
+	return parsed, nil
+}
+
+func validateMonthYear(vErr *ValidationError, field string, monthYear *subscriptionsv1.MonthYear) {
+	if monthYear == nil {
+		return
+	}
+	if monthYear.GetMonth() < 1 || monthYear.GetMonth() > 12 {
+		vErr.Add(field, "month must be between 1 and 12")
+	}
+	if monthYear.GetYear() < 1 || monthYear.GetYear() > 9999 {
+		vErr.Add(field, "year must be between 1 and 9999")
+	}
+}
+
+func isAfter(from, to *subscriptionsv1.MonthYear) bool {
+	fromTime, toTime := fromMonthYear(from), fromMonthYear(to)
+	return fromTime != nil && toTime != nil && fromTime.After(*toTime)
+}
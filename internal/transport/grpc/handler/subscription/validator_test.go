@@ -0,0 +1,148 @@
+package subscription
+
+import (
+	"testing"
+
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+func TestValidateCreateSubscriptionRequest(t *testing.T) {
+	valid := func() *subscriptionsv1.CreateSubscriptionRequest {
+		return &subscriptionsv1.CreateSubscriptionRequest{
+			ServiceName: "Netflix",
+			Price:       999,
+			UserId:      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+			StartDate:   &subscriptionsv1.MonthYear{Year: 2025, Month: 7},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*subscriptionsv1.CreateSubscriptionRequest)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*subscriptionsv1.CreateSubscriptionRequest) {}},
+		{
+			name:    "missing service name",
+			modify:  func(r *subscriptionsv1.CreateSubscriptionRequest) { r.ServiceName = "" },
+			wantErr: "service_name: required",
+		},
+		{
+			name:    "negative price",
+			modify:  func(r *subscriptionsv1.CreateSubscriptionRequest) { r.Price = -1 },
+			wantErr: "price: must be greater than or equal to zero",
+		},
+		{
+			name:    "invalid user id",
+			modify:  func(r *subscriptionsv1.CreateSubscriptionRequest) { r.UserId = "user" },
+			wantErr: "user_id: must be a valid UUID",
+		},
+		{
+			name:    "missing start date",
+			modify:  func(r *subscriptionsv1.CreateSubscriptionRequest) { r.StartDate = nil },
+			wantErr: "start_date: required",
+		},
+		{
+			name: "invalid month and year",
+			modify: func(r *subscriptionsv1.CreateSubscriptionRequest) {
+				r.EndDate = &subscriptionsv1.MonthYear{Year: 0, Month: 13}
+			},
+			wantErr: "end_date: month must be between 1 and 12, end_date: year must be between 1 and 9999",
+		},
+		{
+			name: "all errors in one message",
+			modify: func(r *subscriptionsv1.CreateSubscriptionRequest) {
+				r.ServiceName = ""
+				r.Price = -1
+			},
+			wantErr: "service_name: required, price: must be greater than or equal to zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := valid()
+			tt.modify(request)
+
+			err := ValidateCreateSubscriptionRequest(request)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateCreateSubscriptionRequest() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateCreateSubscriptionRequest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSubscriptionFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  *subscriptionsv1.SubscriptionFilter
+		wantErr string
+	}{
+		{name: "empty", filter: &subscriptionsv1.SubscriptionFilter{}},
+		{name: "nil", filter: nil},
+		{
+			name:    "invalid user id",
+			filter:  &subscriptionsv1.SubscriptionFilter{UserId: stringPtr("user")},
+			wantErr: "filter.user_id: must be a valid UUID",
+		},
+		{
+			name:    "price range",
+			filter:  &subscriptionsv1.SubscriptionFilter{PriceMin: int64Ptr(500), PriceMax: int64Ptr(100)},
+			wantErr: "filter.price_min: must be less than or equal to price_max",
+		},
+		{
+			name: "start range",
+			filter: &subscriptionsv1.SubscriptionFilter{
+				StartFrom: &subscriptionsv1.MonthYear{Year: 2025, Month: 7},
+				StartTo:   &subscriptionsv1.MonthYear{Year: 2025, Month: 1},
+			},
+			wantErr: "filter.start_from: must not be after start_to",
+		},
+		{
+			name: "end range",
+			filter: &subscriptionsv1.SubscriptionFilter{
+				EndFrom: &subscriptionsv1.MonthYear{Year: 2026, Month: 1},
+				EndTo:   &subscriptionsv1.MonthYear{Year: 2025, Month: 12},
+			},
+			wantErr: "filter.end_from: must not be after end_to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSubscriptionFilter(tt.filter)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateSubscriptionFilter() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateSubscriptionFilter() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseID(t *testing.T) {
+	if _, err := ParseID("not-a-uuid"); err == nil || err.Error() != "id: must be a valid UUID" {
+		t.Errorf("ParseID() error = %v, want id: must be a valid UUID", err)
+	}
+	if id, err := ParseID("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"); err != nil || id.String() != "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" {
+		t.Errorf("ParseID() = %v, %v", id, err)
+	}
+}
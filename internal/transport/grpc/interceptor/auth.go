@@ -0,0 +1,67 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// reflectionPrefix методы reflection доступны без токена, чтобы grpcurl мог получить схему
+const reflectionPrefix = "/grpc.reflection."
+
+// AuthUnary требует метаданные authorization: Bearer <jwt>, как HTTP API, и кладет
+// вызывающего в контекст для сервисного слоя
+func AuthUnary(authenticator domain.Authenticator, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, info.FullMethod, authenticator, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func AuthStream(authenticator domain.Authenticator, logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), info.FullMethod, authenticator, logger)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &wrappedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, method string, authenticator domain.Authenticator, logger *slog.Logger) (context.Context, error) {
+	if strings.HasPrefix(method, reflectionPrefix) {
+		return ctx, nil
+	}
+
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, status.Error(codes.Unauthenticated, "authorization required")
+	}
+
+	principal, err := authenticator.Authenticate(ctx, strings.TrimSpace(token))
+	if err != nil {
+		logger.Info("Rejected access token", slog.String("method", method), slog.String("error", err.Error()))
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+
+	return domain.WithPrincipal(ctx, principal), nil
+}
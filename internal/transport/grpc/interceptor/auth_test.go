@@ -0,0 +1,118 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// staticAuthenticator принимает только токен token
+type staticAuthenticator struct {
+	token     string
+	principal *domain.Principal
+}
+
+func (a *staticAuthenticator) Authenticate(_ context.Context, token string) (*domain.Principal, error) {
+	if token != a.token {
+		return nil, fmt.Errorf("%w: invalid signature", domain.ErrUnauthenticated)
+	}
+
+	return a.principal, nil
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthUnary(t *testing.T) {
+	principal := &domain.Principal{Subject: uuid.New()}
+	authenticator := &staticAuthenticator{token: "valid", principal: principal}
+	interceptor := AuthUnary(authenticator, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization []string
+		wantCode      codes.Code
+		wantPrincipal bool
+	}{
+		{name: "valid token", method: unaryInfo.FullMethod, authorization: []string{"Bearer valid"}, wantCode: codes.OK, wantPrincipal: true},
+		{name: "scheme is case insensitive", method: unaryInfo.FullMethod, authorization: []string{"bearer valid"}, wantCode: codes.OK, wantPrincipal: true},
+		{name: "missing metadata", method: unaryInfo.FullMethod, wantCode: codes.Unauthenticated},
+		{name: "wrong scheme", method: unaryInfo.FullMethod, authorization: []string{"Basic valid"}, wantCode: codes.Unauthenticated},
+		{name: "empty token", method: unaryInfo.FullMethod, authorization: []string{"Bearer  "}, wantCode: codes.Unauthenticated},
+		{name: "invalid token", method: unaryInfo.FullMethod, authorization: []string{"Bearer forged"}, wantCode: codes.Unauthenticated},
+		{
+			name:     "reflection without token",
+			method:   "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization[0]))
+			}
+
+			called := false
+			var got *domain.Principal
+			handler := func(ctx context.Context, _ any) (any, error) {
+				called = true
+				got, _ = domain.PrincipalFromContext(ctx)
+				return nil, nil
+			}
+
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %s, want %s", code, tt.wantCode)
+			}
+			if called != (tt.wantCode == codes.OK) {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCode == codes.OK)
+			}
+			if tt.wantPrincipal && got != principal {
+				t.Errorf("principal in context = %v, want %v", got, principal)
+			}
+		})
+	}
+}
+
+func TestAuthStream(t *testing.T) {
+	principal := &domain.Principal{Subject: uuid.New(), Roles: []domain.Role{domain.RoleAdmin}}
+	interceptor := AuthStream(&staticAuthenticator{token: "valid", principal: principal}, slog.New(slog.DiscardHandler))
+	info := &grpc.StreamServerInfo{FullMethod: "/subscriptions.v1.SubscriptionService/ListSubscriptions"}
+
+	var got *domain.Principal
+	handler := func(_ any, stream grpc.ServerStream) error {
+		got, _ = domain.PrincipalFromContext(stream.Context())
+		return nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid"))
+	if err := interceptor(nil, &testServerStream{ctx: ctx}, info, handler); err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if got != principal {
+		t.Errorf("principal in stream context = %v, want %v", got, principal)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer forged"))
+	if err := interceptor(nil, &testServerStream{ctx: ctx}, info, handler); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("error = %v, want codes.Unauthenticated", err)
+	}
+}
@@ -0,0 +1,73 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var unaryInfo = &grpc.UnaryServerInfo{FullMethod: "/subscriptions.v1.SubscriptionService/GetSubscription"}
+
+func TestRequestIDUnary(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "from metadata",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1")),
+			want: "req-1",
+		},
+		{name: "generated", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := func(ctx context.Context, _ any) (any, error) {
+				got, _ = ctx.Value(middleware.RequestIDKey).(string)
+				return nil, nil
+			}
+
+			if _, err := RequestIDUnary()(tt.ctx, nil, unaryInfo, handler); err != nil {
+				t.Fatalf("interceptor error = %v", err)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("request id = %q, want %q", got, tt.want)
+			}
+			if got == "" {
+				t.Error("request id is empty")
+			}
+		})
+	}
+}
+
+func TestLoggingUnaryRecoversPanic(t *testing.T) {
+	handler := func(context.Context, any) (any, error) {
+		panic("boom")
+	}
+
+	_, err := LoggingUnary(slog.New(slog.DiscardHandler))(context.Background(), nil, unaryInfo, handler)
+	if status.Code(err) != codes.Internal {
+		t.Errorf("error = %v, want codes.Internal", err)
+	}
+}
+
+func TestLoggingUnaryKeepsStatus(t *testing.T) {
+	want := status.Error(codes.NotFound, "subscription not found")
+	handler := func(context.Context, any) (any, error) {
+		return nil, want
+	}
+
+	if _, err := LoggingUnary(slog.New(slog.DiscardHandler))(context.Background(), nil, unaryInfo, handler); err != want {
+		t.Errorf("error = %v, want %v", err, want)
+	}
+}
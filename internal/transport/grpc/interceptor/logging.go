@@ -0,0 +1,70 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LoggingUnary логирует каждый вызов с кодом ответа и длительностью и превращает
+// панику обработчика в codes.Internal, как gin.Recovery для HTTP
+func LoggingUnary(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(ctx, logger, info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal error")
+			}
+			logCall(ctx, logger, info.FullMethod, start, err)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func LoggingStream(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(stream.Context(), logger, info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal error")
+			}
+			logCall(stream.Context(), logger, info.FullMethod, start, err)
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+func logCall(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+
+	logger.Log(ctx, level, "gRPC call",
+		slog.Any("request_id", ctx.Value(middleware.RequestIDKey)),
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
+
+func logPanic(ctx context.Context, logger *slog.Logger, method string, r any) {
+	logger.Error("Panic in gRPC handler",
+		slog.Any("request_id", ctx.Value(middleware.RequestIDKey)),
+		slog.String("method", method),
+		slog.Any("panic", r),
+		slog.String("stack", string(debug.Stack())),
+	)
+}
@@ -0,0 +1,65 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// RequestIDUnary берет request id из метаданных x-request-id или создает новый и кладет
+// его в контекст под тем же ключом, что и HTTP middleware, чтобы сервисный слой
+// логировал запросы одинаково. Туда же кладется адрес клиента.
+func RequestIDUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+func RequestIDStream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: stream, ctx: withRequestID(stream.Context())})
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	key := strings.ToLower(middleware.RequestIDKey)
+
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(key, requestID))
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, requestID)
+
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		ctx = context.WithValue(ctx, middleware.ClientIPKey, host)
+	}
+
+	return ctx
+}
+
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
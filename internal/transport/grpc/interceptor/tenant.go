@@ -0,0 +1,72 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TenantUnary определяет организацию вызова по токену или метаданным с именем cfg.Header,
+// как middleware.Tenant в HTTP API. Должен стоять после Auth.
+func TenantUnary(cfg config.TenantConfig, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := resolveTenant(ctx, info.FullMethod, cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func TenantStream(cfg config.TenantConfig, logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := resolveTenant(stream.Context(), info.FullMethod, cfg, logger)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &wrappedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func resolveTenant(ctx context.Context, method string, cfg config.TenantConfig, logger *slog.Logger) (context.Context, error) {
+	if strings.HasPrefix(method, reflectionPrefix) {
+		return ctx, nil
+	}
+
+	var requested string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		// ключи метаданных gRPC всегда в нижнем регистре
+		if values := md.Get(strings.ToLower(cfg.Header)); len(values) > 0 {
+			requested = values[0]
+		}
+	}
+
+	principal, _ := domain.PrincipalFromContext(ctx)
+	organizationID, err := domain.ResolveTenant(principal, requested, cfg.Required)
+	if err != nil {
+		logger.Info("Rejected organization", slog.String("method", method), slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, domain.ErrForbidden):
+			return nil, status.Error(codes.PermissionDenied, "the token belongs to another organization")
+		case errors.Is(err, domain.ErrTenantUnbound):
+			return nil, status.Error(codes.PermissionDenied, "the token is not bound to an organization")
+		case errors.Is(err, domain.ErrInvalidTenant):
+			return nil, status.Error(codes.InvalidArgument, cfg.Header+" must be a UUID")
+		default:
+			return nil, status.Error(codes.InvalidArgument, cfg.Header+" metadata is required")
+		}
+	}
+
+	return domain.WithTenant(ctx, organizationID), nil
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	subscriptionsv1 "github.com/ent1k1377/subscriptions/internal/transport/grpc/gen/subscriptions/v1"
+	"github.com/ent1k1377/subscriptions/internal/transport/grpc/handler/subscription"
+	"github.com/ent1k1377/subscriptions/internal/transport/grpc/interceptor"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type Server struct {
+	grpcServer *grpc.Server
+	addr       string
+	logger     *slog.Logger
+}
+
+// NewServer создает gRPC-сервер. При authenticator == nil вызовы не аутентифицируются.
+func NewServer(cfg config.GRPCConfig, tenantCfg config.TenantConfig, baseLogger *slog.Logger, authenticator domain.Authenticator, subscriptionHandler *subscription.Handler) *Server {
+	logger := baseLogger.With("layer", "grpc")
+
+	// request id выставляется первым, чтобы попасть в логи вызова, в том числе отклоненного
+	unary := []grpc.UnaryServerInterceptor{interceptor.RequestIDUnary(), interceptor.LoggingUnary(logger)}
+	stream := []grpc.StreamServerInterceptor{interceptor.RequestIDStream(), interceptor.LoggingStream(logger)}
+	if authenticator != nil {
+		unary = append(unary, interceptor.AuthUnary(authenticator, logger))
+		stream = append(stream, interceptor.AuthStream(authenticator, logger))
+	}
+	unary = append(unary, interceptor.TenantUnary(tenantCfg, logger))
+	stream = append(stream, interceptor.TenantStream(tenantCfg, logger))
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	subscriptionsv1.RegisterSubscriptionServiceServer(grpcServer, subscriptionHandler)
+
+	// reflection нужен grpcurl и Postman, чтобы вызывать методы без .proto
+	if cfg.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	return &Server{
+		grpcServer: grpcServer,
+		addr:       ":" + cfg.Port,
+		logger:     logger,
+	}
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Starting gRPC server", slog.String("addr", s.addr))
+	return s.grpcServer.Serve(listener)
+}
+
+// Close дожидается завершения текущих вызовов, а по истечении ctx обрывает оставшиеся
+func (s *Server) Close(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -9,8 +9,11 @@ import (
 type MonthYear time.Time
 
 func (my *MonthYear) UnmarshalJSON(b []byte) error {
-	s := strings.Trim(string(b), `"`)
+	return my.UnmarshalParam(strings.Trim(string(b), `"`))
+}
 
+// UnmarshalParam позволяет использовать MonthYear в query-параметрах
+func (my *MonthYear) UnmarshalParam(s string) error {
 	t, err := time.Parse("01-2006", s)
 	if err != nil {
 		return err
@@ -21,6 +24,16 @@ func (my *MonthYear) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Time возвращает указатель на время или nil, если значение не задано
+func (my MonthYear) Time() *time.Time {
+	t := time.Time(my)
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
 func (my *MonthYear) MarshalJSON() ([]byte, error) {
 	t := time.Time(*my)
 	formatted := t.Format("01-2006")
@@ -0,0 +1,121 @@
+package common
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// XLSXWriter потоково пишет книгу XLSX с одним листом. Строки сразу уходят в
+// zip-архив, поэтому размер книги не ограничен памятью.
+type XLSXWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+
+	var escapedName strings.Builder
+	_ = xml.EscapeText(&escapedName, []byte(sheetName))
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escapedName.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &XLSXWriter{zip: zw, sheet: bufio.NewWriter(sheet)}
+	if _, err := writer.sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// WriteRow добавляет строку. Целые числа записываются числовыми ячейками,
+// nil — пустой ячейкой, остальные значения — inline-строками: значение вида "=..."
+// остается текстом и не вычисляется как формула.
+func (x *XLSXWriter) WriteRow(cells ...any) error {
+	x.sheet.WriteString("<row>")
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case nil:
+			x.sheet.WriteString("<c/>")
+		case int:
+			x.sheet.WriteString(`<c><v>` + strconv.Itoa(v) + `</v></c>`)
+		default:
+			x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(x.sheet, []byte(fmt.Sprint(v))); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString("</row>")
+
+	return err
+}
+
+// Flush отправляет накопленные строки в нижележащий writer
+func (x *XLSXWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return x.zip.Flush()
+}
+
+func (x *XLSXWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return x.zip.Close()
+}
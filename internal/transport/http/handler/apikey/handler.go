@@ -0,0 +1,246 @@
+package apikey
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	logger        *slog.Logger
+	apiKeyService *service.APIKey
+}
+
+func NewHandler(baseLogger *slog.Logger, apiKeyService *service.APIKey) *Handler {
+	logger := baseLogger.WithGroup("api key handler")
+
+	return &Handler{
+		logger:        logger,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// Issue выпускает API-ключ
+//
+//	@Summary		Выпустить API-ключ
+//	@Description	Выпускает ключ для сервисов, которые не могут получить JWT. Ключ передается в заголовке Authorization: ApiKey <key>.
+//	@Description	Права: subscriptions:read — чтение и выгрузка подписок, subscriptions:write — создание, изменение и удаление,
+//	@Description	reports:read — суммы и отчеты. Ключу доступны подписки всех пользователей в пределах прав.
+//	@Description	Ключ возвращается только в этом ответе, в базе хранится его хеш. Доступно администраторам.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateAPIKeyRequest	true	"Имя и права ключа"
+//	@Success		201		{object}	IssueAPIKeyResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/api-keys [post]
+func (h *Handler) Issue(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Issue"),
+	)
+
+	var request CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+		return
+	}
+
+	params, err := ToCreateAPIKeyParams(&request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	issued, err := h.apiKeyService.IssueAPIKey(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to issue api key", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to issue the api key"))
+		return
+	}
+
+	c.Header("Location", "/api/admin/api-keys/"+issued.APIKey.UUID.String())
+	c.JSON(http.StatusCreated, ToIssueAPIKeyResponse(issued))
+}
+
+// List возвращает все API-ключи, включая отозванные
+//
+//	@Summary	Список API-ключей
+//	@Tags		api-keys
+//	@Produce	json
+//	@Success	200	{object}	ListAPIKeysResponse
+//	@Failure	401	{object}	common.ErrorResponse
+//	@Failure	403	{object}	common.ErrorResponse
+//	@Failure	500	{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Router		/admin/api-keys [get]
+func (h *Handler) List(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "List"),
+	)
+
+	keys, err := h.apiKeyService.ListAPIKeys(middleware.RequestContext(c))
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to list api keys", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to list the api keys"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ToListAPIKeysResponse(keys))
+}
+
+// Get возвращает API-ключ по UUID
+//
+//	@Summary	Получить API-ключ
+//	@Tags		api-keys
+//	@Produce	json
+//	@Param		uuid	path		string	true	"UUID ключа"	Format(uuid)
+//	@Success	200		{object}	APIKeyResponse
+//	@Failure	400		{object}	common.ErrorResponse
+//	@Failure	401		{object}	common.ErrorResponse
+//	@Failure	403		{object}	common.ErrorResponse
+//	@Failure	404		{object}	common.ErrorResponse
+//	@Failure	500		{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Router		/admin/api-keys/{uuid} [get]
+func (h *Handler) Get(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Get"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	key, err := h.apiKeyService.GetAPIKey(middleware.RequestContext(c), id)
+	if !h.handleError(c, logger, err, "failed to get the api key") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToAPIKeyResponse(key))
+}
+
+// Rotate выпускает новый секрет для ключа
+//
+//	@Summary		Ротация API-ключа
+//	@Description	Выпускает новый секрет, имя, права и UUID ключа не меняются. Прежний секрет действует еще grace_seconds,
+//	@Description	чтобы задачи успели перейти на новый. Отозванный ключ ротировать нельзя.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			uuid	path		string				true	"UUID ключа"	Format(uuid)
+//	@Param			request	body		RotateAPIKeyRequest	false	"Период действия прежнего секрета"
+//	@Success		200		{object}	IssueAPIKeyResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/api-keys/{uuid}/rotate [post]
+func (h *Handler) Rotate(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Rotate"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	var request RotateAPIKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+			return
+		}
+	}
+
+	issued, err := h.apiKeyService.RotateAPIKey(middleware.RequestContext(c), id, ToRotateAPIKeyParams(&request))
+	if !h.handleError(c, logger, err, "failed to rotate the api key") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToIssueAPIKeyResponse(issued))
+}
+
+// Revoke отзывает API-ключ
+//
+//	@Summary		Отозвать API-ключ
+//	@Description	Ключ и его прежний секрет после ротации перестают действовать сразу. Ключ остается в списке с revoked_at.
+//	@Tags			api-keys
+//	@Produce		json
+//	@Param			uuid	path		string	true	"UUID ключа"	Format(uuid)
+//	@Success		200		{object}	APIKeyResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/api-keys/{uuid} [delete]
+func (h *Handler) Revoke(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Revoke"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	key, err := h.apiKeyService.RevokeAPIKey(middleware.RequestContext(c), id)
+	if !h.handleError(c, logger, err, "failed to revoke the api key") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToAPIKeyResponse(key))
+}
+
+// handleError отвечает на ошибку сервиса и возвращает true, если ошибки не было
+func (h *Handler) handleError(c *gin.Context, logger *slog.Logger, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("api key not found"))
+	default:
+		logger.Error("API key request failed", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse(message))
+	}
+
+	return false
+}
+
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(name+" is not valid"))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
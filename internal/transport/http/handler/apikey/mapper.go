@@ -0,0 +1,59 @@
+package apikey
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+func ToCreateAPIKeyParams(request *CreateAPIKeyRequest) (*domain.CreateAPIKeyParams, error) {
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+
+	return &domain.CreateAPIKeyParams{
+		Name:      request.Name,
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: request.ExpiresAt,
+	}, nil
+}
+
+func ToRotateAPIKeyParams(request *RotateAPIKeyRequest) *domain.RotateAPIKeyParams {
+	return &domain.RotateAPIKeyParams{Grace: time.Duration(request.GraceSeconds) * time.Second}
+}
+
+func ToAPIKeyResponse(key *domain.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:             key.UUID.String(),
+		OrganizationID: key.OrganizationID.String(),
+		Name:           key.Name,
+		Prefix:         key.Prefix,
+		Scopes:         key.Scopes,
+		ExpiresAt:      key.ExpiresAt,
+		LastUsedAt:     key.LastUsedAt,
+		RevokedAt:      key.RevokedAt,
+		CreatedAt:      key.CreatedAt,
+		UpdatedAt:      key.UpdatedAt,
+	}
+}
+
+func ToIssueAPIKeyResponse(issued *domain.IssuedAPIKey) *IssueAPIKeyResponse {
+	return &IssueAPIKeyResponse{
+		APIKeyResponse: *ToAPIKeyResponse(issued.APIKey),
+		Key:            issued.Key,
+	}
+}
+
+func ToListAPIKeysResponse(keys []*domain.APIKey) *ListAPIKeysResponse {
+	response := &ListAPIKeysResponse{APIKeys: make([]*APIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.APIKeys = append(response.APIKeys, ToAPIKeyResponse(key))
+	}
+
+	return response
+}
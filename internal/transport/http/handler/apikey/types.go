@@ -0,0 +1,39 @@
+package apikey
+
+import "time"
+
+// CreateAPIKeyRequest выпуск ключа для сервиса. Без expires_at ключ бессрочный.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" example:"nightly-billing-export" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" example:"subscriptions:read,reports:read" binding:"required,min=1,dive,oneof=subscriptions:read subscriptions:write reports:read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// RotateAPIKeyRequest grace_seconds сколько прежний ключ продолжает действовать,
+// по умолчанию 0, не больше 30 дней
+type RotateAPIKeyRequest struct {
+	GraceSeconds int `json:"grace_seconds" example:"86400" binding:"min=0,max=2592000"`
+}
+
+type APIKeyResponse struct {
+	ID             string     `json:"id"`
+	OrganizationID string     `json:"organization_id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix" example:"sk_Qm9vbXN0"`
+	Scopes         []string   `json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IssueAPIKeyResponse ключ возвращается только при выпуске и ротации
+type IssueAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []*APIKeyResponse `json:"api_keys"`
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	logger       *slog.Logger
+	auditService *service.Audit
+}
+
+func NewHandler(baseLogger *slog.Logger, auditService *service.Audit) *Handler {
+	logger := baseLogger.WithGroup("audit handler")
+
+	return &Handler{
+		logger:       logger,
+		auditService: auditService,
+	}
+}
+
+// History возвращает журнал изменений подписки
+//
+//	@Summary		История подписки
+//	@Description	Возвращает записи журнала о создании, изменении и удалении подписки, от новых к старым.
+//	@Description	Каждая запись содержит состояние до и после, изменившиеся поля, автора, request_id и IP-адрес.
+//	@Description	История удаленной подписки остается доступной. Чужие подписки видны только ролям support и admin.
+//	@Tags			audit
+//	@Produce		json
+//	@Param			uuid	path		string	true	"UUID подписки"	Format(uuid)
+//	@Param			limit	query		int		false	"Размер страницы (1–100, по умолчанию 50)"
+//	@Param			cursor	query		string	false	"Курсор страницы (next_cursor)"
+//	@Success		200		{object}	AuditPageResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/{uuid}/history [get]
+func (h *Handler) History(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "History"),
+	)
+
+	id, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		logger.Warn("Failed to parse the uuid", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("uuid is not valid"))
+		return
+	}
+
+	var request HistoryRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	page, err := h.auditService.SubscriptionHistory(middleware.RequestContext(c), id, ToSearchAuditParams(&request))
+	if !h.handleError(c, logger, err, "failed to get the subscription history") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToAuditPageResponse(page))
+}
+
+// Search ищет по журналу изменений всех подписок организации
+//
+//	@Summary		Поиск по журналу изменений
+//	@Description	Возвращает записи журнала от новых к старым. Фильтры объединяются по И.
+//	@Description	actor_id совпадает и с пользователем, и с API-ключом, выполнившим изменение. Доступно администраторам.
+//	@Tags			audit
+//	@Produce		json
+//	@Param			subscription_id	query		string	false	"UUID подписки"								Format(uuid)
+//	@Param			user_id			query		string	false	"UUID владельца подписки"					Format(uuid)
+//	@Param			actor_id		query		string	false	"UUID пользователя или API-ключа автора"	Format(uuid)
+//	@Param			action			query		string	false	"Действие"									Enums(create, update, delete, restore)
+//	@Param			request_id		query		string	false	"Идентификатор запроса (X-Request-ID)"
+//	@Param			from			query		string	false	"Не раньше момента (RFC 3339)"	Format(date-time)
+//	@Param			to				query		string	false	"Раньше момента (RFC 3339)"		Format(date-time)
+//	@Param			limit			query		int		false	"Размер страницы (1–100, по умолчанию 50)"
+//	@Param			cursor			query		string	false	"Курсор страницы (next_cursor)"
+//	@Success		200				{object}	AuditPageResponse
+//	@Failure		400				{object}	common.ErrorResponse
+//	@Failure		401				{object}	common.ErrorResponse
+//	@Failure		403				{object}	common.ErrorResponse
+//	@Failure		500				{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/audit [get]
+func (h *Handler) Search(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Search"),
+	)
+
+	var request SearchAuditRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	params := ToSearchAuditParams(&request.HistoryRequest)
+	params.Filter = ToAuditFilter(&request)
+
+	page, err := h.auditService.SearchAudit(middleware.RequestContext(c), params)
+	if !h.handleError(c, logger, err, "failed to search the audit log") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToAuditPageResponse(page))
+}
+
+// handleError отвечает на ошибку сервиса и возвращает true, если ошибки не было
+func (h *Handler) handleError(c *gin.Context, logger *slog.Logger, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to the audit log is forbidden"))
+	case errors.Is(err, domain.ErrSubscriptionNotFound):
+		logger.Warn("Subscription not found")
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("subscription not found"))
+	case errors.Is(err, domain.ErrInvalidAuditCursor):
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+	default:
+		logger.Error("Audit request failed", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse(message))
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func ToSearchAuditParams(request *HistoryRequest) *domain.SearchAuditParams {
+	limit := request.Limit
+	if limit == 0 {
+		limit = DefaultAuditLimit
+	}
+
+	return &domain.SearchAuditParams{
+		Limit:  limit,
+		Cursor: request.Cursor,
+	}
+}
+
+// ToAuditFilter UUID уже проверены при разборе запроса
+func ToAuditFilter(request *SearchAuditRequest) domain.AuditFilter {
+	filter := domain.AuditFilter{
+		SubscriptionUUID: parseOptionalUUID(request.SubscriptionID),
+		UserUUID:         parseOptionalUUID(request.UserID),
+		ActorUUID:        parseOptionalUUID(request.ActorID),
+		RequestID:        request.RequestID,
+		From:             request.From,
+		To:               request.To,
+	}
+	if request.Action != nil {
+		action := domain.AuditAction(*request.Action)
+		filter.Action = &action
+	}
+
+	return filter
+}
+
+func ToAuditPageResponse(page *domain.AuditPage) *AuditPageResponse {
+	response := &AuditPageResponse{
+		Records:    make([]*AuditRecordResponse, 0, len(page.Records)),
+		NextCursor: page.NextCursor,
+	}
+	for _, record := range page.Records {
+		response.Records = append(response.Records, ToAuditRecordResponse(record))
+	}
+
+	return response
+}
+
+func ToAuditRecordResponse(record *domain.AuditRecord) *AuditRecordResponse {
+	response := &AuditRecordResponse{
+		ID:             record.ID,
+		SubscriptionID: record.SubscriptionUUID.String(),
+		UserID:         record.UserUUID.String(),
+		Action:         string(record.Action),
+		ActorUserID:    uuidString(record.ActorUserUUID),
+		ActorAPIKeyID:  uuidString(record.ActorAPIKeyUUID),
+		RequestID:      record.RequestID,
+		SourceIP:       record.SourceIP,
+		Before:         record.Before,
+		After:          record.After,
+		Diff:           make(map[string]AuditChangeResponse, len(record.Diff)),
+		OccurredAt:     record.OccurredAt,
+	}
+	for field, change := range record.Diff {
+		response.Diff[field] = AuditChangeResponse{From: change.From, To: change.To}
+	}
+
+	return response
+}
+
+func parseOptionalUUID(value *string) *uuid.UUID {
+	if value == nil {
+		return nil
+	}
+
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		return nil
+	}
+
+	return &id
+}
+
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+
+	value := id.String()
+	return &value
+}
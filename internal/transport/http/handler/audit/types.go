@@ -0,0 +1,48 @@
+package audit
+
+import "time"
+
+const DefaultAuditLimit = 50
+
+// HistoryRequest страница истории подписки
+type HistoryRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SearchAuditRequest фильтры поиска по журналу, все необязательные
+type SearchAuditRequest struct {
+	HistoryRequest
+	SubscriptionID *string    `form:"subscription_id" binding:"omitempty,uuid"`
+	UserID         *string    `form:"user_id" binding:"omitempty,uuid"`
+	ActorID        *string    `form:"actor_id" binding:"omitempty,uuid"`
+	Action         *string    `form:"action" binding:"omitempty,oneof=create update delete restore"`
+	RequestID      *string    `form:"request_id"`
+	From           *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To             *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type AuditChangeResponse struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type AuditRecordResponse struct {
+	ID             int64                          `json:"id"`
+	SubscriptionID string                         `json:"subscription_id"`
+	UserID         string                         `json:"user_id"`
+	Action         string                         `json:"action" example:"update"`
+	ActorUserID    *string                        `json:"actor_user_id"`
+	ActorAPIKeyID  *string                        `json:"actor_api_key_id"`
+	RequestID      string                         `json:"request_id"`
+	SourceIP       string                         `json:"source_ip" example:"203.0.113.7"`
+	Before         map[string]any                 `json:"before"`
+	After          map[string]any                 `json:"after"`
+	Diff           map[string]AuditChangeResponse `json:"diff"`
+	OccurredAt     time.Time                      `json:"occurred_at"`
+}
+
+type AuditPageResponse struct {
+	Records    []*AuditRecordResponse `json:"records"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
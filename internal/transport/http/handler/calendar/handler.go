@@ -0,0 +1,191 @@
+package calendar
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	logger          *slog.Logger
+	calendarService *service.Calendar
+}
+
+func NewHandler(baseLogger *slog.Logger, calendarService *service.Calendar) *Handler {
+	logger := baseLogger.WithGroup("calendar handler")
+
+	return &Handler{
+		logger:          logger,
+		calendarService: calendarService,
+	}
+}
+
+// IssueToken выпускает секретную ссылку на календарь пользователя
+//
+//	@Summary		Выпустить ссылку на календарь
+//	@Description	Создает новый токен для подписки на календарь продлений. Ранее выданная ссылка перестает работать.
+//	@Tags			calendar
+//	@Produce		json
+//	@Param			user_id	path		string	true	"UUID пользователя"	Format(uuid)
+//	@Success		201		{object}	FeedTokenResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/users/{user_id}/calendar-token [post]
+func (h *Handler) IssueToken(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "IssueToken"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	token, err := h.calendarService.IssueFeedToken(middleware.RequestContext(c), userID)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to another user is forbidden"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to issue calendar token", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to issue the calendar token"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, FeedTokenResponse{
+		Token:     token.Token,
+		FeedURL:   feedURL(c, userID, token.Token),
+		CreatedAt: token.CreatedAt,
+	})
+}
+
+// RevokeToken отзывает ссылку на календарь пользователя
+//
+//	@Summary	Отозвать ссылку на календарь
+//	@Tags		calendar
+//	@Produce	json
+//	@Param		user_id	path	string	true	"UUID пользователя"	Format(uuid)
+//	@Success	204
+//	@Failure	400	{object}	common.ErrorResponse
+//	@Failure	401	{object}	common.ErrorResponse
+//	@Failure	500	{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/users/{user_id}/calendar-token [delete]
+func (h *Handler) RevokeToken(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "RevokeToken"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	err = h.calendarService.RevokeFeedToken(middleware.RequestContext(c), userID)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to another user is forbidden"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to revoke calendar token", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to revoke the calendar token"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Feed отдает календарь продлений и окончаний подписок в формате iCalendar
+//
+//	@Summary		Календарь подписок
+//	@Description	Возвращает iCalendar (RFC 5545) с событиями продления и событиями окончания подписок.
+//	@Description	Продление повторяется ежемесячно, у подписок, подтвержденных из годовых кандидатов выписки, — ежегодно.
+//	@Description	Ссылку можно добавить в Google Calendar, Apple Calendar или Outlook. Доступ проверяется по токену из ссылки.
+//	@Tags			calendar
+//	@Produce		text/calendar
+//	@Param			user_id		path		string	true	"UUID пользователя"	Format(uuid)
+//	@Param			token		query		string	true	"Токен ссылки"
+//	@Param			remind_days	query		int		false	"За сколько дней напоминать"	minimum(0)	maximum(30)	default(1)
+//	@Success		200			{string}	string	"Календарь"
+//	@Failure		400			{object}	common.ErrorResponse
+//	@Failure		404			{object}	common.ErrorResponse
+//	@Failure		500			{object}	common.ErrorResponse
+//	@Router			/users/{user_id}/calendar.ics [get]
+func (h *Handler) Feed(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Feed"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	var request FeedRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query parameters are not valid"))
+		return
+	}
+
+	remindDays := DefaultRemindDays
+	if request.RemindDays != nil {
+		remindDays = *request.RemindDays
+	}
+
+	subscriptions, err := h.calendarService.FeedSubscriptions(middleware.RequestContext(c), userID, request.Token)
+	if errors.Is(err, domain.ErrInvalidFeedToken) {
+		// на неверный токен отвечаем так же, как на несуществующий календарь
+		logger.Warn("Invalid calendar token", slog.String("user_id", userID.String()))
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("calendar not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get calendar subscriptions", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to build the calendar"))
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Header("Content-Disposition", `inline; filename="subscriptions.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(BuildFeed(subscriptions, remindDays, time.Now())))
+}
+
+// feedURL собирает абсолютную ссылку на календарь с учетом прокси перед сервисом
+func feedURL(c *gin.Context, userID uuid.UUID, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     c.Request.Host,
+		Path:     "/api/users/" + userID.String() + "/calendar.ics",
+		RawQuery: url.Values{"token": {token}}.Encode(),
+	}
+
+	return u.String()
+}
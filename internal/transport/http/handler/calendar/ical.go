@@ -0,0 +1,139 @@
+package calendar
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+const (
+	icalDateLayout     = "20060102"
+	icalDateTimeLayout = "20060102T150405Z"
+	icalMaxLineOctets  = 75
+)
+
+// icalWriter формирует календарь по RFC 5545: строки завершаются CRLF,
+// длинные строки переносятся по 75 октетов.
+type icalWriter struct {
+	b strings.Builder
+}
+
+func (w *icalWriter) line(name, value string) {
+	content := name + ":" + value
+	for len(content) > icalMaxLineOctets {
+		cut := icalMaxLineOctets
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		w.b.WriteString(content[:cut] + "\r\n")
+		// строка-продолжение начинается с пробела, он входит в лимит
+		content = " " + content[cut:]
+	}
+	w.b.WriteString(content + "\r\n")
+}
+
+func (w *icalWriter) String() string {
+	return w.b.String()
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// BuildFeed собирает календарь с повторяющимися событиями продления и событиями
+// окончания подписок. К каждому событию добавляется напоминание за remindDays дней.
+func BuildFeed(subscriptions []*domain.CalendarSubscription, remindDays int, now time.Time) string {
+	var w icalWriter
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//subscriptions//calendar feed//RU")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", escapeText("Подписки"))
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT12H")
+	w.line("X-PUBLISHED-TTL", "PT12H")
+
+	for _, item := range subscriptions {
+		writeRenewalEvent(&w, item.Subscription, item.Period, remindDays, now)
+		if item.Subscription.EndDate != nil {
+			writeEndEvent(&w, item.Subscription, remindDays, now)
+		}
+	}
+
+	w.line("END", "VCALENDAR")
+
+	return w.String()
+}
+
+// writeRenewalEvent продление повторяется с периодом подписки от даты начала и до даты
+// окончания включительно. Если период неизвестен, событие не повторяется.
+func writeRenewalEvent(w *icalWriter, subscription *domain.Subscription, period domain.RecurrencePeriod, remindDays int, now time.Time) {
+	rrule := renewalRule(period)
+	if rrule != "" && subscription.EndDate != nil {
+		rrule += ";UNTIL=" + subscription.EndDate.Format(icalDateLayout)
+	}
+
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", subscription.UUID.String()+"-renewal@subscriptions")
+	w.line("DTSTAMP", stamp(subscription, now))
+	w.line("DTSTART;VALUE=DATE", subscription.StartDate.Format(icalDateLayout))
+	w.line("DTEND;VALUE=DATE", subscription.StartDate.AddDate(0, 0, 1).Format(icalDateLayout))
+	if rrule != "" {
+		w.line("RRULE", rrule)
+	}
+	w.line("SUMMARY", escapeText("Продление "+subscription.ServiceName+" — "+strconv.Itoa(subscription.Price)+" ₽"))
+	if subscription.Notes != "" {
+		w.line("DESCRIPTION", escapeText(subscription.Notes))
+	}
+	w.line("TRANSP", "TRANSPARENT")
+	writeAlarm(w, "Скоро продление "+subscription.ServiceName, remindDays)
+	w.line("END", "VEVENT")
+}
+
+func renewalRule(period domain.RecurrencePeriod) string {
+	switch period {
+	case domain.RecurrenceMonthly:
+		return "FREQ=MONTHLY"
+	case domain.RecurrenceYearly:
+		return "FREQ=YEARLY"
+	default:
+		return ""
+	}
+}
+
+func writeEndEvent(w *icalWriter, subscription *domain.Subscription, remindDays int, now time.Time) {
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", subscription.UUID.String()+"-end@subscriptions")
+	w.line("DTSTAMP", stamp(subscription, now))
+	w.line("DTSTART;VALUE=DATE", subscription.EndDate.Format(icalDateLayout))
+	w.line("DTEND;VALUE=DATE", subscription.EndDate.AddDate(0, 0, 1).Format(icalDateLayout))
+	w.line("SUMMARY", escapeText("Окончание подписки "+subscription.ServiceName))
+	w.line("TRANSP", "TRANSPARENT")
+	writeAlarm(w, "Скоро заканчивается подписка "+subscription.ServiceName, remindDays)
+	w.line("END", "VEVENT")
+}
+
+func writeAlarm(w *icalWriter, description string, remindDays int) {
+	w.line("BEGIN", "VALARM")
+	w.line("ACTION", "DISPLAY")
+	w.line("TRIGGER", "-P"+strconv.Itoa(remindDays)+"D")
+	w.line("DESCRIPTION", escapeText(description))
+	w.line("END", "VALARM")
+}
+
+func stamp(subscription *domain.Subscription, now time.Time) string {
+	if subscription.UpdatedAt.IsZero() {
+		return now.UTC().Format(icalDateTimeLayout)
+	}
+
+	return subscription.UpdatedAt.UTC().Format(icalDateTimeLayout)
+}
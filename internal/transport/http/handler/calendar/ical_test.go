@@ -0,0 +1,178 @@
+package calendar
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestICalWriterLineFolding(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantLines int
+	}{
+		{name: "short", value: "VCALENDAR", wantLines: 1},
+		{name: "exactly at limit", value: strings.Repeat("a", icalMaxLineOctets-len("SUMMARY:")), wantLines: 1},
+		{name: "one octet over", value: strings.Repeat("a", icalMaxLineOctets-len("SUMMARY:")+1), wantLines: 2},
+		{name: "long ascii", value: strings.Repeat("a", 200), wantLines: 3},
+		{name: "cyrillic is not split", value: strings.Repeat("ж", 100), wantLines: 3},
+		{name: "four-byte runes are not split", value: strings.Repeat("😀", 40), wantLines: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w icalWriter
+			w.line("SUMMARY", tt.value)
+			out := w.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(lines) != tt.wantLines {
+				t.Fatalf("got %d lines, want %d: %q", len(lines), tt.wantLines, lines)
+			}
+
+			var unfolded strings.Builder
+			for i, line := range lines {
+				if len(line) > icalMaxLineOctets {
+					t.Errorf("line %d has %d octets, want at most %d", i, len(line), icalMaxLineOctets)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d %q splits a multi-byte character", i, line)
+				}
+				if i > 0 {
+					if !strings.HasPrefix(line, " ") {
+						t.Errorf("continuation line %d %q does not start with a space", i, line)
+					}
+					line = line[1:]
+				}
+				unfolded.WriteString(line)
+			}
+			if want := "SUMMARY:" + tt.value; unfolded.String() != want {
+				t.Errorf("unfolded = %q, want %q", unfolded.String(), want)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "Netflix", want: "Netflix"},
+		{value: "a;b,c", want: `a\;b\,c`},
+		{value: `C:\path`, want: `C:\\path`},
+		{value: "first\nsecond", want: `first\nsecond`},
+		{value: "first\r\nsecond", want: `first\nsecond`},
+		{value: `\;`, want: `\\\;`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.value); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRenewalRule(t *testing.T) {
+	tests := []struct {
+		period domain.RecurrencePeriod
+		want   string
+	}{
+		{period: domain.RecurrenceMonthly, want: "FREQ=MONTHLY"},
+		{period: domain.RecurrenceYearly, want: "FREQ=YEARLY"},
+		{period: "", want: ""},
+		{period: "weekly", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := renewalRule(tt.period); got != tt.want {
+			t.Errorf("renewalRule(%q) = %q, want %q", tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestBuildFeed(t *testing.T) {
+	now := time.Date(2025, time.July, 15, 10, 30, 0, 0, time.UTC)
+	endDate := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	subscription := func(endDate *time.Time) *domain.Subscription {
+		return &domain.Subscription{
+			UUID:        uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+			ServiceName: "Yandex Plus",
+			Price:       399,
+			StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:     endDate,
+			Notes:       "семейный, на двоих",
+		}
+	}
+
+	tests := []struct {
+		name        string
+		item        *domain.CalendarSubscription
+		wantLines   []string
+		absentLines []string // префиксы строк, которых не должно быть в календаре
+	}{
+		{
+			name: "monthly without end",
+			item: &domain.CalendarSubscription{Subscription: subscription(nil), Period: domain.RecurrenceMonthly},
+			wantLines: []string{
+				"UID:6ba7b810-9dad-11d1-80b4-00c04fd430c8-renewal@subscriptions",
+				"DTSTAMP:20250715T103000Z",
+				"DTSTART;VALUE=DATE:20250701",
+				"DTEND;VALUE=DATE:20250702",
+				"RRULE:FREQ=MONTHLY",
+				"DESCRIPTION:семейный\\, на двоих",
+				"TRIGGER:-P3D",
+			},
+			absentLines: []string{"UID:6ba7b810-9dad-11d1-80b4-00c04fd430c8-end@subscriptions"},
+		},
+		{
+			name: "yearly with end",
+			item: &domain.CalendarSubscription{Subscription: subscription(&endDate), Period: domain.RecurrenceYearly},
+			wantLines: []string{
+				"RRULE:FREQ=YEARLY;UNTIL=20251201",
+				"UID:6ba7b810-9dad-11d1-80b4-00c04fd430c8-end@subscriptions",
+				"DTSTART;VALUE=DATE:20251201",
+			},
+		},
+		{
+			name:        "unknown period is not repeated",
+			item:        &domain.CalendarSubscription{Subscription: subscription(&endDate), Period: "weekly"},
+			wantLines:   []string{"UID:6ba7b810-9dad-11d1-80b4-00c04fd430c8-renewal@subscriptions"},
+			absentLines: []string{"RRULE:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := BuildFeed([]*domain.CalendarSubscription{tt.item}, 3, now)
+			lines := strings.Split(strings.ReplaceAll(feed, "\r\n ", ""), "\r\n")
+
+			if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-2] != "END:VCALENDAR" {
+				t.Fatalf("feed is not wrapped in VCALENDAR: %q", feed)
+			}
+			for _, want := range tt.wantLines {
+				if !slices.Contains(lines, want) {
+					t.Errorf("feed has no line %q:\n%s", want, feed)
+				}
+			}
+			for _, absent := range tt.absentLines {
+				for _, line := range lines {
+					if strings.HasPrefix(line, absent) {
+						t.Errorf("feed has unexpected line %q", line)
+					}
+				}
+			}
+		})
+	}
+}
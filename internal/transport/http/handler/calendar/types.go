@@ -0,0 +1,18 @@
+package calendar
+
+import "time"
+
+const (
+	DefaultRemindDays = 1
+)
+
+type FeedRequest struct {
+	Token      string `form:"token" binding:"required"`
+	RemindDays *int   `form:"remind_days" binding:"omitempty,gte=0,lte=30"`
+}
+
+type FeedTokenResponse struct {
+	Token     string    `json:"token"`
+	FeedURL   string    `json:"feed_url" example:"https://example.com/api/users/f81d4fae-7dec-11d0-a765-00a0c91e6bf6/calendar.ics?token=..."`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger     *slog.Logger
+	cfg        config.EventsConfig
+	changeFeed *service.ChangeFeed
+}
+
+func NewHandler(baseLogger *slog.Logger, cfg config.EventsConfig, changeFeed *service.ChangeFeed) *Handler {
+	logger := baseLogger.WithGroup("events handler")
+
+	return &Handler{
+		logger:     logger,
+		cfg:        cfg,
+		changeFeed: changeFeed,
+	}
+}
+
+// Stream отдает изменения подписок в реальном времени
+//
+//	@Summary		Поток изменений подписок
+//	@Description	Server-Sent Events: событие на каждое создание, изменение и удаление подписки.
+//	@Description	Тип изменения передается в поле event (subscription.created, subscription.updated, subscription.deleted), данные — ChangeEvent в JSON.
+//	@Description	При переподключении браузер присылает заголовок Last-Event-ID, и поток продолжается с пропущенных событий, если они еще хранятся.
+//	@Description	Раз в несколько секунд приходит комментарий ": heartbeat". Медленный клиент отключается, после чего может переподключиться с Last-Event-ID.
+//	@Tags			subscriptions
+//	@Produce		text/event-stream
+//	@Param			user_id			query		string		false	"Только подписки пользователя"	Format(uuid)
+//	@Param			service_name	query		string		false	"Только подписки сервиса, без учета регистра"
+//	@Param			last_event_id	query		int			false	"Продолжить после события, если нельзя передать заголовок"
+//	@Param			Last-Event-ID	header		string		false	"Продолжить после события"
+//	@Success		200				{object}	ChangeEvent	"Поток событий"
+//	@Failure		400				{object}	common.ErrorResponse
+//	@Failure		401				{object}	common.ErrorResponse
+//	@Failure		503				{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/events [get]
+func (h *Handler) Stream(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Stream"),
+	)
+
+	var request StreamRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query parameters are not valid"))
+		return
+	}
+
+	filter, err := ToChangeFilter(&request)
+	if err != nil {
+		logger.Warn("Failed to convert the request to change filter", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query parameters are not valid"))
+		return
+	}
+
+	lastEventID, err := ParseLastEventID(c.GetHeader("Last-Event-ID"), request.LastEventID)
+	if err != nil {
+		logger.Warn("Failed to parse Last-Event-ID", slog.String("last_event_id", c.GetHeader("Last-Event-ID")))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("Last-Event-ID is not valid"))
+		return
+	}
+
+	ctx := middleware.RequestContext(c)
+	stream, err := h.changeFeed.Subscribe(ctx, filter, lastEventID)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("cannot stream changes of another user"))
+		return
+	}
+	if err != nil {
+		logger.Warn("Failed to open change stream", slog.String("error", err.Error()))
+		c.JSON(http.StatusServiceUnavailable, common.ToErrorResponse("event stream is not available"))
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// nginx иначе буферизует ответ и события приходят пачками
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if err := writeRetry(c.Writer, RetryMillis); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	sent := 0
+	for {
+		change, err := stream.Next(ctx, h.cfg.Heartbeat)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				logger.Info("Client closed the event stream", slog.Int("sent", sent))
+			case errors.Is(err, domain.ErrChangeStreamLagged), errors.Is(err, domain.ErrChangeStreamClosed):
+				logger.Info("Event stream closed", slog.String("reason", err.Error()), slog.Int("sent", sent))
+			default:
+				logger.Error("Event stream interrupted", slog.String("error", err.Error()), slog.Int("sent", sent))
+			}
+			return
+		}
+
+		if change == nil {
+			err = writeHeartbeat(c.Writer)
+		} else {
+			err = writeEvent(c.Writer, ToChangeEvent(change))
+			sent++
+		}
+		if err != nil {
+			logger.Info("Failed to write to the event stream", slog.String("error", err.Error()), slog.Int("sent", sent))
+			return
+		}
+		c.Writer.Flush()
+	}
+}
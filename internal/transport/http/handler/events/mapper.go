@@ -0,0 +1,47 @@
+package events
+
+import (
+	"strconv"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+	"github.com/google/uuid"
+)
+
+func ToChangeFilter(request *StreamRequest) (domain.ChangeFilter, error) {
+	var filter domain.ChangeFilter
+	if request.UserID != nil {
+		userID, err := uuid.Parse(*request.UserID)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserUUID = &userID
+	}
+	filter.ServiceName = request.ServiceName
+
+	return filter, nil
+}
+
+// ParseLastEventID заголовок Last-Event-ID важнее query-параметра: его выставляет
+// браузер при автоматическом переподключении
+func ParseLastEventID(header string, query *int64) (*int64, error) {
+	if header == "" {
+		return query, nil
+	}
+
+	id, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || id < 0 {
+		return nil, strconv.ErrSyntax
+	}
+
+	return &id, nil
+}
+
+func ToChangeEvent(change *domain.SubscriptionChange) *ChangeEvent {
+	return &ChangeEvent{
+		ID:           change.ID,
+		Type:         string(change.Type),
+		OccurredAt:   change.OccurredAt,
+		Subscription: subscription.ToGetSubscriptionResponse(change.Subscription),
+	}
+}
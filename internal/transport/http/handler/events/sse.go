@@ -0,0 +1,32 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// writeEvent записывает событие в формате text/event-stream. JSON не содержит
+// переводов строк, поэтому data умещается в одну строку.
+func writeEvent(w io.Writer, event *ChangeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", strconv.FormatInt(event.ID, 10), event.Type, data)
+	return err
+}
+
+// writeHeartbeat комментарий не вызывает событий у клиента, но не дает прокси
+// закрыть простаивающее соединение
+func writeHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": heartbeat\n\n")
+	return err
+}
+
+func writeRetry(w io.Writer, millis int) error {
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", millis)
+	return err
+}
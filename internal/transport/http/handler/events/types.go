@@ -0,0 +1,25 @@
+package events
+
+import (
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+)
+
+// RetryMillis через сколько браузер переподключится после обрыва потока
+const RetryMillis = 3000
+
+type StreamRequest struct {
+	UserID      *string `form:"user_id" binding:"omitempty,uuid"`
+	ServiceName *string `form:"service_name" binding:"omitempty,min=1"`
+	// LastEventID для клиентов, которые не умеют передавать заголовок Last-Event-ID
+	LastEventID *int64 `form:"last_event_id" binding:"omitempty,gte=0"`
+}
+
+// ChangeEvent данные события SSE, тип изменения передается в поле event
+type ChangeEvent struct {
+	ID           int64                                 `json:"id" example:"1024"`
+	Type         string                                `json:"type" example:"subscription.updated"`
+	OccurredAt   time.Time                             `json:"occurred_at"`
+	Subscription *subscription.GetSubscriptionResponse `json:"subscription"`
+}
@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	mygraphql "github.com/ent1k1377/subscriptions/internal/transport/graphql"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger     *slog.Logger
+	playground bool
+	schema     *mygraphql.Schema
+}
+
+func NewHandler(baseLogger *slog.Logger, cfg config.GraphQLConfig, schema *mygraphql.Schema) *Handler {
+	logger := baseLogger.WithGroup("graphql handler")
+
+	return &Handler{
+		logger:     logger,
+		playground: cfg.Playground,
+		schema:     schema,
+	}
+}
+
+// Query выполняет GraphQL-запрос
+//
+//	@Summary		GraphQL
+//	@Description	Подписки, сводка и прогноз расходов пользователя, суммы и мутации за один запрос. Схема доступна через интроспекцию.
+//	@Description	Подписки пользователей загружаются пакетно: запрос по нескольким пользователям выполняет одно обращение к базе.
+//	@Description	Запросы, сложность которых превышает лимит, отклоняются до выполнения с кодом COMPLEXITY_LIMIT_EXCEEDED.
+//	@Description	Ошибки выполнения возвращаются в errors со статусом 200, код ошибки — в extensions.code.
+//	@Tags			graphql
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		Request	true	"GraphQL-запрос"
+//	@Success		200		{object}	Response
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/graphql [post]
+func (h *Handler) Query(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Query"),
+	)
+
+	var request Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+		return
+	}
+
+	response := h.schema.Exec(middleware.RequestContext(c), request.Query, request.OperationName, request.Variables)
+	if len(response.Errors) > 0 {
+		logger.Info("GraphQL query finished with errors",
+			slog.String("operation", request.OperationName),
+			slog.Int("errors", len(response.Errors)),
+			slog.String("first_error", response.Errors[0].Message),
+		)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Playground отдает GraphiQL, если он включен в конфигурации (graphql.playground)
+func (h *Handler) Playground(c *gin.Context) {
+	if !h.playground {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("playground is disabled"))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(playgroundPage))
+}
@@ -0,0 +1,23 @@
+package graphql
+
+import "encoding/json"
+
+// Request тело запроса GraphQL over HTTP
+type Request struct {
+	Query         string         `json:"query" binding:"required" example:"query { user(id: \"f81d4fae-7dec-11d0-a765-00a0c91e6bf6\") { summary { activeCount monthlyCost } } }"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response ответ GraphQL: data и/или errors
+type Response struct {
+	Data       json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	Errors     []ResponseError `json:"errors,omitempty"`
+	Extensions map[string]any  `json:"extensions,omitempty"`
+}
+
+type ResponseError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler пробы оркестратора. Ответ 503 означает, что экземпляр не должен получать трафик.
+type Handler struct {
+	logger        *slog.Logger
+	healthService *service.Health
+}
+
+func NewHandler(baseLogger *slog.Logger, healthService *service.Health) *Handler {
+	logger := baseLogger.WithGroup("health handler")
+
+	return &Handler{
+		logger:        logger,
+		healthService: healthService,
+	}
+}
+
+// Liveness отвечает, пока процесс способен обрабатывать запросы. Зависимости не
+// проверяются: их недоступность не лечится перезапуском.
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, &StatusResponse{Status: string(domain.HealthStatusUp)})
+}
+
+// Readiness готов ли экземпляр принимать трафик: база отвечает, миграции применены
+// и остановка не началась
+func (h *Handler) Readiness(c *gin.Context) {
+	if h.healthService.ShuttingDown() {
+		c.JSON(http.StatusServiceUnavailable, &StatusResponse{Status: string(domain.HealthStatusDown), Error: "shutting down"})
+		return
+	}
+
+	health := h.healthService.Check(c.Request.Context())
+	c.JSON(statusCode(health), ToStatusResponse(health))
+}
+
+// Health подробное состояние: статус и время ответа каждой зависимости
+func (h *Handler) Health(c *gin.Context) {
+	health := h.healthService.Check(c.Request.Context())
+	c.JSON(statusCode(health), ToHealthResponse(health))
+}
+
+func statusCode(health *domain.Health) int {
+	if health.Ready() {
+		return http.StatusOK
+	}
+
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type database struct {
+	pingErr error
+}
+
+func (d database) Ping(context.Context) error {
+	return d.pingErr
+}
+
+func (d database) MigrationVersion(context.Context) (int64, error) {
+	return 1, nil
+}
+
+// TestProbes liveness не зависит от базы, readiness и подробная проверка отвечают 503,
+// когда база недоступна или началась остановка
+func TestProbes(t *testing.T) {
+	tests := []struct {
+		name         string
+		pingErr      error
+		shuttingDown bool
+		wantLive     int
+		wantReady    int
+		wantError    string
+	}{
+		{name: "healthy", wantLive: http.StatusOK, wantReady: http.StatusOK},
+		{name: "database is down", pingErr: errors.New("connection refused"),
+			wantLive: http.StatusOK, wantReady: http.StatusServiceUnavailable, wantError: "database: connection refused"},
+		{name: "shutting down", shuttingDown: true,
+			wantLive: http.StatusOK, wantReady: http.StatusServiceUnavailable, wantError: "shutting down"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthService := service.NewHealth(logger, config.HealthConfig{CheckTimeout: time.Second}, database{pingErr: tt.pingErr}, 1)
+			if tt.shuttingDown {
+				healthService.StartShutdown()
+			}
+			handler := NewHandler(logger, healthService)
+			router := gin.New()
+			router.GET("/healthz", handler.Liveness)
+			router.GET("/readyz", handler.Readiness)
+			router.GET("/health", handler.Health)
+
+			live := serve(router, "/healthz")
+			if live.Code != tt.wantLive {
+				t.Errorf("/healthz status = %d, want %d", live.Code, tt.wantLive)
+			}
+
+			ready := serve(router, "/readyz")
+			var status StatusResponse
+			if err := json.Unmarshal(ready.Body.Bytes(), &status); err != nil {
+				t.Fatalf("unmarshal /readyz response: %v", err)
+			}
+			if ready.Code != tt.wantReady || status.Error != tt.wantError {
+				t.Errorf("/readyz = %d %+v, want %d with error %q", ready.Code, status, tt.wantReady, tt.wantError)
+			}
+
+			detailed := serve(router, "/health")
+			var health HealthResponse
+			if err := json.Unmarshal(detailed.Body.Bytes(), &health); err != nil {
+				t.Fatalf("unmarshal /health response: %v", err)
+			}
+			if detailed.Code != tt.wantReady || health.ShuttingDown != tt.shuttingDown || len(health.Dependencies) != 2 {
+				t.Errorf("/health = %d %+v, want %d with both dependencies", detailed.Code, health, tt.wantReady)
+			}
+		})
+	}
+}
+
+func serve(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
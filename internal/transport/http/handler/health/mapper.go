@@ -0,0 +1,43 @@
+package health
+
+import (
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+func ToHealthResponse(health *domain.Health) *HealthResponse {
+	dependencies := make([]*DependencyResponse, 0, len(health.Dependencies))
+	for _, dependency := range health.Dependencies {
+		dependencies = append(dependencies, &DependencyResponse{
+			Name:      dependency.Name,
+			Status:    string(dependency.Status),
+			LatencyMS: float64(dependency.Latency) / float64(time.Millisecond),
+			Error:     dependency.Error,
+			Details:   dependency.Details,
+		})
+	}
+
+	return &HealthResponse{
+		Status:       string(health.Status),
+		ShuttingDown: health.ShuttingDown,
+		Dependencies: dependencies,
+	}
+}
+
+// ToStatusResponse первая недоступная зависимость объясняет, почему сервис не готов
+func ToStatusResponse(health *domain.Health) *StatusResponse {
+	response := &StatusResponse{Status: string(health.Status)}
+	if health.ShuttingDown {
+		response.Error = "shutting down"
+		return response
+	}
+	for _, dependency := range health.Dependencies {
+		if dependency.Status != domain.HealthStatusUp {
+			response.Error = dependency.Name + ": " + dependency.Error
+			break
+		}
+	}
+
+	return response
+}
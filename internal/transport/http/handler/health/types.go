@@ -0,0 +1,21 @@
+package health
+
+// StatusResponse ответ /healthz и /readyz
+type StatusResponse struct {
+	Status string `json:"status" example:"up"`
+	Error  string `json:"error,omitempty"`
+}
+
+type DependencyResponse struct {
+	Name      string         `json:"name" example:"database"`
+	Status    string         `json:"status" example:"up"`
+	LatencyMS float64        `json:"latency_ms" example:"1.25"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type HealthResponse struct {
+	Status       string                `json:"status" example:"up"`
+	ShuttingDown bool                  `json:"shutting_down"`
+	Dependencies []*DependencyResponse `json:"dependencies"`
+}
@@ -0,0 +1,173 @@
+package role
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	logger      *slog.Logger
+	roleService *service.Role
+}
+
+func NewHandler(baseLogger *slog.Logger, roleService *service.Role) *Handler {
+	logger := baseLogger.WithGroup("role handler")
+
+	return &Handler{
+		logger:      logger,
+		roleService: roleService,
+	}
+}
+
+// List возвращает роли, назначенные пользователю
+//
+//	@Summary		Роли пользователя
+//	@Description	Возвращает роли, назначенные через API. Роли из claim roles токена в список не входят. Доступно администраторам.
+//	@Tags			roles
+//	@Produce		json
+//	@Param			user_id	path		string	true	"UUID пользователя"	Format(uuid)
+//	@Success		200		{object}	ListRolesResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/roles [get]
+func (h *Handler) List(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "List"),
+	)
+
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	assignments, err := h.roleService.ListRoles(middleware.RequestContext(c), userID)
+	if !h.handleError(c, logger, err, "failed to list the roles") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToListRolesResponse(assignments))
+}
+
+// Assign назначает роль пользователю
+//
+//	@Summary		Назначить роль
+//	@Description	Назначает роль: user — свои подписки, support — чтение подписок и отчетов всех пользователей,
+//	@Description	admin — все права, включая удаление чужих подписок и управление ролями. Повторное назначение ничего не меняет.
+//	@Tags			roles
+//	@Produce		json
+//	@Param			user_id	path		string	true	"UUID пользователя"	Format(uuid)
+//	@Param			role	path		string	true	"Роль"				Enums(user, support, admin)
+//	@Success		200		{object}	RoleAssignmentResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/roles/{role} [put]
+func (h *Handler) Assign(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Assign"),
+	)
+
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	role, ok := parseRole(c)
+	if !ok {
+		return
+	}
+
+	assignment, err := h.roleService.AssignRole(middleware.RequestContext(c), userID, role)
+	if !h.handleError(c, logger, err, "failed to assign the role") {
+		return
+	}
+
+	c.JSON(http.StatusOK, ToRoleAssignmentResponse(assignment))
+}
+
+// Revoke отзывает роль, назначенную через API
+//
+//	@Summary		Отозвать роль
+//	@Description	Отзывает назначенную роль, действует со следующего запроса пользователя. Роль из токена так не отозвать.
+//	@Tags			roles
+//	@Produce		json
+//	@Param			user_id	path		string	true	"UUID пользователя"	Format(uuid)
+//	@Param			role	path		string	true	"Роль"				Enums(user, support, admin)
+//	@Success		200		{object}	common.SuccessfulResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/roles/{role} [delete]
+func (h *Handler) Revoke(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Revoke"),
+	)
+
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	role, ok := parseRole(c)
+	if !ok {
+		return
+	}
+
+	err := h.roleService.RevokeRole(middleware.RequestContext(c), userID, role)
+	if !h.handleError(c, logger, err, "failed to revoke the role") {
+		return
+	}
+
+	c.JSON(http.StatusOK, common.ToSuccessfulResponse("revoked the role"))
+}
+
+// handleError отвечает на ошибку сервиса и возвращает true, если ошибки не было
+func (h *Handler) handleError(c *gin.Context, logger *slog.Logger, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+	case errors.Is(err, domain.ErrRoleAssignmentNotFound):
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("role assignment not found"))
+	default:
+		logger.Error("Role request failed", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse(message))
+	}
+
+	return false
+}
+
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func parseRole(c *gin.Context) (domain.Role, bool) {
+	role := domain.Role(c.Param("role"))
+	if !domain.IsValidRole(role) {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("role must be one of user, support, admin"))
+		return "", false
+	}
+
+	return role, true
+}
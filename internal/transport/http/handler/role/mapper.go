@@ -0,0 +1,28 @@
+package role
+
+import (
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+func ToRoleAssignmentResponse(assignment *domain.RoleAssignment) *RoleAssignmentResponse {
+	response := &RoleAssignmentResponse{
+		UserID:    assignment.UserUUID.String(),
+		Role:      string(assignment.Role),
+		CreatedAt: assignment.CreatedAt,
+	}
+	if assignment.GrantedBy != nil {
+		grantedBy := assignment.GrantedBy.String()
+		response.GrantedBy = &grantedBy
+	}
+
+	return response
+}
+
+func ToListRolesResponse(assignments []*domain.RoleAssignment) *ListRolesResponse {
+	response := &ListRolesResponse{Roles: make([]*RoleAssignmentResponse, 0, len(assignments))}
+	for _, assignment := range assignments {
+		response.Roles = append(response.Roles, ToRoleAssignmentResponse(assignment))
+	}
+
+	return response
+}
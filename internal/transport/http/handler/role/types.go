@@ -0,0 +1,14 @@
+package role
+
+import "time"
+
+type RoleAssignmentResponse struct {
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role" example:"support"`
+	GrantedBy *string   `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListRolesResponse struct {
+	Roles []*RoleAssignmentResponse `json:"roles"`
+}
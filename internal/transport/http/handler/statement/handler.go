@@ -0,0 +1,269 @@
+package statement
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	logger           *slog.Logger
+	statementService *service.Statement
+}
+
+func NewHandler(baseLogger *slog.Logger, statementService *service.Statement) *Handler {
+	logger := baseLogger.WithGroup("statement handler")
+
+	return &Handler{
+		logger:           logger,
+		statementService: statementService,
+	}
+}
+
+// Upload разбирает банковскую выписку и предлагает найденные в ней подписки
+//
+//	@Summary		Поиск подписок в банковской выписке
+//	@Description	Принимает выписку CSV или OFX в поле file (multipart/form-data) или в теле запроса.
+//	@Description	Списания группируются по получателю и сумме, среди групп ищутся ежемесячные и ежегодные повторения.
+//	@Description	Найденные повторения сохраняются как кандидаты и заменяют неподтвержденных кандидатов из прошлой выписки.
+//	@Description	Получатели, для которых у пользователя уже есть подписка, не предлагаются.
+//	@Description	В CSV нужны колонки даты, описания и суммы (или расхода); формат определяется по имени файла и содержимому.
+//	@Tags			statements
+//	@Accept			mpfd,plain
+//	@Produce		json
+//	@Param			user_id	path		string						true	"UUID пользователя"	Format(uuid)
+//	@Param			file	formData	file						false	"Файл выписки"
+//	@Param			format	query		string						false	"Формат выписки"	Enums(csv, ofx)
+//	@Success		201		{object}	AnalyzeStatementResponse	"Найденные кандидаты"
+//	@Failure		400		{object}	common.ErrorResponse		"Неверный запрос или файл"
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/users/{user_id}/statements [post]
+func (h *Handler) Upload(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Upload"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	var request UploadStatementRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxStatementBytes)
+	var file io.Reader = c.Request.Body
+	var filename string
+	if c.ContentType() == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			logger.Warn("Failed to get the file", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, common.ToErrorResponse("file is required"))
+			return
+		}
+
+		opened, err := fileHeader.Open()
+		if err != nil {
+			logger.Warn("Failed to open the file", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, common.ToErrorResponse("file is not valid"))
+			return
+		}
+		defer opened.Close()
+		file, filename = opened, fileHeader.Filename
+	}
+
+	transactions, err := ParseStatement(file, request.Format, filename)
+	if err != nil {
+		logger.Warn("Failed to parse the statement", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	candidates, err := h.statementService.AnalyzeStatement(middleware.RequestContext(c), userID, transactions)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to another user is forbidden"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to analyze the statement", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to analyze the statement"))
+		return
+	}
+
+	logger.Info("Statement analyzed successfully", slog.Int("candidates", len(candidates)))
+	c.JSON(http.StatusCreated, AnalyzeStatementResponse{
+		Transactions: len(transactions),
+		Candidates:   ToCandidateResponses(candidates),
+	})
+}
+
+// ListCandidates возвращает неподтвержденные подписки, найденные в выписках
+//
+//	@Summary	Кандидаты в подписки
+//	@Tags		statements
+//	@Produce	json
+//	@Param		user_id	path		string	true	"UUID пользователя"	Format(uuid)
+//	@Success	200		{object}	ListCandidatesResponse
+//	@Failure	400		{object}	common.ErrorResponse
+//	@Failure	401		{object}	common.ErrorResponse
+//	@Failure	500		{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/users/{user_id}/subscription-candidates [get]
+func (h *Handler) ListCandidates(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "ListCandidates"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	candidates, err := h.statementService.ListCandidates(middleware.RequestContext(c), userID)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to another user is forbidden"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to list candidates", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to list the candidates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ListCandidatesResponse{Candidates: ToCandidateResponses(candidates)})
+}
+
+// ConfirmCandidates создает подписки по выбранным кандидатам
+//
+//	@Summary		Подтвердить кандидатов
+//	@Description	Создает подписки по выбранным кандидатам. Название, стоимость и даты можно поправить,
+//	@Description	остальные значения берутся из предложения. Если хотя бы один кандидат не найден, ничего не создается.
+//	@Tags			statements
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		string						true	"UUID пользователя"	Format(uuid)
+//	@Param			request	body		ConfirmCandidatesRequest	true	"Выбранные кандидаты"
+//	@Success		201		{object}	ConfirmCandidatesResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/users/{user_id}/subscription-candidates/confirm [post]
+func (h *Handler) ConfirmCandidates(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "ConfirmCandidates"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	var request ConfirmCandidatesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+		return
+	}
+
+	params, err := ToConfirmCandidateParams(&request)
+	if err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	subscriptions, err := h.statementService.ConfirmCandidates(middleware.RequestContext(c), userID, params)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to another user is forbidden"))
+		return
+	}
+	if errors.Is(err, domain.ErrCandidateNotFound) {
+		logger.Warn("Candidate not found", slog.String("error", err.Error()))
+		c.JSON(http.StatusNotFound, common.ToErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to confirm candidates", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to confirm the candidates"))
+		return
+	}
+
+	logger.Info("Candidates confirmed successfully", slog.Int("created", len(subscriptions)))
+	c.JSON(http.StatusCreated, ToConfirmCandidatesResponse(subscriptions))
+}
+
+// DismissCandidate отклоняет кандидата
+//
+//	@Summary	Отклонить кандидата
+//	@Tags		statements
+//	@Produce	json
+//	@Param		user_id			path	string	true	"UUID пользователя"	Format(uuid)
+//	@Param		candidate_id	path	string	true	"UUID кандидата"	Format(uuid)
+//	@Success	204
+//	@Failure	400	{object}	common.ErrorResponse
+//	@Failure	401	{object}	common.ErrorResponse
+//	@Failure	404	{object}	common.ErrorResponse
+//	@Failure	500	{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/users/{user_id}/subscription-candidates/{candidate_id} [delete]
+func (h *Handler) DismissCandidate(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "DismissCandidate"),
+	)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the user id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+	candidateID, err := uuid.Parse(c.Param("candidate_id"))
+	if err != nil {
+		logger.Warn("Failed to parse the candidate id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("candidate_id is not valid"))
+		return
+	}
+
+	err = h.statementService.DismissCandidate(middleware.RequestContext(c), userID, candidateID)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("access to another user is forbidden"))
+		return
+	}
+	if errors.Is(err, domain.ErrCandidateNotFound) {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("candidate not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to dismiss candidate", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to dismiss the candidate"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+
+	"github.com/google/uuid"
+)
+
+func ToCandidateResponse(candidate *domain.SubscriptionCandidate) *CandidateResponse {
+	var endDate *common.MonthYear
+	if candidate.EndDate != nil {
+		monthYear := common.MonthYear(*candidate.EndDate)
+		endDate = &monthYear
+	}
+
+	return &CandidateResponse{
+		ID:             candidate.UUID.String(),
+		ServiceName:    candidate.ServiceName,
+		Merchant:       candidate.Merchant,
+		Price:          candidate.Price,
+		ChargeAmount:   candidate.ChargeAmount,
+		Period:         string(candidate.Period),
+		StartDate:      common.MonthYear(candidate.StartDate),
+		EndDate:        endDate,
+		Occurrences:    candidate.Occurrences,
+		LastChargeDate: candidate.LastChargeDate.Format(time.DateOnly),
+		Confidence:     candidate.Confidence,
+	}
+}
+
+func ToCandidateResponses(candidates []*domain.SubscriptionCandidate) []*CandidateResponse {
+	response := make([]*CandidateResponse, 0, len(candidates))
+	for _, candidate := range candidates {
+		response = append(response, ToCandidateResponse(candidate))
+	}
+
+	return response
+}
+
+func ToConfirmCandidateParams(request *ConfirmCandidatesRequest) ([]*domain.ConfirmCandidateParams, error) {
+	var vErr subscription.ValidationError
+	params := make([]*domain.ConfirmCandidateParams, 0, len(request.Candidates))
+	seen := make(map[uuid.UUID]bool, len(request.Candidates))
+	for _, candidate := range request.Candidates {
+		id, err := uuid.Parse(candidate.ID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			vErr.Add("candidates", "candidate "+candidate.ID+" is duplicated")
+			continue
+		}
+		seen[id] = true
+
+		p := &domain.ConfirmCandidateParams{
+			UUID:        id,
+			ServiceName: candidate.ServiceName,
+			Price:       candidate.Price,
+		}
+		if candidate.StartDate != nil {
+			p.StartDate = candidate.StartDate.Time()
+		}
+		if candidate.EndDate != nil {
+			p.EndDate = candidate.EndDate.Time()
+		}
+		if p.StartDate != nil && p.EndDate != nil && p.EndDate.Before(*p.StartDate) {
+			vErr.Add("end_date", "must not be before start_date")
+		}
+		params = append(params, p)
+	}
+
+	if vErr.HasErrors() {
+		return nil, &vErr
+	}
+
+	return params, nil
+}
+
+func ToConfirmCandidatesResponse(subscriptions []*domain.Subscription) *ConfirmCandidatesResponse {
+	response := &ConfirmCandidatesResponse{
+		Subscriptions: make([]*subscription.GetSubscriptionResponse, 0, len(subscriptions)),
+	}
+	for _, s := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, subscription.ToGetSubscriptionResponse(s))
+	}
+
+	return response
+}
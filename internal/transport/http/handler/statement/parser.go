@@ -0,0 +1,303 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+
+	MaxStatementBytes        = 10 << 20
+	MaxStatementTransactions = 50000
+)
+
+// statementColumns названия колонок, под которыми банки выгружают нужные поля
+var statementColumns = map[string][]string{
+	"date":        {"date", "transaction date", "posted date", "posting date", "booking date", "дата", "дата операции", "дата платежа"},
+	"description": {"description", "merchant", "payee", "name", "details", "memo", "описание", "описание операции", "назначение платежа", "получатель"},
+	"amount":      {"amount", "sum", "value", "сумма", "сумма операции", "сумма платежа"},
+	"debit":       {"debit", "withdrawal", "расход", "списание"},
+}
+
+var statementDateLayouts = []string{
+	"2006-01-02", "02.01.2006", "02/01/2006", "2006/01/02", "02.01.06",
+	"2006-01-02 15:04:05", "2006-01-02T15:04:05", "02.01.2006 15:04:05", "02.01.2006 15:04",
+}
+
+var (
+	ofxTransactionRe = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxFieldRe       = regexp.MustCompile(`(?i)<(DTPOSTED|TRNAMT|NAME|MEMO)>([^<\r\n]*)`)
+)
+
+// DetectFormat определяет формат выписки по имени файла и содержимому
+func DetectFormat(filename string, head []byte) string {
+	lowerName := strings.ToLower(filename)
+	if strings.HasSuffix(lowerName, ".ofx") || strings.HasSuffix(lowerName, ".qfx") {
+		return FormatOFX
+	}
+
+	upperHead := bytes.ToUpper(head)
+	if bytes.Contains(upperHead, []byte("OFXHEADER")) || bytes.Contains(upperHead, []byte("<OFX>")) {
+		return FormatOFX
+	}
+
+	return FormatCSV
+}
+
+// ParseStatement читает выписку в формате CSV или OFX
+func ParseStatement(r io.Reader, format, filename string) ([]domain.BankTransaction, error) {
+	reader := bufio.NewReader(r)
+	if format == "" {
+		head, err := reader.Peek(4096)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		format = DetectFormat(filename, head)
+	}
+
+	var transactions []domain.BankTransaction
+	var err error
+	if format == FormatOFX {
+		transactions, err = parseOFX(reader)
+	} else {
+		transactions, err = parseStatementCSV(reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("statement has no transactions")
+	}
+
+	return transactions, nil
+}
+
+func parseStatementCSV(reader *bufio.Reader) ([]domain.BankTransaction, error) {
+	header, err := reader.Peek(4096)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	if i := bytes.IndexByte(header, '\n'); i >= 0 {
+		header = header[:i]
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = subscription.DetectDelimiter(string(header))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	csvReader.LazyQuotes = true
+
+	record, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := resolveStatementColumns(record)
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("date column is missing")
+	}
+	if _, ok := columns["description"]; !ok {
+		return nil, fmt.Errorf("description column is missing")
+	}
+	_, hasAmount := columns["amount"]
+	_, hasDebit := columns["debit"]
+	if !hasAmount && !hasDebit {
+		return nil, fmt.Errorf("amount column is missing")
+	}
+
+	var transactions []domain.BankTransaction
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		date, err := parseStatementDate(value("date"))
+		if err != nil {
+			// итоговые строки и пустые хвосты в выгрузках банков пропускаем
+			continue
+		}
+
+		var amount int64
+		if hasAmount && value("amount") != "" {
+			amount, err = parseAmount(value("amount"))
+		} else {
+			amount, err = parseAmount(value("debit"))
+			amount = -abs(amount)
+		}
+		if err != nil || amount == 0 {
+			continue
+		}
+
+		if len(transactions) == MaxStatementTransactions {
+			return nil, fmt.Errorf("statement has more than %d transactions", MaxStatementTransactions)
+		}
+		transactions = append(transactions, domain.BankTransaction{
+			Date:        date,
+			Description: value("description"),
+			Amount:      amount,
+		})
+	}
+
+	return transactions, nil
+}
+
+func resolveStatementColumns(header []string) map[string]int {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := positions[name]; !ok {
+			positions[name] = i
+		}
+	}
+
+	columns := make(map[string]int, len(statementColumns))
+	for field, aliases := range statementColumns {
+		for _, alias := range aliases {
+			if i, ok := positions[alias]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	return columns
+}
+
+func parseOFX(r io.Reader) ([]domain.BankTransaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []domain.BankTransaction
+	for _, match := range ofxTransactionRe.FindAllSubmatch(data, -1) {
+		fields := make(map[string]string)
+		for _, field := range ofxFieldRe.FindAllSubmatch(match[1], -1) {
+			fields[strings.ToUpper(string(field[1]))] = html.UnescapeString(strings.TrimSpace(string(field[2])))
+		}
+
+		// DTPOSTED имеет вид YYYYMMDD[HHMMSS[.XXX][TZ]], для подписок достаточно даты
+		posted := fields["DTPOSTED"]
+		if len(posted) < 8 {
+			return nil, fmt.Errorf("transaction %d: DTPOSTED is not valid", len(transactions)+1)
+		}
+		date, err := time.Parse("20060102", posted[:8])
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: DTPOSTED is not valid", len(transactions)+1)
+		}
+
+		amount, err := parseAmount(fields["TRNAMT"])
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: TRNAMT is not valid", len(transactions)+1)
+		}
+
+		description := fields["NAME"]
+		if description == "" {
+			description = fields["MEMO"]
+		}
+
+		if len(transactions) == MaxStatementTransactions {
+			return nil, fmt.Errorf("statement has more than %d transactions", MaxStatementTransactions)
+		}
+		transactions = append(transactions, domain.BankTransaction{Date: date, Description: description, Amount: amount})
+	}
+
+	return transactions, nil
+}
+
+func parseStatementDate(value string) (time.Time, error) {
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("date %q is not valid", value)
+}
+
+// parseAmount разбирает сумму в копейках. Понимает разделители разрядов
+// (пробел, точка, запятая), десятичную запятую, символ валюты и сумму в скобках
+// как отрицательную: "-1 299,00 ₽", "(9.99)", "1,299.00".
+func parseAmount(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-") || strings.HasSuffix(value, "-") ||
+		(strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")"))
+
+	number := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, value)
+	if number == "" {
+		return 0, fmt.Errorf("amount %q is not valid", value)
+	}
+
+	lastDot, lastComma := strings.LastIndexByte(number, '.'), strings.LastIndexByte(number, ',')
+	decimal := max(lastDot, lastComma)
+	if lastDot >= 0 && lastComma < 0 || lastComma >= 0 && lastDot < 0 {
+		// один вид разделителя: десятичный, только если он встречается один раз
+		// и после него не больше двух цифр
+		sep := number[decimal : decimal+1]
+		if strings.Count(number, sep) > 1 || len(number)-decimal-1 > 2 {
+			decimal = -1
+		}
+	}
+
+	integer, fraction := number, ""
+	if decimal >= 0 {
+		integer, fraction = number[:decimal], number[decimal+1:]
+	}
+	integer = strings.NewReplacer(".", "", ",", "").Replace(integer)
+
+	parsed, err := strconv.ParseFloat(integer+"."+fraction+"0", 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not valid", value)
+	}
+
+	amount := int64(math.Round(parsed * 100))
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
@@ -0,0 +1,55 @@
+package statement
+
+import (
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+)
+
+const MaxConfirmCandidates = 100
+
+type UploadStatementRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ofx"`
+}
+
+// CandidateResponse предложенная подписка, найденная в выписке
+type CandidateResponse struct {
+	ID          string `json:"id"`
+	ServiceName string `json:"service_name" example:"Netflix"`
+	Merchant    string `json:"merchant" example:"netflix"`
+	// Price стоимость в месяц
+	Price int `json:"price" example:"999"`
+	// ChargeAmount сумма одного списания
+	ChargeAmount   int               `json:"charge_amount" example:"999"`
+	Period         string            `json:"period" enums:"monthly,yearly"`
+	StartDate      common.MonthYear  `json:"start_date"`
+	EndDate        *common.MonthYear `json:"end_date"`
+	Occurrences    int               `json:"occurrences" example:"6"`
+	LastChargeDate string            `json:"last_charge_date" example:"2025-06-15"`
+	Confidence     float64           `json:"confidence" example:"0.92"`
+}
+
+type AnalyzeStatementResponse struct {
+	Transactions int                  `json:"transactions"`
+	Candidates   []*CandidateResponse `json:"candidates"`
+}
+
+type ListCandidatesResponse struct {
+	Candidates []*CandidateResponse `json:"candidates"`
+}
+
+// ConfirmCandidateRequest подтверждение кандидата. Незаполненные поля берутся из предложения.
+type ConfirmCandidateRequest struct {
+	ID          string            `json:"id" binding:"required,uuid"`
+	ServiceName *string           `json:"service_name,omitempty" binding:"omitempty,min=1,max=64"`
+	Price       *int              `json:"price,omitempty" binding:"omitempty,gte=0"`
+	StartDate   *common.MonthYear `json:"start_date,omitempty" example:"01-2025"`
+	EndDate     *common.MonthYear `json:"end_date,omitempty" example:"12-2025"`
+}
+
+type ConfirmCandidatesRequest struct {
+	Candidates []*ConfirmCandidateRequest `json:"candidates" binding:"required,min=1,max=100,dive"`
+}
+
+type ConfirmCandidatesResponse struct {
+	Subscriptions []*subscription.GetSubscriptionResponse `json:"subscriptions"`
+}
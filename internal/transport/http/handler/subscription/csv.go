@@ -0,0 +1,275 @@
+package subscription
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	MaxImportRows  = 10000
+	MaxImportBytes = 10 << 20
+)
+
+// importFields поля подписки, которые можно загрузить из CSV
+var importFields = []string{"service_name", "price", "user_id", "start_date", "end_date", "notes"}
+
+var importDateLayouts = []string{"01-2006", "2006-01-02", "2006-01", "01/2006"}
+
+// CSVImportOptions настройки разбора файла
+type CSVImportOptions struct {
+	// Delimiter разделитель колонок, 0 — определить по заголовку
+	Delimiter rune
+	// Mapping сопоставляет поле подписки с названием колонки в файле.
+	// Поля без сопоставления ищутся по собственному имени без учета регистра.
+	Mapping map[string]string
+	// DefaultUserID подставляется в строки без колонки user_id
+	DefaultUserID string
+}
+
+// ImportRow строка файла после разбора. Params заполнен только для валидных строк.
+type ImportRow struct {
+	Line   int
+	Params *domain.CreateSubscriptionParams
+	Err    error
+}
+
+// ParseDelimiter разбирает параметр delimiter: один символ или "tab"
+func ParseDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("delimiter must be a single character")
+	}
+
+	return runes[0], nil
+}
+
+// DetectDelimiter выбирает разделитель, который чаще всего встречается в заголовке
+func DetectDelimiter(header string) rune {
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if count := strings.Count(header, string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+
+	return best
+}
+
+// ParseImportCSV читает CSV и валидирует каждую строку по тем же правилам,
+// что и запрос на создание подписки. Возвращает использованный разделитель.
+func ParseImportCSV(r io.Reader, options CSVImportOptions) ([]*ImportRow, rune, error) {
+	reader := bufio.NewReader(r)
+
+	delimiter := options.Delimiter
+	if delimiter == 0 {
+		header, err := reader.Peek(4096)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, 0, err
+		}
+		if i := bytes.IndexByte(header, '\n'); i >= 0 {
+			header = header[:i]
+		}
+		delimiter = DetectDelimiter(string(header))
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, delimiter, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, delimiter, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns, err := resolveImportColumns(header, options)
+	if err != nil {
+		return nil, delimiter, err
+	}
+
+	var rows []*ImportRow
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, delimiter, err
+			}
+			rows = append(rows, &ImportRow{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		line, _ := csvReader.FieldPos(0)
+		if len(rows) == MaxImportRows {
+			return nil, delimiter, fmt.Errorf("file has more than %d rows", MaxImportRows)
+		}
+
+		params, err := toImportParams(record, columns, options)
+		rows = append(rows, &ImportRow{Line: line, Params: params, Err: err})
+	}
+
+	return rows, delimiter, nil
+}
+
+func resolveImportColumns(header []string, options CSVImportOptions) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := positions[name]; !ok {
+			positions[name] = i
+		}
+	}
+
+	columns := make(map[string]int, len(importFields))
+	for _, field := range importFields {
+		name, mapped := options.Mapping[field]
+		if !mapped {
+			name = field
+		}
+
+		if i, ok := positions[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("column %q mapped to %s is not in the header", name, field)
+		}
+	}
+
+	for field := range options.Mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("unknown field %q in mapping", field)
+		}
+	}
+
+	for _, field := range []string{"service_name", "price", "start_date"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("required column %s is missing", field)
+		}
+	}
+	if _, ok := columns["user_id"]; !ok && options.DefaultUserID == "" {
+		return nil, fmt.Errorf("required column user_id is missing, pass user_id to use one user for all rows")
+	}
+
+	return columns, nil
+}
+
+func toImportParams(record []string, columns map[string]int, options CSVImportOptions) (*domain.CreateSubscriptionParams, error) {
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var vErr ValidationError
+	request := CreateSubscriptionRequest{
+		ServiceName: value("service_name"),
+		UserID:      value("user_id"),
+		Notes:       value("notes"),
+	}
+	if request.UserID == "" {
+		request.UserID = options.DefaultUserID
+	}
+
+	if price := value("price"); price != "" {
+		parsed, err := strconv.Atoi(price)
+		if err != nil {
+			vErr.Add("price", "must be an integer")
+		}
+		request.Price = parsed
+	}
+
+	if startDate := value("start_date"); startDate != "" {
+		parsed, err := parseImportDate(startDate)
+		if err != nil {
+			vErr.Add("start_date", err.Error())
+		}
+		request.StartDate = common.MonthYear(parsed)
+	}
+
+	if endDate := value("end_date"); endDate != "" {
+		parsed, err := parseImportDate(endDate)
+		if err != nil {
+			vErr.Add("end_date", err.Error())
+		}
+		monthYear := common.MonthYear(parsed)
+		request.EndDate = &monthYear
+	}
+
+	validationErrors := []error{
+		FromBindingErrors(binding.Validator.ValidateStruct(&request)),
+		ValidateCreateSubscriptionRequest(request),
+	}
+	for _, err := range validationErrors {
+		var fieldErrors *ValidationError
+		if !errors.As(err, &fieldErrors) {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for field, message := range fieldErrors.Errors {
+			if _, ok := vErr.Errors[field]; !ok {
+				vErr.Add(field, message)
+			}
+		}
+	}
+
+	if vErr.HasErrors() {
+		return nil, &vErr
+	}
+
+	return ToCreateSubscriptionParams(&request)
+}
+
+// parseImportDate принимает MM-YYYY и ISO-даты; день отбрасывается, так как
+// подписки учитываются помесячно
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("must be a date in MM-YYYY or YYYY-MM-DD format")
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
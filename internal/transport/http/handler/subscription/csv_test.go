@@ -0,0 +1,258 @@
+package subscription
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const testUserID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    rune
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "tab", want: '\t'},
+		{value: `\t`, want: '\t'},
+		{value: ";", want: ';'},
+		{value: "|", want: '|'},
+		{value: ";;", wantErr: true},
+		{value: `"`, wantErr: true},
+		{value: "\n", wantErr: true},
+		{value: "\r", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDelimiter(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDelimiter(%q) error = nil, want error", tt.value)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDelimiter(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   rune
+	}{
+		{header: "service_name,price,user_id,start_date", want: ','},
+		{header: "service_name;price;user_id;start_date", want: ';'},
+		{header: "service_name\tprice\tuser_id", want: '\t'},
+		{header: "service_name|price|user_id", want: '|'},
+		{header: "service_name;price,with,commas", want: ','},
+		{header: "service_name", want: ','},
+	}
+
+	for _, tt := range tests {
+		if got := DetectDelimiter(tt.header); got != tt.want {
+			t.Errorf("DetectDelimiter(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseImportCSV(t *testing.T) {
+	// row описывает ожидаемую строку: номер строки в файле и поле с ошибкой, если строка невалидна
+	type row struct {
+		line     int
+		errField string
+	}
+
+	tests := []struct {
+		name          string
+		input         string
+		options       CSVImportOptions
+		wantDelimiter rune
+		wantRows      []row
+		wantErr       string
+	}{
+		{
+			name: "comma separated",
+			input: "service_name,price,user_id,start_date,end_date\n" +
+				"Netflix,999," + testUserID + ",07-2025,12-2025\n",
+			wantDelimiter: ',',
+			wantRows:      []row{{line: 2}},
+		},
+		{
+			name: "semicolon detected and header with bom",
+			input: "\ufeffService_Name;PRICE;user_id;start_date\n" +
+				"Netflix;999;" + testUserID + ";2025-07-15\n",
+			wantDelimiter: ';',
+			wantRows:      []row{{line: 2}},
+		},
+		{
+			name:          "explicit delimiter",
+			input:         "service_name|price|user_id|start_date\nNetflix|999|" + testUserID + "|2025-07\n",
+			options:       CSVImportOptions{Delimiter: '|'},
+			wantDelimiter: '|',
+			wantRows:      []row{{line: 2}},
+		},
+		{
+			name:  "mapping and default user",
+			input: "Сервис,Стоимость,Начало\nNetflix,999,07/2025\n",
+			options: CSVImportOptions{
+				Mapping:       map[string]string{"service_name": "сервис", "price": "Стоимость", "start_date": "Начало"},
+				DefaultUserID: testUserID,
+			},
+			wantDelimiter: ',',
+			wantRows:      []row{{line: 2}},
+		},
+		{
+			name: "invalid rows keep their line numbers",
+			input: "service_name,price,user_id,start_date\n" +
+				"Netflix,cheap," + testUserID + ",07-2025\n" +
+				"\n" +
+				" , , , \n" +
+				"Spotify,299,not-a-uuid,07-2025\n" +
+				"Yandex Plus,199," + testUserID + ",July\n" +
+				",199," + testUserID + ",07-2025\n",
+			wantDelimiter: ',',
+			wantRows: []row{
+				{line: 2, errField: "price"},
+				{line: 5, errField: "user_id"},
+				{line: 6, errField: "start_date"},
+				{line: 7, errField: "service_name"},
+			},
+		},
+		{
+			name:          "header only",
+			input:         "service_name,price,user_id,start_date\n",
+			wantDelimiter: ',',
+		},
+		{name: "empty file", input: "", wantErr: "file is empty"},
+		{
+			name:    "required column missing",
+			input:   "service_name,user_id,start_date\nNetflix," + testUserID + ",07-2025\n",
+			wantErr: "required column price is missing",
+		},
+		{
+			name:    "user column missing without default",
+			input:   "service_name,price,start_date\nNetflix,999,07-2025\n",
+			wantErr: "required column user_id is missing",
+		},
+		{
+			name:    "mapped column not in header",
+			input:   "service_name,price,user_id,start_date\n",
+			options: CSVImportOptions{Mapping: map[string]string{"price": "cost"}},
+			wantErr: `column "cost" mapped to price is not in the header`,
+		},
+		{
+			name:    "unknown mapping field",
+			input:   "service_name,price,user_id,start_date\n",
+			options: CSVImportOptions{Mapping: map[string]string{"discount": "price"}},
+			wantErr: `unknown field "discount" in mapping`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, delimiter, err := ParseImportCSV(strings.NewReader(tt.input), tt.options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseImportCSV() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseImportCSV() error = %v", err)
+			}
+			if delimiter != tt.wantDelimiter {
+				t.Errorf("delimiter = %q, want %q", delimiter, tt.wantDelimiter)
+			}
+			if len(rows) != len(tt.wantRows) {
+				t.Fatalf("len(rows) = %d, want %d", len(rows), len(tt.wantRows))
+			}
+
+			for i, want := range tt.wantRows {
+				got := rows[i]
+				if got.Line != want.line {
+					t.Errorf("rows[%d].Line = %d, want %d", i, got.Line, want.line)
+				}
+				if want.errField == "" {
+					if got.Err != nil || got.Params == nil {
+						t.Errorf("rows[%d] = %+v, want valid row", i, got)
+					}
+					continue
+				}
+
+				var vErr *ValidationError
+				if !errors.As(got.Err, &vErr) {
+					t.Fatalf("rows[%d].Err = %v, want ValidationError", i, got.Err)
+				}
+				if _, ok := vErr.Errors[want.errField]; !ok {
+					t.Errorf("rows[%d].Err = %v, want error for %s", i, vErr, want.errField)
+				}
+			}
+		})
+	}
+}
+
+func TestParseImportCSVParams(t *testing.T) {
+	input := "service_name,price,start_date,end_date,notes\n" +
+		" Netflix ,999,2025-07-15,2025-12-31,семейный тариф\n"
+
+	rows, _, err := ParseImportCSV(strings.NewReader(input), CSVImportOptions{DefaultUserID: testUserID})
+	if err != nil {
+		t.Fatalf("ParseImportCSV() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Err != nil {
+		t.Fatalf("rows = %+v, want one valid row", rows)
+	}
+
+	params := rows[0].Params
+	if params.ServiceName != "Netflix" || params.Price != 999 || params.Notes != "семейный тариф" {
+		t.Errorf("params = %+v", params)
+	}
+	if params.UserUUID != uuid.MustParse(testUserID) {
+		t.Errorf("UserUUID = %s, want %s", params.UserUUID, testUserID)
+	}
+	if want := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC); !params.StartDate.Equal(want) {
+		t.Errorf("StartDate = %s, want %s", params.StartDate, want)
+	}
+	if want := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC); params.EndDate == nil || !params.EndDate.Equal(want) {
+		t.Errorf("EndDate = %v, want %s", params.EndDate, want)
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	july := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "07-2025", want: july},
+		{value: "2025-07-01", want: july},
+		{value: "2025-07-31", want: july},
+		{value: "2025-07", want: july},
+		{value: "07/2025", want: july},
+		{value: "13-2025", wantErr: true},
+		{value: "31.07.2025", wantErr: true},
+		{value: "July 2025", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseImportDate(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseImportDate(%q) = %s, want error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseImportDate(%q) = %s, %v, want %s", tt.value, got, err, tt.want)
+		}
+	}
+}
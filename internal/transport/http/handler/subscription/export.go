@@ -0,0 +1,201 @@
+package subscription
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+)
+
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatXLSX  = "xlsx"
+
+	DateFormatMonthYear = "month_year"
+	DateFormatISO       = "iso"
+
+	// exportFlushEvery через сколько строк отправлять данные клиенту
+	exportFlushEvery = 200
+)
+
+var exportColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "notes", "created_at", "updated_at"}
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:   "text/csv; charset=utf-8",
+	ExportFormatJSONL: "application/x-ndjson",
+	ExportFormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportWriter записывает подписки в выбранном формате по одной
+type ExportWriter interface {
+	Write(subscription *domain.Subscription) error
+	Flush() error
+	Close() error
+}
+
+func NewExportWriter(w io.Writer, format, dateFormat string) (ExportWriter, error) {
+	layout := "01-2006"
+	if dateFormat == DateFormatISO {
+		layout = time.DateOnly
+	}
+
+	switch format {
+	case ExportFormatJSONL:
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+		encoder.SetEscapeHTML(false)
+		return &jsonlExportWriter{buffered: buffered, encoder: encoder, layout: layout}, nil
+	case ExportFormatXLSX:
+		xlsx, err := common.NewXLSXWriter(w, "subscriptions")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExportWriter{xlsx: xlsx, layout: layout}, xlsx.WriteRow(toCells(exportColumns)...)
+	default:
+		writer := csv.NewWriter(w)
+		return &csvExportWriter{csv: writer, layout: layout}, writer.Write(exportColumns)
+	}
+}
+
+func ExportContentType(format string) string {
+	return exportContentTypes[format]
+}
+
+func exportRecord(subscription *domain.Subscription, layout string) []string {
+	endDate := ""
+	if subscription.EndDate != nil {
+		endDate = subscription.EndDate.Format(layout)
+	}
+
+	return []string{
+		subscription.UUID.String(),
+		subscription.ServiceName,
+		strconv.Itoa(subscription.Price),
+		subscription.UserUUID.String(),
+		subscription.StartDate.Format(layout),
+		endDate,
+		subscription.Notes,
+		subscription.CreatedAt.Format(time.RFC3339),
+		subscription.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type csvExportWriter struct {
+	csv    *csv.Writer
+	layout string
+}
+
+func (w *csvExportWriter) Write(subscription *domain.Subscription) error {
+	record := exportRecord(subscription, w.layout)
+	for i, value := range record {
+		record[i] = escapeFormula(value)
+	}
+
+	return w.csv.Write(record)
+}
+
+func (w *csvExportWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvExportWriter) Close() error {
+	return w.Flush()
+}
+
+type jsonlExportRecord struct {
+	ID          string  `json:"id"`
+	ServiceName string  `json:"service_name"`
+	Price       int     `json:"price"`
+	UserID      string  `json:"user_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     *string `json:"end_date"`
+	Notes       string  `json:"notes"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type jsonlExportWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+	layout   string
+}
+
+func (w *jsonlExportWriter) Write(subscription *domain.Subscription) error {
+	record := exportRecord(subscription, w.layout)
+
+	var endDate *string
+	if subscription.EndDate != nil {
+		endDate = &record[5]
+	}
+
+	return w.encoder.Encode(&jsonlExportRecord{
+		ID:          record[0],
+		ServiceName: subscription.ServiceName,
+		Price:       subscription.Price,
+		UserID:      record[3],
+		StartDate:   record[4],
+		EndDate:     endDate,
+		Notes:       subscription.Notes,
+		CreatedAt:   record[7],
+		UpdatedAt:   record[8],
+	})
+}
+
+func (w *jsonlExportWriter) Flush() error {
+	return w.buffered.Flush()
+}
+
+func (w *jsonlExportWriter) Close() error {
+	return w.Flush()
+}
+
+type xlsxExportWriter struct {
+	xlsx   *common.XLSXWriter
+	layout string
+}
+
+func (w *xlsxExportWriter) Write(subscription *domain.Subscription) error {
+	cells := toCells(exportRecord(subscription, w.layout))
+	cells[2] = subscription.Price
+	if subscription.EndDate == nil {
+		cells[5] = nil
+	}
+
+	return w.xlsx.WriteRow(cells...)
+}
+
+func (w *xlsxExportWriter) Flush() error {
+	return w.xlsx.Flush()
+}
+
+func (w *xlsxExportWriter) Close() error {
+	return w.xlsx.Close()
+}
+
+// escapeFormula не дает табличному редактору выполнить значение ячейки CSV как формулу:
+// значение, которое начинается с =, +, -, @, табуляции или CR, предваряется апострофом.
+// В XLSX строки записываются inline-ячейками, которые как формулы не вычисляются.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func toCells(values []string) []any {
+	cells := make([]any, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+
+	return cells
+}
@@ -0,0 +1,186 @@
+package subscription
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func exportSubscriptions() []*domain.Subscription {
+	endDate := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, time.June, 15, 10, 30, 0, 0, time.UTC)
+
+	return []*domain.Subscription{
+		{
+			UUID:        uuid.MustParse("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"),
+			ServiceName: "Netflix",
+			Price:       999,
+			UserUUID:    uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:     &endDate,
+			Notes:       "семейный тариф",
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		},
+		{
+			UUID:        uuid.MustParse("1b4e28ba-2fa1-11d2-883f-0016d3cca427"),
+			ServiceName: "=HYPERLINK(\"http://evil\")",
+			Price:       0,
+			UserUUID:    uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			StartDate:   time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Notes:       "<b>&</b>",
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		},
+	}
+}
+
+func writeExport(t *testing.T, format, dateFormat string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer, err := NewExportWriter(&buf, format, dateFormat)
+	if err != nil {
+		t.Fatalf("NewExportWriter() error = %v", err)
+	}
+	for _, subscription := range exportSubscriptions() {
+		if err := writer.Write(subscription); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Netflix", want: "Netflix"},
+		{in: "", want: ""},
+		{in: "=SUM(A1:A2)", want: "'=SUM(A1:A2)"},
+		{in: "+1", want: "'+1"},
+		{in: "-1", want: "'-1"},
+		{in: "@cmd", want: "'@cmd"},
+		{in: "\tvalue", want: "'\tvalue"},
+		{in: "\rvalue", want: "'\rvalue"},
+		{in: "a=b", want: "a=b"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeFormula(tt.in); got != tt.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVExportWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeExport(t, ExportFormatCSV, DateFormatMonthYear))).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV is not valid: %v", err)
+	}
+
+	want := [][]string{
+		exportColumns,
+		{
+			"f81d4fae-7dec-11d0-a765-00a0c91e6bf6", "Netflix", "999", "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+			"07-2025", "12-2025", "семейный тариф", "2025-06-15T10:30:00Z", "2025-06-15T10:30:00Z",
+		},
+		{
+			"1b4e28ba-2fa1-11d2-883f-0016d3cca427", "'=HYPERLINK(\"http://evil\")", "0", "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+			"01-2025", "", "<b>&</b>", "2025-06-15T10:30:00Z", "2025-06-15T10:30:00Z",
+		},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV records = %q, want %q", records, want)
+	}
+}
+
+func TestJSONLExportWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(string(writeExport(t, ExportFormatJSONL, DateFormatISO)), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	var first, second jsonlExportRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("line 1 is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("line 2 is not valid JSON: %v", err)
+	}
+
+	if first.StartDate != "2025-07-01" || first.EndDate == nil || *first.EndDate != "2025-12-01" || first.Price != 999 {
+		t.Errorf("line 1 = %+v, want ISO dates and price 999", first)
+	}
+	if second.EndDate != nil {
+		t.Errorf("line 2 end_date = %q, want null", *second.EndDate)
+	}
+	if second.ServiceName != "=HYPERLINK(\"http://evil\")" {
+		t.Errorf("line 2 service_name = %q, want value without CSV escaping", second.ServiceName)
+	}
+	if !strings.Contains(lines[1], `"notes":"<b>&</b>"`) {
+		t.Errorf("line 2 = %s, want HTML characters unescaped", lines[1])
+	}
+}
+
+func TestXLSXExportWriter(t *testing.T) {
+	data := writeExport(t, ExportFormatXLSX, DateFormatMonthYear)
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("exported XLSX is not a zip archive: %v", err)
+	}
+
+	var sheet string
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open sheet: %v", err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read sheet: %v", err)
+		}
+		sheet = string(content)
+	}
+	if sheet == "" {
+		t.Fatal("sheet1.xml not found")
+	}
+
+	for _, want := range []string{
+		`<t xml:space="preserve">service_name</t>`,
+		`<c><v>999</v></c>`,
+		`<t xml:space="preserve">=HYPERLINK(&#34;http://evil&#34;)</t>`,
+		`<t xml:space="preserve">&lt;b&gt;&amp;&lt;/b&gt;</t>`,
+		`<c/>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet does not contain %s", want)
+		}
+	}
+	if strings.Contains(sheet, "<f>") {
+		t.Error("sheet contains a formula cell")
+	}
+	if got := strings.Count(sheet, "<row>"); got != 3 {
+		t.Errorf("sheet has %d rows, want 3", got)
+	}
+}
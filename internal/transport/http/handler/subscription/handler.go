@@ -1,10 +1,15 @@
 package subscription
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"time"
 
+	"github.com/ent1k1377/subscriptions/internal/domain"
 	"github.com/ent1k1377/subscriptions/internal/service"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
@@ -34,13 +39,16 @@ func NewHandler(baseLogger *slog.Logger, subscriptionService *service.Subscripti
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		CreateSubscriptionRequest	true	"Данные подписки"
-//	@Success		201		{object}	common.SuccessfulResponse	"Successfully created"
+//	@Success		201		{object}	GetSubscriptionResponse		"Successfully created"
+//	@Header			201		{string}	Location					"URL созданной подписки"
 //	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
 //	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/subscriptions [post]
 func (h *Handler) Create(c *gin.Context) {
-	logger := h.logger.With(
-		slog.String("request_id", c.MustGet(middleware.RequestIDKey).(string)),
+	logger := middleware.Logger(c, h.logger).With(
 		slog.String("func", "Create"),
 	)
 
@@ -65,8 +73,12 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	ct := context.WithValue(c.Request.Context(), middleware.RequestIDKey, c.MustGet(middleware.RequestIDKey).(string))
-	err = h.subscriptionService.CreateSubscription(ct, params)
+	subscription, err := h.subscriptionService.CreateSubscription(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Creating subscription for another user", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("cannot create subscriptions for another user"))
+		return
+	}
 	if err != nil {
 		logger.Warn("Failed to create subscription", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to create the subscription"))
@@ -74,23 +86,30 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	logger.Info("Create subscription successfully")
-	c.JSON(http.StatusCreated, common.ToSuccessfulResponse("created the subscription"))
+	c.Header("Location", SubscriptionLocation(subscription.UUID))
+	c.JSON(http.StatusCreated, ToGetSubscriptionResponse(subscription))
 }
 
 // GetSubscription возвращает подписку по UUID пользователя
 //
 //	@Summary		Получить подписку
-//	@Description	Возвращает информацию о подписке по UUID
+//	@Description	Возвращает информацию о подписке по UUID. С as_of возвращает подписку в состоянии на этот момент,
+//	@Description	в том числе удаленную позже; если в тот момент подписки не было, отвечает 404.
 //	@Tags			subscriptions
 //	@Accept			json
 //	@Produce		json
-//	@Param			uuid	path		string	true	"UUID подписки"	Format(uuid)	Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
+//	@Param			uuid	path		string	true	"UUID подписки"				Format(uuid)		Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
+//	@Param			as_of	query		string	false	"Момент времени (RFC 3339)"	Format(date-time)	Example(2026-03-01T00:00:00Z)
 //	@Success		200		{object}	GetSubscriptionResponse
 //	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
 //	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/subscriptions/{uuid} [get]
 func (h *Handler) GetSubscription(c *gin.Context) {
-	logger := h.logger.With(
+	logger := middleware.Logger(c, h.logger).With(
 		slog.String("func", "GetSubscription"),
 	)
 
@@ -103,7 +122,19 @@ func (h *Handler) GetSubscription(c *gin.Context) {
 		return
 	}
 
-	subscription, err := h.subscriptionService.GetSubscription(uuidParse)
+	var request GetSubscriptionRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	subscription, err := h.subscriptionService.GetSubscription(middleware.RequestContext(c), uuidParse, request.AsOf)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("subscription not found"))
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to get subscription", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to get the subscription"))
@@ -124,12 +155,17 @@ func (h *Handler) GetSubscription(c *gin.Context) {
 //	@Produce		json
 //	@Param			uuid	path		string						true	"UUID подписки"	Format(uuid)	Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
 //	@Param			request	body		UpdateSubscriptionRequest	true	"Данные для обновления подписки"
-//	@Success		200		{object}	common.SuccessfulResponse
+//	@Success		200		{object}	GetSubscriptionResponse
 //	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
 //	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/subscriptions/{uuid} [put]
 func (h *Handler) UpdateSubscription(c *gin.Context) {
-	logger := h.logger.With(
+	logger := middleware.Logger(c, h.logger).With(
 		slog.String("func", "UpdateSubscription"),
 	)
 
@@ -148,8 +184,24 @@ func (h *Handler) UpdateSubscription(c *gin.Context) {
 		return
 	}
 
+	if err := ValidateUpdateSubscriptionRequest(request); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
 	params := ToUpdateSubscriptionParams(&request)
-	err = h.subscriptionService.UpdateSubscription(uuidParse, params)
+	subscription, err := h.subscriptionService.UpdateSubscription(middleware.RequestContext(c), uuidParse, params)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("subscription not found"))
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Updating subscription is forbidden", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("not allowed to update the subscription"))
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to update subscription", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to update the subscription"))
@@ -157,7 +209,7 @@ func (h *Handler) UpdateSubscription(c *gin.Context) {
 	}
 
 	logger.Info("Update subscription successfully")
-	c.JSON(http.StatusOK, common.ToSuccessfulResponse("updated the subscription"))
+	c.JSON(http.StatusOK, ToGetSubscriptionResponse(subscription))
 }
 
 // DeleteSubscription удаляет подписку по UUID
@@ -170,10 +222,15 @@ func (h *Handler) UpdateSubscription(c *gin.Context) {
 //	@Param			uuid	path		string						true	"UUID подписки"	Format(uuid)	Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
 //	@Success		200		{object}	common.SuccessfulResponse	"Успешное удаление"
 //	@Failure		400		{object}	common.ErrorResponse		"Неверный UUID"
-//	@Failure		500		{object}	common.ErrorResponse		"Ошибка сервера"
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse	"Нет права на удаление"
+//	@Failure		404		{object}	common.ErrorResponse	"Подписка не найдена"
+//	@Failure		500		{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/subscriptions/{uuid} [delete]
 func (h *Handler) DeleteSubscription(c *gin.Context) {
-	logger := h.logger.With(
+	logger := middleware.Logger(c, h.logger).With(
 		slog.String("func", "DeleteSubscription"),
 	)
 
@@ -185,7 +242,17 @@ func (h *Handler) DeleteSubscription(c *gin.Context) {
 		return
 	}
 
-	err = h.subscriptionService.DeleteSubscription(uuidParse)
+	err = h.subscriptionService.DeleteSubscription(middleware.RequestContext(c), uuidParse)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("subscription not found"))
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Deleting subscription is forbidden", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("not allowed to delete the subscription"))
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to delete subscription", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to delete the subscription"))
@@ -196,23 +263,98 @@ func (h *Handler) DeleteSubscription(c *gin.Context) {
 	c.JSON(http.StatusOK, common.ToSuccessfulResponse("deleted the subscription"))
 }
 
-// ListSubscriptions возвращает список подписок с возможностью фильтрации и пагинации
+// RestoreSubscription восстанавливает удаленную подписку
 //
-//	@Summary		Список подписок
-//	@Description	Возвращает список подписок с фильтрацией и пагинацией (через query-параметры)
+//	@Summary		Восстановить подписку
+//	@Description	Восстанавливает удаленную подписку с прежним UUID в последнем состоянии перед удалением.
+//	@Description	Восстановление записывается в журнал действием restore, получатели событий получают subscription.created.
 //	@Tags			subscriptions
 //	@Accept			json
 //	@Produce		json
-//	@Param			user_id	query		string					false	"Фильтр по UUID пользователя"		Format(uuid)	Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
-//	@Param			service	query		string					false	"Фильтр по названию сервиса"		Example(Netflix)
-//	@Param			limit	query		int						false	"Количество записей на странице"	minimum(1)	maximum(100)	Example(10)
-//	@Param			offset	query		int						false	"Смещение для пагинации"			minimum(0)	Example(0)
-//	@Success		200		{array}		GetSubscriptionResponse	"Список подписок"
-//	@Failure		400		{object}	common.ErrorResponse	"Неверный запрос"
+//	@Param			uuid	path		string					true	"UUID подписки"	Format(uuid)	Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
+//	@Success		200		{object}	GetSubscriptionResponse	"Восстановленная подписка"
+//	@Failure		400		{object}	common.ErrorResponse	"Неверный UUID"
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		403		{object}	common.ErrorResponse	"Нет права на изменение"
+//	@Failure		404		{object}	common.ErrorResponse	"Подписка не найдена"
+//	@Failure		409		{object}	common.ErrorResponse	"Подписка не удалена"
 //	@Failure		500		{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/{uuid}/restore [post]
+func (h *Handler) RestoreSubscription(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "RestoreSubscription"),
+	)
+
+	uuidParam := c.Param("uuid")
+	uuidParse, err := uuid.Parse(uuidParam)
+	if err != nil {
+		logger.Warn("Failed to parse the uuid", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("uuid is not valid"))
+		return
+	}
+
+	subscription, err := h.subscriptionService.RestoreSubscription(middleware.RequestContext(c), uuidParse)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		logger.Warn("Subscription not found", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("subscription not found"))
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Restoring subscription is forbidden", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("not allowed to restore the subscription"))
+		return
+	}
+	if errors.Is(err, domain.ErrSubscriptionNotDeleted) {
+		logger.Warn("Subscription is not deleted", slog.String("uuid", uuidParse.String()))
+		c.JSON(http.StatusConflict, common.ToErrorResponse("subscription is not deleted"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to restore subscription", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to restore the subscription"))
+		return
+	}
+
+	logger.Info("Restore subscription successfully")
+	c.JSON(http.StatusOK, ToGetSubscriptionResponse(subscription))
+}
+
+// ListSubscriptions возвращает список подписок с возможностью фильтрации, сортировки и пагинации
+//
+//	@Summary		Список подписок
+//	@Description	Возвращает список подписок с фильтрацией и keyset-пагинацией (через query-параметры).
+//	@Description	Для перехода между страницами передайте в cursor значение next_cursor или prev_cursor из предыдущего ответа.
+//	@Description
+//	@Description	Грамматика фильтров: все заданные параметры объединяются через AND, границы диапазонов включительные.
+//	@Description	- service_name: список названий через запятую или повторяющийся параметр, совпадение без учета регистра, символы % и _ сравниваются буквально;
+//	@Description	- price_min / price_max: целые неотрицательные числа;
+//	@Description	- start_from / start_to, end_from / end_to: даты в формате MM-YYYY;
+//	@Description	- active_at (MM-YYYY): подписки, действующие на указанный месяц;
+//	@Description	- open_ended: true — только бессрочные подписки, false — только с датой окончания;
+//	@Description	- created_since / updated_since: метка времени в формате RFC 3339.
+//	@Description
+//	@Description	as_of (RFC 3339) возвращает подписки в состоянии на этот момент: фильтры применяются к тогдашним значениям.
+//	@Tags			subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id			query		string						false	"Фильтр по UUID пользователя"						Format(uuid)	Example(f81d4fae-7dec-11d0-a765-00a0c91e6bf6)
+//	@Param			service_name	query		string						false	"Фильтр по названию сервиса"						Example(Netflix)
+//	@Param			limit			query		int							false	"Количество записей на странице (по умолчанию 20)"	minimum(1)	maximum(100)	Example(10)
+//	@Param			cursor			query		string						false	"Курсор страницы (next_cursor или prev_cursor)"
+//	@Param			sort			query		string						false	"Сортировка: service_name, price, start_date, created_at"	Example(price,-start_date)
+//	@Param			total_count		query		bool						false	"Вернуть общее количество записей"
+//	@Param			as_of			query		string						false	"Момент времени (RFC 3339)"	Format(date-time)	Example(2026-03-01T00:00:00Z)
+//	@Success		200				{object}	ListSubscriptionResponse	"Список подписок"
+//	@Failure		400				{object}	common.ErrorResponse		"Неверный запрос"
+//	@Failure		401				{object}	common.ErrorResponse
+//	@Failure		500				{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/subscriptions/list [get]
 func (h *Handler) ListSubscriptions(c *gin.Context) {
-	logger := h.logger.With(
+	logger := middleware.Logger(c, h.logger).With(
 		slog.String("func", "ListSubscriptions"),
 	)
 
@@ -223,37 +365,227 @@ func (h *Handler) ListSubscriptions(c *gin.Context) {
 		return
 	}
 
+	if err := ValidateSubscriptionFilterRequest(&request.SubscriptionFilterRequest); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
 	params, err := ToListSubscriptionParams(&request)
 	if err != nil {
+		logger.Warn("Failed to convert the request to list params", slog.String("error", err.Error()))
 		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query is not valid"))
 		return
 	}
 
-	subscription, err := h.subscriptionService.ListSubscriptions(params)
+	page, err := h.subscriptionService.ListSubscriptions(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Listing another user's subscriptions", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("cannot list subscriptions of another user"))
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidSort) || errors.Is(err, domain.ErrInvalidCursor) {
+		logger.Warn("Invalid list parameters", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to list subscriptions", slog.String("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to list the subscription "+err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to list the subscriptions"))
 		return
 	}
 
 	logger.Info("List subscriptions successfully")
-	c.JSON(200, ToListSubscriptionResponse(subscription))
+	c.JSON(http.StatusOK, ToListSubscriptionResponse(page))
+}
+
+// Export выгружает подписки в файл
+//
+//	@Summary		Экспорт подписок
+//	@Description	Потоково выгружает подписки в CSV, JSON Lines или XLSX. Поддерживает те же фильтры, что и /subscriptions/list.
+//	@Description	Строки читаются из базы курсором и сразу отправляются клиенту, поэтому размер выгрузки не ограничен.
+//	@Description	В CSV значения, начинающиеся с =, +, -, @, табуляции или CR, предваряются апострофом, чтобы табличный редактор не выполнил их как формулу.
+//	@Tags			subscriptions
+//	@Produce		text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format			query		string					false	"Формат файла"							Enums(csv, jsonl, xlsx)	default(csv)
+//	@Param			date_format		query		string					false	"Формат дат: MM-YYYY или YYYY-MM-DD"	Enums(month_year, iso)	default(month_year)
+//	@Param			sort			query		string					false	"Сортировка, как в /subscriptions/list"	Example(-price)
+//	@Param			user_id			query		string					false	"Фильтр по UUID пользователя"			Format(uuid)
+//	@Param			service_name	query		[]string				false	"Фильтр по названиям сервисов"			collectionFormat(csv)
+//	@Param			price_min		query		int						false	"Минимальная цена"						minimum(0)
+//	@Param			price_max		query		int						false	"Максимальная цена"						minimum(0)
+//	@Param			start_from		query		string					false	"Дата начала не раньше (MM-YYYY)"
+//	@Param			start_to		query		string					false	"Дата начала не позже (MM-YYYY)"
+//	@Param			end_from		query		string					false	"Дата окончания не раньше (MM-YYYY)"
+//	@Param			end_to			query		string					false	"Дата окончания не позже (MM-YYYY)"
+//	@Param			active_at		query		string					false	"Подписка действует в указанный месяц (MM-YYYY)"
+//	@Param			open_ended		query		bool					false	"true — без даты окончания, false — с датой окончания"
+//	@Param			created_since	query		string					false	"Создана не раньше (RFC 3339)"		Format(date-time)
+//	@Param			updated_since	query		string					false	"Обновлена не раньше (RFC 3339)"	Format(date-time)
+//	@Success		200				{file}		file					"Файл выгрузки"
+//	@Failure		400				{object}	common.ErrorResponse	"Неверный запрос"
+//	@Failure		401				{object}	common.ErrorResponse
+//	@Failure		500				{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/export [get]
+func (h *Handler) Export(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Export"),
+	)
+
+	var request ExportSubscriptionsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	if err := ValidateSubscriptionFilterRequest(&request.SubscriptionFilterRequest); err != nil {
+		logger.Warn("Failed to validate the request", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	params, err := ToExportSubscriptionsParams(&request)
+	if err != nil {
+		logger.Warn("Failed to convert the request to export params", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query is not valid"))
+		return
+	}
+
+	format := request.Format
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	filename := "subscriptions-" + time.Now().Format("20060102-150405") + "." + format
+	c.Header("Content-Type", ExportContentType(format))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Status(http.StatusOK)
+
+	writer, err := NewExportWriter(c.Writer, format, request.DateFormat)
+	if err != nil {
+		logger.Error("Failed to start export", slog.String("error", err.Error()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ToErrorResponse("failed to export the subscriptions"))
+		return
+	}
+
+	exported := 0
+	err = h.subscriptionService.ExportSubscriptions(middleware.RequestContext(c), params, func(subscription *domain.Subscription) error {
+		if err := writer.Write(subscription); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		status, message := http.StatusInternalServerError, "failed to export the subscriptions"
+		if errors.Is(err, domain.ErrInvalidSort) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			status, message = http.StatusForbidden, "cannot export subscriptions of another user"
+		}
+
+		logger.Warn("Failed to export subscriptions", slog.String("error", err.Error()))
+		c.AbortWithStatusJSON(status, common.ToErrorResponse(message))
+		return
+	}
+	if err != nil {
+		logger.Error("Export interrupted", slog.String("error", err.Error()), slog.Int("exported", exported))
+		c.Abort()
+		return
+	}
+
+	logger.Info("Export subscriptions successfully", slog.Int("exported", exported), slog.String("format", format))
+}
+
+// SearchSubscriptions ищет подписки по названию сервиса и заметкам
+//
+//	@Summary		Поиск подписок
+//	@Description	Полнотекстовый и нечеткий (pg_trgm) поиск по названию сервиса и заметкам.
+//	@Description	Находит подписки с опечатками в запросе ("netflx") и по нескольким словам ("yandex plus").
+//	@Description	Результаты упорядочены по релевантности. highlight содержит HTML: текст экранирован, совпадения обрамлены тегами <mark></mark>.
+//	@Tags			subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string						true	"Поисковый запрос"				Example(netflx)
+//	@Param			user_id	query		string						false	"Фильтр по UUID пользователя"	Format(uuid)
+//	@Param			limit	query		int							false	"Количество результатов"		minimum(1)	maximum(100)	Example(10)
+//	@Success		200		{object}	SearchSubscriptionsResponse	"Найденные подписки"
+//	@Failure		400		{object}	common.ErrorResponse		"Неверный запрос"
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/search [get]
+func (h *Handler) SearchSubscriptions(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "SearchSubscriptions"),
+	)
+
+	var request SearchSubscriptionsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	params, err := ToSearchSubscriptionsParams(&request)
+	if err != nil || params.Query == "" {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query is not valid"))
+		return
+	}
+
+	results, err := h.subscriptionService.SearchSubscriptions(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Searching another user's subscriptions", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("cannot search subscriptions of another user"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to search subscriptions", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to search the subscriptions"))
+		return
+	}
+
+	logger.Info("Search subscriptions successfully", slog.Int("found", len(results)))
+	c.JSON(http.StatusOK, ToSearchSubscriptionsResponse(results))
 }
 
 // TotalCostSubscriptions считает общую стоимость подписок по заданным параметрам
 //
 //	@Summary		Общая стоимость подписок
-//	@Description	Возвращает суммарную стоимость подписок для пользователя (по фильтрам из тела запроса)
+//	@Description	Возвращает суммарную стоимость подписок для пользователя (по фильтрам из тела запроса).
+//	@Description	С as_of (RFC 3339) сумма считается по подпискам в состоянии на этот момент.
 //	@Tags			subscriptions
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		TotalCostSubscriptionsRequest	true	"Параметры для подсчета стоимости"
 //	@Success		200		{object}	map[string]int					"Общая стоимость подписок"
 //	@Failure		400		{object}	common.ErrorResponse			"Неверный запрос"
-//	@Failure		500		{object}	common.ErrorResponse			"Ошибка сервера"
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		500		{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/subscriptions/total [post]
 func (h *Handler) TotalCostSubscriptions(c *gin.Context) {
-	logger := h.logger.With(
+	logger := middleware.Logger(c, h.logger).With(
 		slog.String("func", "ListSubscriptions"),
 	)
 
@@ -264,8 +596,19 @@ func (h *Handler) TotalCostSubscriptions(c *gin.Context) {
 		return
 	}
 
-	params := ToTotalCostSubscriptionsParams(&request)
-	sum, err := h.subscriptionService.TotalCostSubscriptions(params)
+	params, err := ToTotalCostSubscriptionsParams(&request)
+	if err != nil {
+		logger.Warn("Failed to convert the request to total cost params", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("user_id is not valid"))
+		return
+	}
+
+	sum, err := h.subscriptionService.TotalCostSubscriptions(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Total cost of another user's subscriptions", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("cannot get the total cost of another user"))
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to list subscriptions", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to get the total cost subscriptions"))
@@ -275,3 +618,175 @@ func (h *Handler) TotalCostSubscriptions(c *gin.Context) {
 	logger.Info("Total cost subscriptions successfully")
 	c.JSON(http.StatusOK, gin.H{"total": sum})
 }
+
+// Batch выполняет пакет операций создания, обновления и удаления подписок
+//
+//	@Summary		Пакетные операции
+//	@Description	Выполняет до 100 операций create/update/delete за один запрос.
+//	@Description	В режиме atomic (по умолчанию) все операции выполняются в одной транзакции: при любой ошибке изменения откатываются.
+//	@Description	В режиме best_effort каждая операция выполняется независимо, результат возвращается по каждому индексу.
+//	@Description	Ошибки валидации возвращаются по индексу операции; в режиме atomic они отклоняют весь пакет до выполнения.
+//	@Tags			subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		BatchRequest	true	"Операции"
+//	@Success		200		{object}	BatchResponse	"Результаты операций"
+//	@Failure		400		{object}	BatchResponse	"Ошибки валидации"
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		422		{object}	BatchResponse			"Пакет откатился из-за ошибки операции"
+//	@Failure		500		{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/batch [post]
+func (h *Handler) Batch(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Batch"),
+	)
+
+	var request BatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+		return
+	}
+
+	mode := domain.BatchMode(request.Mode)
+	if mode == "" {
+		mode = domain.BatchModeAtomic
+	}
+	if mode != domain.BatchModeAtomic && mode != domain.BatchModeBestEffort {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("mode must be atomic or best_effort"))
+		return
+	}
+
+	operations, itemErrors := ToBatchOperations(request.Operations)
+	if mode == domain.BatchModeAtomic && len(itemErrors) > 0 {
+		logger.Warn("Batch validation failed", slog.Int("invalid", len(itemErrors)))
+		c.JSON(http.StatusBadRequest, ToBatchResponse(mode, len(request.Operations), nil, itemErrors))
+		return
+	}
+
+	results, err := h.subscriptionService.ExecuteBatch(middleware.RequestContext(c), &domain.BatchParams{
+		Mode:       mode,
+		Operations: operations,
+	})
+	var itemErr *domain.BatchItemError
+	if errors.As(err, &itemErr) && !isInternalBatchError(itemErr.Err) {
+		logger.Warn("Batch rolled back", slog.String("error", err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, ToBatchResponse(mode, len(request.Operations), nil, map[int]error{itemErr.Index: itemErr.Err}))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to execute batch", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to execute the batch"))
+		return
+	}
+
+	logger.Info("Batch executed successfully")
+	c.JSON(http.StatusOK, ToBatchResponse(mode, len(request.Operations), results, itemErrors))
+}
+
+// Import загружает подписки из CSV-файла
+//
+//	@Summary		Импорт подписок из CSV
+//	@Description	Принимает CSV в поле file (multipart/form-data) или в теле запроса (text/csv).
+//	@Description	Первая строка — заголовок. По умолчанию колонки ищутся по именам service_name, price, user_id, start_date, end_date, notes;
+//	@Description	другие названия задаются через mapping. Даты принимаются в форматах MM-YYYY, YYYY-MM-DD и YYYY-MM.
+//	@Description	Разделитель (',', ';', табуляция, '|') определяется по заголовку, если не передан явно.
+//	@Description	В режиме dry_run строки только проверяются. Иначе валидные строки создаются одной транзакцией, невалидные пропускаются.
+//	@Tags			subscriptions
+//	@Accept			mpfd,plain
+//	@Produce		json
+//	@Param			file		formData	file						false	"CSV-файл"
+//	@Param			dry_run		query		bool						false	"Только проверить файл"
+//	@Param			delimiter	query		string						false	"Разделитель: символ или tab"					Example(;)
+//	@Param			mapping		query		string						false	"JSON: поле подписки -> колонка файла"			Example({"service_name":"Сервис","price":"Сумма"})
+//	@Param			user_id		query		string						false	"Пользователь для строк без колонки user_id"	Format(uuid)
+//	@Success		200			{object}	ImportSubscriptionsResponse	"Отчет проверки (dry_run)"
+//	@Success		201			{object}	ImportSubscriptionsResponse	"Отчет импорта"
+//	@Failure		400			{object}	common.ErrorResponse		"Неверный запрос или файл"
+//	@Failure		401			{object}	common.ErrorResponse
+//	@Failure		500			{object}	common.ErrorResponse	"Ошибка сервера"
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/subscriptions/import [post]
+func (h *Handler) Import(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Import"),
+	)
+
+	var request ImportSubscriptionsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+
+	options := CSVImportOptions{DefaultUserID: request.UserID}
+	var err error
+	if options.Delimiter, err = ParseDelimiter(request.Delimiter); err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+	if request.Mapping != "" {
+		if err := json.Unmarshal([]byte(request.Mapping), &options.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, common.ToErrorResponse("mapping must be a JSON object"))
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBytes)
+	var file io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			logger.Warn("Failed to get the file", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, common.ToErrorResponse("file is required"))
+			return
+		}
+
+		opened, err := fileHeader.Open()
+		if err != nil {
+			logger.Warn("Failed to open the file", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, common.ToErrorResponse("file is not valid"))
+			return
+		}
+		defer opened.Close()
+		file = opened
+	}
+
+	rows, delimiter, err := ParseImportCSV(file, options)
+	if err != nil {
+		logger.Warn("Failed to parse the file", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	params := make([]*domain.CreateSubscriptionParams, 0, len(rows))
+	for _, row := range rows {
+		if row.Err == nil {
+			params = append(params, row.Params)
+		}
+	}
+
+	if request.DryRun || len(params) == 0 {
+		logger.Info("Import checked", slog.Int("rows", len(rows)), slog.Int("valid", len(params)))
+		c.JSON(http.StatusOK, ToImportSubscriptionsResponse(request.DryRun, delimiter, rows, nil))
+		return
+	}
+
+	created, err := h.subscriptionService.ImportSubscriptions(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		logger.Warn("Importing subscriptions for another user", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("cannot import subscriptions for another user"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to import subscriptions", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to import the subscriptions"))
+		return
+	}
+
+	logger.Info("Import subscriptions successfully", slog.Int("created", len(created)))
+	c.JSON(http.StatusCreated, ToImportSubscriptionsResponse(false, delimiter, rows, created))
+}
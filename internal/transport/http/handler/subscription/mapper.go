@@ -1,11 +1,16 @@
 package subscription
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ent1k1377/subscriptions/internal/domain"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
 
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
@@ -28,6 +33,7 @@ func ToCreateSubscriptionParams(request *CreateSubscriptionRequest) (*domain.Cre
 		UserUUID:    uuidParse,
 		StartDate:   startDate,
 		EndDate:     endDate,
+		Notes:       request.Notes,
 	}, nil
 }
 
@@ -46,9 +52,16 @@ func ToGetSubscriptionResponse(subscription *domain.Subscription) *GetSubscripti
 		UserID:      subscription.UserUUID.String(),
 		StartDate:   startDate,
 		EndDate:     endDate,
+		Notes:       subscription.Notes,
+		CreatedAt:   subscription.CreatedAt,
+		UpdatedAt:   subscription.UpdatedAt,
 	}
 }
 
+func SubscriptionLocation(id uuid.UUID) string {
+	return "/api/subscriptions/" + id.String()
+}
+
 func ToUpdateSubscriptionParams(request *UpdateSubscriptionRequest) *domain.UpdateSubscriptionParams {
 	var endDate *time.Time
 	if request.EndDate != nil {
@@ -60,53 +73,362 @@ func ToUpdateSubscriptionParams(request *UpdateSubscriptionRequest) *domain.Upda
 		ServiceName: request.ServiceName,
 		Price:       request.Price,
 		EndDate:     endDate,
+		Notes:       request.Notes,
 	}
 }
 
-func ToListSubscriptionParams(request *ListSubscriptionRequest) (*domain.ListSubscriptionParams, error) {
+func ToSubscriptionFilter(request *SubscriptionFilterRequest) (domain.SubscriptionFilter, error) {
 	var userID *uuid.UUID
 	if request.UserID != nil {
 		id, err := uuid.Parse(*request.UserID)
 		if err != nil {
-			return nil, err
+			return domain.SubscriptionFilter{}, err
 		}
 		userID = &id
 	}
 
+	var serviceNames []string
+	for _, value := range request.ServiceNames {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				serviceNames = append(serviceNames, name)
+			}
+		}
+	}
+
+	return domain.SubscriptionFilter{
+		ServiceNames:     serviceNames,
+		ServiceNameMatch: domain.ServiceNameIgnoreCase,
+		UserID:           userID,
+		PriceMin:         request.PriceMin,
+		PriceMax:         request.PriceMax,
+		StartFrom:        request.StartFrom.Time(),
+		StartTo:          request.StartTo.Time(),
+		EndFrom:          request.EndFrom.Time(),
+		EndTo:            request.EndTo.Time(),
+		ActiveAt:         request.ActiveAt.Time(),
+		OpenEnded:        request.OpenEnded,
+		CreatedSince:     request.CreatedSince,
+		UpdatedSince:     request.UpdatedSince,
+	}, nil
+}
+
+func ToListSubscriptionParams(request *ListSubscriptionRequest) (*domain.ListSubscriptionParams, error) {
+	filter, err := ToSubscriptionFilter(&request.SubscriptionFilterRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := ParseSort(request.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
 	return &domain.ListSubscriptionParams{
-		ServiceName: request.ServiceName,
-		UserID:      userID,
-		Page:        request.Page,
-		Limit:       request.Limit,
+		Filter:    filter,
+		Cursor:    request.Cursor,
+		Limit:     limit,
+		Sort:      sort,
+		WithTotal: request.TotalCount,
+		AsOf:      request.AsOf,
 	}, nil
 }
 
-func ToListSubscriptionResponse(subscriptions []*domain.Subscription) *ListSubscriptionResponse {
+func ToExportSubscriptionsParams(request *ExportSubscriptionsRequest) (*domain.ExportSubscriptionsParams, error) {
+	filter, err := ToSubscriptionFilter(&request.SubscriptionFilterRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := ParseSort(request.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ExportSubscriptionsParams{
+		Filter: filter,
+		Sort:   sort,
+	}, nil
+}
+
+// ParseSort разбирает строку вида "price,-start_date", где "-" означает сортировку по убыванию
+func ParseSort(sort string) ([]domain.SortField, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(sort, ",")
+	fields := make([]domain.SortField, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		field := strings.TrimPrefix(part, "-")
+		if field == "" {
+			return nil, fmt.Errorf("%w: empty field", domain.ErrInvalidSort)
+		}
+
+		fields = append(fields, domain.SortField{Field: field, Desc: desc})
+	}
+
+	return fields, nil
+}
+
+func ToListSubscriptionResponse(page *domain.SubscriptionPage) *ListSubscriptionResponse {
+	subscriptions := make([]*GetSubscriptionResponse, 0, len(page.Subscriptions))
+	for _, subscription := range page.Subscriptions {
+		subscriptions = append(subscriptions, ToGetSubscriptionResponse(subscription))
+	}
+
 	return &ListSubscriptionResponse{
 		Subscriptions: subscriptions,
+		NextCursor:    page.NextCursor,
+		PrevCursor:    page.PrevCursor,
+		TotalCount:    page.TotalCount,
+	}
+}
+
+func ToSearchSubscriptionsParams(request *SearchSubscriptionsRequest) (*domain.SearchSubscriptionsParams, error) {
+	var userID *uuid.UUID
+	if request.UserID != nil {
+		id, err := uuid.Parse(*request.UserID)
+		if err != nil {
+			return nil, err
+		}
+		userID = &id
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+
+	return &domain.SearchSubscriptionsParams{
+		Query:  strings.TrimSpace(request.Query),
+		UserID: userID,
+		Limit:  min(limit, MaxListLimit),
+	}, nil
+}
+
+func ToSearchSubscriptionsResponse(results []*domain.SearchResult) *SearchSubscriptionsResponse {
+	response := &SearchSubscriptionsResponse{
+		Results: make([]*SearchSubscriptionResult, 0, len(results)),
+	}
+	for _, result := range results {
+		response.Results = append(response.Results, &SearchSubscriptionResult{
+			GetSubscriptionResponse: *ToGetSubscriptionResponse(result.Subscription),
+			Rank:                    result.Rank,
+			Highlight: SearchHighlight{
+				ServiceName: result.ServiceNameHighlight,
+				Notes:       result.NotesHighlight,
+			},
+		})
 	}
+
+	return response
 }
 
-func ToTotalCostSubscriptionsParams(request *TotalCostSubscriptionsRequest) *domain.TotalCostSubscriptionsParams {
-	var serviceName *string
+func ToTotalCostSubscriptionsParams(request *TotalCostSubscriptionsRequest) (*domain.TotalCostSubscriptionsParams, error) {
+	var filter domain.SubscriptionFilter
 	if request.ServiceName != nil {
-		serviceName = request.ServiceName
+		filter.ServiceNames = []string{*request.ServiceName}
 	}
 
-	var userID *uuid.UUID
 	if request.UserID != nil {
 		id, err := uuid.Parse(*request.UserID)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 
-		userID = &id
+		filter.UserID = &id
 	}
 
 	return &domain.TotalCostSubscriptionsParams{
-		ServiceName: serviceName,
-		UserID:      userID,
-		StartDate:   time.Time(request.StartDate),
-		EndDate:     time.Time(request.EndDate),
+		Filter:    filter,
+		StartDate: time.Time(request.StartDate),
+		EndDate:   time.Time(request.EndDate),
+		AsOf:      request.AsOf,
+	}, nil
+}
+
+// ToBatchOperations разбирает и валидирует операции пакета. Невалидные операции не
+// попадают в результат, их ошибки возвращаются по индексу.
+func ToBatchOperations(requests []BatchOperationRequest) ([]*domain.BatchOperation, map[int]error) {
+	operations := make([]*domain.BatchOperation, 0, len(requests))
+	itemErrors := make(map[int]error)
+	for i := range requests {
+		operation, err := toBatchOperation(i, &requests[i])
+		if err != nil {
+			itemErrors[i] = err
+			continue
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, itemErrors
+}
+
+func toBatchOperation(index int, request *BatchOperationRequest) (*domain.BatchOperation, error) {
+	operation := &domain.BatchOperation{
+		Index: index,
+		Type:  domain.BatchOperationType(request.Op),
 	}
+
+	if operation.Type == domain.BatchOperationUpdate || operation.Type == domain.BatchOperationDelete {
+		id, err := uuid.Parse(request.ID)
+		if err != nil {
+			var vErr ValidationError
+			vErr.Add("id", "must be a valid UUID")
+			return nil, &vErr
+		}
+		operation.UUID = id
+	}
+
+	switch operation.Type {
+	case domain.BatchOperationCreate:
+		var create CreateSubscriptionRequest
+		if err := decodeBatchData(request.Data, &create); err != nil {
+			return nil, err
+		}
+		if err := ValidateCreateSubscriptionRequest(create); err != nil {
+			return nil, err
+		}
+		params, err := ToCreateSubscriptionParams(&create)
+		if err != nil {
+			return nil, err
+		}
+		operation.Create = params
+	case domain.BatchOperationUpdate:
+		var update UpdateSubscriptionRequest
+		if err := decodeBatchData(request.Data, &update); err != nil {
+			return nil, err
+		}
+		if err := ValidateUpdateSubscriptionRequest(update); err != nil {
+			return nil, err
+		}
+		operation.Update = ToUpdateSubscriptionParams(&update)
+	case domain.BatchOperationDelete:
+	default:
+		var vErr ValidationError
+		vErr.Add("op", "must be one of create, update, delete")
+		return nil, &vErr
+	}
+
+	return operation, nil
+}
+
+func decodeBatchData(data json.RawMessage, target any) error {
+	if len(data) == 0 {
+		var vErr ValidationError
+		vErr.Add("data", "required")
+		return &vErr
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return &BatchDataError{Err: err}
+	}
+
+	return FromBindingErrors(binding.Validator.ValidateStruct(target))
+}
+
+func ToBatchResponse(mode domain.BatchMode, total int, results []*domain.BatchItemResult, itemErrors map[int]error) *BatchResponse {
+	items := make([]*BatchItemResponse, total)
+	for i := range items {
+		items[i] = &BatchItemResponse{Index: i, Status: "skipped"}
+	}
+
+	for index, err := range itemErrors {
+		item := items[index]
+		item.Status = "failed"
+
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			item.Error = "validation failed"
+			item.Errors = vErr.Errors
+		} else if isInternalBatchError(err) {
+			item.Error = "internal error"
+		} else {
+			item.Error = err.Error()
+		}
+	}
+
+	for _, result := range results {
+		item := items[result.Index]
+		switch {
+		case result.Err == nil:
+			item.Status = "ok"
+			if result.Subscription != nil {
+				item.Subscription = ToGetSubscriptionResponse(result.Subscription)
+			}
+		case isInternalBatchError(result.Err):
+			item.Status = "failed"
+			item.Error = "internal error"
+		default:
+			item.Status = "failed"
+			item.Error = result.Err.Error()
+		}
+	}
+
+	response := &BatchResponse{Mode: string(mode), Results: items}
+	for _, item := range items {
+		switch item.Status {
+		case "ok":
+			response.Succeeded++
+		case "failed":
+			response.Failed++
+		}
+	}
+
+	return response
+}
+
+// isInternalBatchError отличает сбои базы от ошибок, вызванных данными клиента
+func isInternalBatchError(err error) bool {
+	var vErr *ValidationError
+	var dataErr *BatchDataError
+	return !errors.As(err, &vErr) && !errors.As(err, &dataErr) && !errors.Is(err, domain.ErrSubscriptionNotFound) &&
+		!errors.Is(err, domain.ErrForbidden)
+}
+
+func ToImportSubscriptionsResponse(dryRun bool, delimiter rune, rows []*ImportRow, created []*domain.Subscription) *ImportSubscriptionsResponse {
+	response := &ImportSubscriptionsResponse{
+		DryRun:    dryRun,
+		Delimiter: string(delimiter),
+		Total:     len(rows),
+		Created:   len(created),
+		Rows:      make([]*ImportRowResponse, 0, len(rows)),
+	}
+
+	next := 0
+	for _, row := range rows {
+		item := &ImportRowResponse{Row: row.Line}
+		if row.Err != nil {
+			response.Invalid++
+			item.Status = "invalid"
+
+			var vErr *ValidationError
+			if errors.As(row.Err, &vErr) {
+				item.Error = "validation failed"
+				item.Errors = vErr.Errors
+			} else {
+				item.Error = row.Err.Error()
+			}
+		} else {
+			response.Valid++
+			item.Status = "valid"
+			if next < len(created) {
+				item.Status = "created"
+				item.ID = created[next].UUID.String()
+				next++
+			}
+		}
+
+		response.Rows = append(response.Rows, item)
+	}
+
+	return response
 }
@@ -0,0 +1,165 @@
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestToBatchOperations(t *testing.T) {
+	subscriptionID := uuid.MustParse("f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+	userID := "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	requests := []BatchOperationRequest{
+		{Op: "create", Data: json.RawMessage(`{"service_name":"Netflix","price":999,"user_id":"` + userID + `","start_date":"07-2025"}`)},
+		{Op: "update", ID: subscriptionID.String(), Data: json.RawMessage(`{"service_name":"Netflix","price":1299,"end_date":"12-2025"}`)},
+		{Op: "delete", ID: subscriptionID.String()},
+		{Op: "delete", ID: "not-a-uuid"},
+		{Op: "create"},
+		{Op: "create", Data: json.RawMessage(`{"service_name":`)},
+		{Op: "create", Data: json.RawMessage(`{"service_name":"Netflix","price":999,"start_date":"07-2025"}`)},
+		{Op: "update", ID: subscriptionID.String(), Data: json.RawMessage(`{"price":-1}`)},
+		{Op: "upsert"},
+	}
+
+	operations, itemErrors := ToBatchOperations(requests)
+
+	if len(operations) != 3 {
+		t.Fatalf("got %d operations, want 3", len(operations))
+	}
+
+	create := operations[0]
+	if create.Index != 0 || create.Type != domain.BatchOperationCreate || create.Create == nil {
+		t.Fatalf("operation 0 = %+v, want create", create)
+	}
+	if create.Create.ServiceName != "Netflix" || create.Create.Price != 999 || create.Create.UserUUID.String() != userID {
+		t.Errorf("create params = %+v", create.Create)
+	}
+	if !create.Create.StartDate.Equal(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("create start date = %v, want 2025-07-01", create.Create.StartDate)
+	}
+
+	update := operations[1]
+	if update.Index != 1 || update.Type != domain.BatchOperationUpdate || update.UUID != subscriptionID || update.Update == nil {
+		t.Fatalf("operation 1 = %+v, want update of %s", update, subscriptionID)
+	}
+	if update.Update.Price != 1299 || update.Update.EndDate == nil {
+		t.Errorf("update params = %+v", update.Update)
+	}
+
+	remove := operations[2]
+	if remove.Index != 2 || remove.Type != domain.BatchOperationDelete || remove.UUID != subscriptionID {
+		t.Errorf("operation 2 = %+v, want delete of %s", remove, subscriptionID)
+	}
+
+	wantFields := map[int]string{
+		3: "id",
+		4: "data",
+		6: "user_id",
+		7: "service_name",
+		8: "op",
+	}
+	if len(itemErrors) != len(wantFields)+1 {
+		t.Errorf("got %d item errors, want %d: %v", len(itemErrors), len(wantFields)+1, itemErrors)
+	}
+	for index, field := range wantFields {
+		var vErr *ValidationError
+		if !errors.As(itemErrors[index], &vErr) {
+			t.Errorf("operation %d error = %v, want ValidationError", index, itemErrors[index])
+			continue
+		}
+		if _, ok := vErr.Errors[field]; !ok {
+			t.Errorf("operation %d errors = %v, want field %q", index, vErr.Errors, field)
+		}
+	}
+
+	var dataErr *BatchDataError
+	if !errors.As(itemErrors[5], &dataErr) {
+		t.Errorf("operation 5 error = %v, want BatchDataError", itemErrors[5])
+	}
+}
+
+func TestToBatchResponse(t *testing.T) {
+	subscription := &domain.Subscription{
+		UUID:        uuid.MustParse("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"),
+		ServiceName: "Netflix",
+		Price:       999,
+		UserUUID:    uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	var vErr ValidationError
+	vErr.Add("id", "must be a valid UUID")
+	itemErrors := map[int]error{
+		1: &vErr,
+		2: &BatchDataError{Err: errors.New("unexpected end of JSON input")},
+	}
+	results := []*domain.BatchItemResult{
+		{Index: 0, Subscription: subscription},
+		{Index: 3},
+		{Index: 4, Err: domain.ErrSubscriptionNotFound},
+		{Index: 5, Err: fmt.Errorf("failed to delete: %w", errors.New("connection reset"))},
+	}
+
+	response := ToBatchResponse(domain.BatchModeBestEffort, 7, results, itemErrors)
+
+	if response.Mode != "best_effort" || response.Succeeded != 2 || response.Failed != 4 {
+		t.Errorf("response = mode %q, succeeded %d, failed %d; want best_effort, 2, 4",
+			response.Mode, response.Succeeded, response.Failed)
+	}
+
+	want := []BatchItemResponse{
+		{Index: 0, Status: "ok"},
+		{Index: 1, Status: "failed", Error: "validation failed", Errors: map[string]string{"id": "must be a valid UUID"}},
+		{Index: 2, Status: "failed", Error: "data is not valid: unexpected end of JSON input"},
+		{Index: 3, Status: "ok"},
+		{Index: 4, Status: "failed", Error: "subscription not found"},
+		{Index: 5, Status: "failed", Error: "internal error"},
+		{Index: 6, Status: "skipped"},
+	}
+	if len(response.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(response.Results), len(want))
+	}
+	for i, item := range response.Results {
+		got := *item
+		got.Subscription = nil
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("result %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if got := response.Results[0].Subscription; got == nil || got.ID != subscription.UUID.String() {
+		t.Errorf("result 0 subscription = %+v, want %s", got, subscription.UUID)
+	}
+	if response.Results[3].Subscription != nil {
+		t.Errorf("result 3 subscription = %+v, want nil for delete", response.Results[3].Subscription)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "ServiceName", want: "service_name"},
+		{in: "Price", want: "price"},
+		{in: "UserID", want: "user_id"},
+		{in: "IDList", want: "id_list"},
+		{in: "HTTPStatusCode", want: "http_status_code"},
+		{in: "price", want: "price"},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := toSnakeCase(tt.in); got != tt.want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
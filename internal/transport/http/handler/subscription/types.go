@@ -1,7 +1,9 @@
 package subscription
 
 import (
-	"github.com/ent1k1377/subscriptions/internal/domain"
+	"encoding/json"
+	"time"
+
 	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
 )
 
@@ -12,6 +14,7 @@ type CreateSubscriptionRequest struct {
 	UserID      string            `json:"user_id" example:"f81d4fae-7dec-11d0-a765-00a0c91e6bf6" binding:"required,uuid"`
 	StartDate   common.MonthYear  `json:"start_date" example:"01-2025" binding:"required"`
 	EndDate     *common.MonthYear `json:"end_date,omitempty" example:"12-2025"`
+	Notes       string            `json:"notes,omitempty" example:"семейный тариф"`
 }
 
 type GetSubscriptionResponse struct {
@@ -21,23 +24,91 @@ type GetSubscriptionResponse struct {
 	UserID      string            `json:"user_id"`
 	StartDate   common.MonthYear  `json:"start_date"`
 	EndDate     *common.MonthYear `json:"end_date"`
+	Notes       string            `json:"notes"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
 type UpdateSubscriptionRequest struct {
 	ServiceName string            `json:"service_name"`
 	Price       int               `json:"price"`
 	EndDate     *common.MonthYear `json:"end_date,omitempty"`
+	Notes       string            `json:"notes,omitempty"`
+}
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// SubscriptionFilterRequest query-параметры фильтрации подписок
+type SubscriptionFilterRequest struct {
+	ServiceNames []string         `form:"service_name"`
+	UserID       *string          `form:"user_id,omitempty"`
+	PriceMin     *int             `form:"price_min" binding:"omitempty,gte=0"`
+	PriceMax     *int             `form:"price_max" binding:"omitempty,gte=0"`
+	StartFrom    common.MonthYear `form:"start_from"`
+	StartTo      common.MonthYear `form:"start_to"`
+	EndFrom      common.MonthYear `form:"end_from"`
+	EndTo        common.MonthYear `form:"end_to"`
+	ActiveAt     common.MonthYear `form:"active_at"`
+	OpenEnded    *bool            `form:"open_ended"`
+	CreatedSince *time.Time       `form:"created_since" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedSince *time.Time       `form:"updated_since" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// GetSubscriptionRequest as_of состояние подписки на момент времени в формате RFC 3339
+type GetSubscriptionRequest struct {
+	AsOf *time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"`
 }
 
 type ListSubscriptionRequest struct {
-	ServiceName *string `form:"service_name,omitempty"`
-	UserID      *string `form:"user_id,omitempty"`
-	Page        int     `form:"page"`
-	Limit       int     `form:"limit"`
+	SubscriptionFilterRequest
+	Cursor     string     `form:"cursor"`
+	Limit      int        `form:"limit" binding:"omitempty,min=1,max=100"`
+	Sort       string     `form:"sort"`
+	TotalCount bool       `form:"total_count"`
+	AsOf       *time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"`
 }
 
 type ListSubscriptionResponse struct {
-	Subscriptions []*domain.Subscription `json:"subscriptions"`
+	Subscriptions []*GetSubscriptionResponse `json:"subscriptions"`
+	NextCursor    string                     `json:"next_cursor,omitempty"`
+	PrevCursor    string                     `json:"prev_cursor,omitempty"`
+	TotalCount    *int                       `json:"total_count,omitempty"`
+}
+
+type ExportSubscriptionsRequest struct {
+	SubscriptionFilterRequest
+	Format     string `form:"format" binding:"omitempty,oneof=csv jsonl xlsx"`
+	DateFormat string `form:"date_format" binding:"omitempty,oneof=month_year iso"`
+	Sort       string `form:"sort"`
+}
+
+type SearchSubscriptionsRequest struct {
+	Query  string  `form:"q" binding:"required"`
+	UserID *string `form:"user_id,omitempty"`
+	Limit  int     `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SearchHighlight фрагменты названия и заметок в виде HTML. Текст экранирован, единственная
+// разметка в нем — теги <mark></mark> вокруг совпадений, поэтому фрагмент можно вставлять
+// в страницу как HTML. Для обычного текста уберите теги и раскодируйте сущности.
+type SearchHighlight struct {
+	// ServiceName название сервиса: HTML-экранированный текст, совпадения обрамлены тегами <mark></mark>
+	ServiceName string `json:"service_name"`
+	// Notes фрагменты заметок: HTML-экранированный текст, совпадения обрамлены тегами <mark></mark>
+	Notes string `json:"notes,omitempty"`
+}
+
+type SearchSubscriptionResult struct {
+	GetSubscriptionResponse
+	Rank      float64         `json:"rank"`
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+type SearchSubscriptionsResponse struct {
+	Results []*SearchSubscriptionResult `json:"results"`
 }
 
 type TotalCostSubscriptionsRequest struct {
@@ -45,4 +116,58 @@ type TotalCostSubscriptionsRequest struct {
 	UserID      *string          `json:"user_id"`
 	StartDate   common.MonthYear `json:"start_date"`
 	EndDate     common.MonthYear `json:"end_date"`
+	AsOf        *time.Time       `json:"as_of,omitempty" example:"2026-03-01T00:00:00Z"`
+}
+
+const MaxBatchOperations = 100
+
+type BatchOperationRequest struct {
+	Op   string          `json:"op" example:"create" enums:"create,update,delete"`
+	ID   string          `json:"id,omitempty" example:"f81d4fae-7dec-11d0-a765-00a0c91e6bf6"`
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+type BatchRequest struct {
+	Mode       string                  `json:"mode" example:"atomic" enums:"atomic,best_effort"`
+	Operations []BatchOperationRequest `json:"operations" binding:"required,min=1,max=100"`
+}
+
+type BatchItemResponse struct {
+	Index        int                      `json:"index"`
+	Status       string                   `json:"status" enums:"ok,failed,skipped"`
+	Subscription *GetSubscriptionResponse `json:"subscription,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+	Errors       map[string]string        `json:"errors,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode      string               `json:"mode"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []*BatchItemResponse `json:"results"`
+}
+
+type ImportSubscriptionsRequest struct {
+	DryRun    bool   `form:"dry_run"`
+	Delimiter string `form:"delimiter"`
+	Mapping   string `form:"mapping"`
+	UserID    string `form:"user_id"`
+}
+
+type ImportRowResponse struct {
+	Row    int               `json:"row"`
+	Status string            `json:"status" enums:"valid,invalid,created"`
+	ID     string            `json:"id,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type ImportSubscriptionsResponse struct {
+	DryRun    bool                 `json:"dry_run"`
+	Delimiter string               `json:"delimiter"`
+	Total     int                  `json:"total"`
+	Valid     int                  `json:"valid"`
+	Invalid   int                  `json:"invalid"`
+	Created   int                  `json:"created"`
+	Rows      []*ImportRowResponse `json:"rows"`
 }
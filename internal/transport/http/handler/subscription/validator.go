@@ -1,8 +1,14 @@
 package subscription
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
@@ -25,6 +31,52 @@ func (e *ValidationError) HasErrors() bool {
 	return len(e.Errors) > 0
 }
 
+// BatchDataError тело операции пакета не удалось разобрать
+type BatchDataError struct {
+	Err error
+}
+
+func (e *BatchDataError) Error() string {
+	return "data is not valid: " + e.Err.Error()
+}
+
+func (e *BatchDataError) Unwrap() error {
+	return e.Err
+}
+
+// FromBindingErrors переводит ошибки тегов binding в ValidationError с именами полей в snake_case
+func FromBindingErrors(err error) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	var vErr ValidationError
+	for _, fieldError := range fieldErrors {
+		vErr.Add(toSnakeCase(fieldError.Field()), "failed on the '"+fieldError.Tag()+"' rule")
+	}
+
+	return &vErr
+}
+
+// toSnakeCase переводит имя поля в snake_case, аббревиатуры остаются одним словом: UserID -> user_id
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+			if startsWord {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
 func ValidateCreateSubscriptionRequest(req CreateSubscriptionRequest) error {
 	var vErr ValidationError
 
@@ -41,8 +93,50 @@ func ValidateCreateSubscriptionRequest(req CreateSubscriptionRequest) error {
 	}
 
 	if vErr.HasErrors() {
-		return err
+		return &vErr
 	}
 
 	return nil
 }
+
+func ValidateUpdateSubscriptionRequest(req UpdateSubscriptionRequest) error {
+	var vErr ValidationError
+
+	if req.ServiceName == "" {
+		vErr.Add("service_name", "required")
+	}
+	if req.Price < 0 {
+		vErr.Add("price", "must be greater than or equal to zero")
+	}
+
+	if vErr.HasErrors() {
+		return &vErr
+	}
+
+	return nil
+}
+
+func ValidateSubscriptionFilterRequest(req *SubscriptionFilterRequest) error {
+	var vErr ValidationError
+
+	if req.PriceMin != nil && req.PriceMax != nil && *req.PriceMin > *req.PriceMax {
+		vErr.Add("price_min", "must be less than or equal to price_max")
+	}
+	if isAfter(req.StartFrom, req.StartTo) {
+		vErr.Add("start_from", "must not be after start_to")
+	}
+	if isAfter(req.EndFrom, req.EndTo) {
+		vErr.Add("end_from", "must not be after end_to")
+	}
+
+	if vErr.HasErrors() {
+		return &vErr
+	}
+
+	return nil
+}
+
+func isAfter(from, to common.MonthYear) bool {
+	fromTime, toTime := from.Time(), to.Time()
+	return fromTime != nil && toTime != nil && fromTime.After(*toTime)
+}
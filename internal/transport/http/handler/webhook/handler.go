@@ -0,0 +1,372 @@
+package webhook
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/service"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	logger         *slog.Logger
+	webhookService *service.Webhook
+}
+
+func NewHandler(baseLogger *slog.Logger, webhookService *service.Webhook) *Handler {
+	logger := baseLogger.WithGroup("webhook handler")
+
+	return &Handler{
+		logger:         logger,
+		webhookService: webhookService,
+	}
+}
+
+// Create регистрирует вебхук
+//
+//	@Summary		Создать вебхук
+//	@Description	Регистрирует адрес для событий subscription.created, subscription.updated, subscription.deleted и subscription.ending_soon.
+//	@Description	Событие отправляется POST-запросом с JSON {id, type, occurred_at, data}. Заголовок X-Webhook-Signature имеет вид t=<unix>,v1=<hex>,
+//	@Description	где v1 — HMAC-SHA256 секрета от строки "<unix>.<тело запроса>". Ответ не из 2xx повторяется с экспоненциальной задержкой.
+//	@Description	Если секрет не передан, он генерируется. Секрет возвращается только в ответе на создание.
+//	@Description	Адрес должен использовать http или https и не вести в loopback, частные, link-local и служебные сети:
+//	@Description	такой адрес отклоняется с ответом 422, а при отправке соединение с ним не устанавливается.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateWebhookRequest	true	"Настройки вебхука"
+//	@Success		201		{object}	CreateWebhookResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		422		{object}	common.ErrorResponse	"Адрес недопустим"
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks [post]
+func (h *Handler) Create(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Create"),
+	)
+
+	var request CreateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+		return
+	}
+
+	params, err := ToCreateWebhookParams(&request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(middleware.RequestContext(c), params)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidWebhookURL) {
+		logger.Warn("Webhook url is not allowed", slog.String("error", err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, common.ToErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to create webhook", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to create the webhook"))
+		return
+	}
+
+	c.Header("Location", "/api/webhooks/"+webhook.UUID.String())
+	c.JSON(http.StatusCreated, CreateWebhookResponse{
+		WebhookResponse: *ToWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+}
+
+// List возвращает все вебхуки
+//
+//	@Summary	Список вебхуков
+//	@Tags		webhooks
+//	@Produce	json
+//	@Success	200	{object}	ListWebhooksResponse
+//	@Failure	401	{object}	common.ErrorResponse
+//	@Failure	500	{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Router		/webhooks [get]
+func (h *Handler) List(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "List"),
+	)
+
+	webhooks, err := h.webhookService.ListWebhooks(middleware.RequestContext(c))
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to list webhooks", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to list the webhooks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ToListWebhooksResponse(webhooks))
+}
+
+// Get возвращает вебхук по UUID
+//
+//	@Summary	Получить вебхук
+//	@Tags		webhooks
+//	@Produce	json
+//	@Param		uuid	path		string	true	"UUID вебхука"	Format(uuid)
+//	@Success	200		{object}	WebhookResponse
+//	@Failure	400		{object}	common.ErrorResponse
+//	@Failure	401		{object}	common.ErrorResponse
+//	@Failure	404		{object}	common.ErrorResponse
+//	@Failure	500		{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Router		/webhooks/{uuid} [get]
+func (h *Handler) Get(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Get"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(middleware.RequestContext(c), id)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("webhook not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get webhook", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to get the webhook"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ToWebhookResponse(webhook))
+}
+
+// Update заменяет настройки вебхука
+//
+//	@Summary		Обновить вебхук
+//	@Description	Заменяет адрес, список событий и признак active. Если передан secret, он заменяет прежний.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			uuid	path		string					true	"UUID вебхука"	Format(uuid)
+//	@Param			request	body		UpdateWebhookRequest	true	"Настройки вебхука"
+//	@Success		200		{object}	WebhookResponse
+//	@Failure		400		{object}	common.ErrorResponse
+//	@Failure		401		{object}	common.ErrorResponse
+//	@Failure		404		{object}	common.ErrorResponse
+//	@Failure		422		{object}	common.ErrorResponse	"Адрес недопустим"
+//	@Failure		500		{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{uuid} [put]
+func (h *Handler) Update(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Update"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	var request UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Warn("Failed to bind the body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("json body is not valid"))
+		return
+	}
+
+	params, err := ToUpdateWebhookParams(&request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(err.Error()))
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(middleware.RequestContext(c), id, params)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidWebhookURL) {
+		logger.Warn("Webhook url is not allowed", slog.String("error", err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, common.ToErrorResponse(err.Error()))
+		return
+	}
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("webhook not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to update webhook", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to update the webhook"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ToWebhookResponse(webhook))
+}
+
+// Delete удаляет вебхук вместе с журналом отправок
+//
+//	@Summary	Удалить вебхук
+//	@Tags		webhooks
+//	@Produce	json
+//	@Param		uuid	path	string	true	"UUID вебхука"	Format(uuid)
+//	@Success	204
+//	@Failure	400	{object}	common.ErrorResponse
+//	@Failure	401	{object}	common.ErrorResponse
+//	@Failure	404	{object}	common.ErrorResponse
+//	@Failure	500	{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Router		/webhooks/{uuid} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Delete"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	err := h.webhookService.DeleteWebhook(middleware.RequestContext(c), id)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("webhook not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to delete webhook", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to delete the webhook"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries возвращает журнал отправок вебхука, новые первыми
+//
+//	@Summary	Журнал отправок
+//	@Tags		webhooks
+//	@Produce	json
+//	@Param		uuid	path		string	true	"UUID вебхука"				Format(uuid)
+//	@Param		limit	query		int		false	"Сколько записей вернуть"	minimum(1)	maximum(200)	default(50)
+//	@Success	200		{object}	ListDeliveriesResponse
+//	@Failure	400		{object}	common.ErrorResponse
+//	@Failure	401		{object}	common.ErrorResponse
+//	@Failure	404		{object}	common.ErrorResponse
+//	@Failure	500		{object}	common.ErrorResponse
+//	@Security	BearerAuth
+//	@Router		/webhooks/{uuid}/deliveries [get]
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "ListDeliveries"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+
+	var request ListDeliveriesRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Warn("Failed to bind the query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse("query line is not valid"))
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = DefaultDeliveriesLimit
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(middleware.RequestContext(c), id, request.Limit)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("webhook not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to list webhook deliveries", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to list the deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ToListDeliveriesResponse(deliveries))
+}
+
+// Redeliver повторно отправляет событие из журнала
+//
+//	@Summary		Повторить отправку
+//	@Description	Ставит в очередь новую отправку того же события с тем же телом. Получатель может отличить повтор по X-Webhook-Event-Id.
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			uuid		path		string	true	"UUID вебхука"	Format(uuid)
+//	@Param			delivery_id	path		string	true	"UUID отправки"	Format(uuid)
+//	@Success		202			{object}	DeliveryResponse
+//	@Failure		400			{object}	common.ErrorResponse
+//	@Failure		401			{object}	common.ErrorResponse
+//	@Failure		404			{object}	common.ErrorResponse
+//	@Failure		500			{object}	common.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{uuid}/deliveries/{delivery_id}/redeliver [post]
+func (h *Handler) Redeliver(c *gin.Context) {
+	logger := middleware.Logger(c, h.logger).With(
+		slog.String("func", "Redeliver"),
+	)
+
+	id, ok := parseUUIDParam(c, "uuid")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseUUIDParam(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(middleware.RequestContext(c), id, deliveryID)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, common.ToErrorResponse("admin role required"))
+		return
+	}
+	if errors.Is(err, domain.ErrDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, common.ToErrorResponse("delivery not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to redeliver webhook", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, common.ToErrorResponse("failed to redeliver the event"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ToDeliveryResponse(delivery))
+}
+
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ToErrorResponse(name+" is not valid"))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+func ToCreateWebhookParams(request *CreateWebhookRequest) (*domain.CreateWebhookParams, error) {
+	if err := validateWebhookURL(request.URL); err != nil {
+		return nil, err
+	}
+
+	return &domain.CreateWebhookParams{
+		URL:    request.URL,
+		Secret: request.Secret,
+		Events: toEventTypes(request.Events),
+	}, nil
+}
+
+func ToUpdateWebhookParams(request *UpdateWebhookRequest) (*domain.UpdateWebhookParams, error) {
+	if err := validateWebhookURL(request.URL); err != nil {
+		return nil, err
+	}
+
+	return &domain.UpdateWebhookParams{
+		URL:    request.URL,
+		Secret: request.Secret,
+		Events: toEventTypes(request.Events),
+		Active: *request.Active,
+	}, nil
+}
+
+func ToWebhookResponse(webhook *domain.Webhook) *WebhookResponse {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, string(event))
+	}
+
+	return &WebhookResponse{
+		ID:             webhook.UUID.String(),
+		OrganizationID: webhook.OrganizationID.String(),
+		URL:            webhook.URL,
+		Events:         events,
+		Active:         webhook.Active,
+		CreatedAt:      webhook.CreatedAt,
+		UpdatedAt:      webhook.UpdatedAt,
+	}
+}
+
+func ToListWebhooksResponse(webhooks []*domain.Webhook) *ListWebhooksResponse {
+	response := &ListWebhooksResponse{Webhooks: make([]*WebhookResponse, 0, len(webhooks))}
+	for _, webhook := range webhooks {
+		response.Webhooks = append(response.Webhooks, ToWebhookResponse(webhook))
+	}
+
+	return response
+}
+
+func ToDeliveryResponse(delivery *domain.WebhookDelivery) *DeliveryResponse {
+	return &DeliveryResponse{
+		ID:             delivery.UUID.String(),
+		EventID:        delivery.EventID.String(),
+		EventType:      string(delivery.EventType),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		Payload:        delivery.Payload,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+func ToListDeliveriesResponse(deliveries []*domain.WebhookDelivery) *ListDeliveriesResponse {
+	response := &ListDeliveriesResponse{Deliveries: make([]*DeliveryResponse, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, ToDeliveryResponse(delivery))
+	}
+
+	return response
+}
+
+func toEventTypes(events []string) []domain.EventType {
+	types := make([]domain.EventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, domain.EventType(event))
+	}
+
+	return types
+}
+
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 200
+)
+
+// CreateWebhookRequest регистрация вебхука. Пустой список events — все события.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://billing.example.com/hooks/subscriptions" binding:"required,url,max=2048"`
+	Secret string   `json:"secret,omitempty" binding:"omitempty,min=16,max=256"`
+	Events []string `json:"events,omitempty" example:"subscription.created,subscription.deleted" binding:"omitempty,dive,oneof=subscription.created subscription.updated subscription.deleted subscription.ending_soon"`
+}
+
+// UpdateWebhookRequest полностью заменяет настройки вебхука. Пустой secret оставляет прежний.
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Secret string   `json:"secret,omitempty" binding:"omitempty,min=16,max=256"`
+	Events []string `json:"events" binding:"omitempty,dive,oneof=subscription.created subscription.updated subscription.deleted subscription.ending_soon"`
+	Active *bool    `json:"active" binding:"required"`
+}
+
+type WebhookResponse struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreateWebhookResponse секрет возвращается только при создании
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []*WebhookResponse `json:"webhooks"`
+}
+
+type ListDeliveriesRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+type DeliveryResponse struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type" example:"subscription.created"`
+	Status         string          `json:"status" enums:"pending,succeeded,failed"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type ListDeliveriesResponse struct {
+	Deliveries []*DeliveryResponse `json:"deliveries"`
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+const PrincipalKey = "principal"
+
+const (
+	SchemeBearer = "Bearer"
+	SchemeAPIKey = "ApiKey"
+)
+
+// Auth требует заголовок Authorization: Bearer <jwt> или ApiKey <key> и кладет вызывающего
+// в контекст запроса, откуда его берет сервисный слой. При apiKeys == nil ключи не принимаются.
+func Auth(logger *slog.Logger, jwt, apiKeys domain.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, ok := authorization(c.GetHeader("Authorization"))
+
+		var authenticator domain.Authenticator
+		switch {
+		case ok && strings.EqualFold(scheme, SchemeBearer):
+			authenticator = jwt
+		case ok && strings.EqualFold(scheme, SchemeAPIKey) && apiKeys != nil:
+			authenticator = apiKeys
+		}
+		if authenticator == nil {
+			c.Header("WWW-Authenticate", SchemeBearer)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, common.ToErrorResponse("authorization required"))
+			return
+		}
+
+		principal, err := authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			logger := logger.With(
+				slog.String("request_id", c.GetString(RequestIDKey)),
+				slog.String("scheme", scheme),
+				slog.String("error", err.Error()),
+			)
+			if !errors.Is(err, domain.ErrUnauthenticated) {
+				logger.Error("Failed to authenticate the request")
+				c.AbortWithStatusJSON(http.StatusInternalServerError, common.ToErrorResponse("failed to authenticate the request"))
+				return
+			}
+
+			logger.Info("Rejected credentials")
+			c.Header("WWW-Authenticate", scheme+` error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, common.ToErrorResponse("invalid credentials"))
+			return
+		}
+
+		c.Set(PrincipalKey, principal)
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequirePermission объявляет право, без которого обработчик недоступен. Роль пользователя
+// или scopes API-ключа должны давать его хотя бы на собственные данные; чьи именно данные
+// доступны, проверяет сервисный слой.
+func RequirePermission(permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFromContext(c.Request.Context())
+		if ok && !principal.Has(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, common.ToErrorResponse("the "+string(permission)+" permission is required"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func authorization(header string) (string, string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+
+	return scheme, token, ok && token != ""
+}
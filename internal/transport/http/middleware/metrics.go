@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics считает запросы и их длительность по шаблону маршрута, методу и статусу.
+// Должен стоять первым, чтобы учитывать и запросы, отклоненные другими middleware.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
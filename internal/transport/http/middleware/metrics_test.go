@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ent1k1377/subscriptions/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestMetricsRoute запросы считаются по шаблону маршрута, а не по пути, неизвестные
+// пути — с пустым маршрутом; учитываются и запросы, прерванные следующими middleware
+func TestMetricsRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/api/subscriptions/:uuid", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/subscriptions", func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) })
+
+	tests := []struct {
+		method string
+		path   string
+		route  string
+		status string
+	}{
+		{method: http.MethodGet, path: "/api/subscriptions/6ba7b810-9dad-11d1-80b4-00c04fd430c8", route: "/api/subscriptions/:uuid", status: "200"},
+		{method: http.MethodPost, path: "/api/subscriptions", route: "/api/subscriptions", status: "401"},
+		{method: http.MethodGet, path: "/wp-admin/setup.php", route: "", status: "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			counter := metrics.HTTPRequests.WithLabelValues(tt.route, tt.method, tt.status)
+			before := testutil.ToFloat64(counter)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("requests_total{route=%q,method=%q,status=%q} grew by %v, want 1", tt.route, tt.method, tt.status, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit ограничивает частоту запросов алгоритмом token bucket. Вызывающий получает
+// корзину на каждый маршрут по правилу из cfg.Routes или cfg.Default и, если для него
+// задано правило в cfg.Principals, общую корзину на все маршруты. Должен стоять после
+// Auth, чтобы различать вызывающих по ключу или токену, а не по IP-адресу.
+//
+// Ответ содержит заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset
+// по самой исчерпанной корзине, отклоненный запрос получает 429 и Retry-After.
+// Если хранилище недоступно, запрос пропускается.
+func RateLimit(logger *slog.Logger, cfg config.RateLimitConfig, limiter domain.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		caller, callerID := rateLimitCaller(c)
+
+		rule, ok := cfg.Routes[route]
+		if !ok {
+			rule = cfg.Default
+		}
+		buckets := []rateLimitBucket{{key: route + "|" + caller, limit: toRateLimit(rule)}}
+		if rule, ok := cfg.Principals[callerID]; ok {
+			buckets = append(buckets, rateLimitBucket{key: caller, limit: toRateLimit(rule)})
+		}
+
+		var decision *domain.RateLimitDecision
+		for _, bucket := range buckets {
+			current, err := limiter.Take(c.Request.Context(), bucket.key, bucket.limit)
+			if err != nil {
+				logger.Error("Failed to check the rate limit",
+					slog.String("request_id", c.GetString(RequestIDKey)),
+					slog.String("error", err.Error()),
+				)
+				c.Next()
+				return
+			}
+			if decision == nil || !current.Allowed || (decision.Allowed && current.Remaining < decision.Remaining) {
+				decision = current
+			}
+			if !current.Allowed {
+				break
+			}
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+		if !decision.Allowed {
+			logger.Info("Rate limit exceeded",
+				slog.String("request_id", c.GetString(RequestIDKey)),
+				slog.String("route", route),
+				slog.String("caller", caller),
+			)
+			c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(decision.RetryAfter), 1)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, common.ToErrorResponse("rate limit exceeded"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type rateLimitBucket struct {
+	key   string
+	limit domain.RateLimit
+}
+
+// rateLimitCaller ключ корзины вызывающего и его идентификатор для поиска в cfg.Principals
+func rateLimitCaller(c *gin.Context) (string, string) {
+	principal, ok := domain.PrincipalFromContext(c.Request.Context())
+	switch {
+	case ok && principal.APIKeyUUID != nil:
+		return "key:" + principal.APIKeyUUID.String(), principal.APIKeyUUID.String()
+	case ok:
+		return "user:" + principal.Subject.String(), principal.Subject.String()
+	default:
+		return "ip:" + c.ClientIP(), c.ClientIP()
+	}
+}
+
+func toRateLimit(rule config.RateLimitRule) domain.RateLimit {
+	return domain.RateLimit{Rate: rule.Rate, Burst: rule.Burst}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// memoryRateLimitSweep как часто удалять полные корзины: они ничем не отличаются от отсутствующих
+const memoryRateLimitSweep = time.Minute
+
+// MemoryRateLimiter корзины в памяти процесса. Каждый экземпляр сервиса считает
+// запросы отдельно, для нескольких экземпляров нужен общий backend.
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt когда корзина наполнится, после этого ее можно удалить
+	fullAt time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *MemoryRateLimiter) Take(_ context.Context, key string, limit domain.RateLimit) (*domain.RateLimitDecision, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Burst), updatedAt: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = limit.Refill(bucket.tokens, now.Sub(bucket.updatedAt))
+	bucket.updatedAt = now
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	decision := limit.Decision(bucket.tokens, allowed)
+	bucket.fullAt = now.Add(decision.Reset)
+
+	return decision, nil
+}
+
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memoryRateLimitSweep {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
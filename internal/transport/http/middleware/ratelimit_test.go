@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// slowRefill корзина, которая почти не пополняется за время теста
+const slowRefill = 0.001
+
+func TestMemoryRateLimiterTake(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	limit := domain.RateLimit{Rate: slowRefill, Burst: 3}
+
+	tests := []struct {
+		key           string
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{key: "a", wantAllowed: true, wantRemaining: 2},
+		{key: "a", wantAllowed: true, wantRemaining: 1},
+		{key: "a", wantAllowed: true, wantRemaining: 0},
+		{key: "a", wantAllowed: false, wantRemaining: 0},
+		{key: "b", wantAllowed: true, wantRemaining: 2},
+		{key: "a", wantAllowed: false, wantRemaining: 0},
+	}
+
+	for i, tt := range tests {
+		decision, err := limiter.Take(context.Background(), tt.key, limit)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if decision.Allowed != tt.wantAllowed || decision.Remaining != tt.wantRemaining {
+			t.Fatalf("take %d (%s) = allowed %v remaining %d, want %v %d",
+				i, tt.key, decision.Allowed, decision.Remaining, tt.wantAllowed, tt.wantRemaining)
+		}
+		if decision.Limit != limit.Burst {
+			t.Errorf("take %d Limit = %d, want %d", i, decision.Limit, limit.Burst)
+		}
+		if !decision.Allowed && decision.RetryAfter <= 0 {
+			t.Errorf("take %d RetryAfter = %s, want positive", i, decision.RetryAfter)
+		}
+	}
+}
+
+func TestMemoryRateLimiterRefills(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	limit := domain.RateLimit{Rate: slowRefill, Burst: 1}
+
+	if decision, _ := limiter.Take(context.Background(), "a", limit); !decision.Allowed {
+		t.Fatal("first take was rejected")
+	}
+	if decision, _ := limiter.Take(context.Background(), "a", limit); decision.Allowed {
+		t.Fatal("take from an empty bucket was allowed")
+	}
+
+	// сдвигаем последнее обновление назад на время, за которое появляется один токен
+	limiter.mu.Lock()
+	limiter.buckets["a"].updatedAt = limiter.buckets["a"].updatedAt.Add(-time.Duration(1 / slowRefill * float64(time.Second)))
+	limiter.mu.Unlock()
+
+	if decision, _ := limiter.Take(context.Background(), "a", limit); !decision.Allowed {
+		t.Fatal("take after refill was rejected")
+	}
+}
+
+func TestMemoryRateLimiterSweep(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	limit := domain.RateLimit{Rate: slowRefill, Burst: 1}
+
+	if _, err := limiter.Take(context.Background(), "a", limit); err != nil {
+		t.Fatal(err)
+	}
+
+	limiter.mu.Lock()
+	limiter.buckets["a"].fullAt = time.Now().Add(-time.Second)
+	limiter.lastSweep = time.Now().Add(-memoryRateLimitSweep)
+	limiter.mu.Unlock()
+
+	if _, err := limiter.Take(context.Background(), "b", limit); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := limiter.buckets["a"]; ok {
+		t.Fatal("full bucket was not swept")
+	}
+}
+
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Take(context.Context, string, domain.RateLimit) (*domain.RateLimitDecision, error) {
+	return nil, errors.New("storage is down")
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keyID := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	cfg := config.RateLimitConfig{
+		Default: config.RateLimitRule{Rate: slowRefill, Burst: 2},
+		Routes: map[string]config.RateLimitRule{
+			"POST /ping": {Rate: slowRefill, Burst: 1},
+		},
+		Principals: map[string]config.RateLimitRule{
+			keyID.String(): {Rate: slowRefill, Burst: 1},
+		},
+	}
+
+	type request struct {
+		method     string
+		principal  *domain.Principal
+		wantStatus int
+	}
+
+	tests := []struct {
+		name     string
+		limiter  domain.RateLimiter
+		requests []request
+	}{
+		{
+			name:    "default rule per route",
+			limiter: NewMemoryRateLimiter(),
+			requests: []request{
+				{method: http.MethodGet, wantStatus: http.StatusOK},
+				{method: http.MethodGet, wantStatus: http.StatusOK},
+				{method: http.MethodGet, wantStatus: http.StatusTooManyRequests},
+				{method: http.MethodPost, wantStatus: http.StatusOK},
+				{method: http.MethodPost, wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:    "principal rule applies to all routes",
+			limiter: NewMemoryRateLimiter(),
+			requests: []request{
+				{method: http.MethodGet, principal: &domain.Principal{APIKeyUUID: &keyID}, wantStatus: http.StatusOK},
+				{method: http.MethodPost, principal: &domain.Principal{APIKeyUUID: &keyID}, wantStatus: http.StatusTooManyRequests},
+				{method: http.MethodPost, wantStatus: http.StatusOK},
+			},
+		},
+		{
+			name:    "requests pass when storage fails",
+			limiter: failingRateLimiter{},
+			requests: []request{
+				{method: http.MethodGet, wantStatus: http.StatusOK},
+				{method: http.MethodGet, wantStatus: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RateLimit(slog.New(slog.DiscardHandler), cfg, tt.limiter))
+			router.Handle(http.MethodGet, "/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.Handle(http.MethodPost, "/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(r.method, "/ping", nil)
+				if r.principal != nil {
+					req = req.WithContext(domain.WithPrincipal(req.Context(), r.principal))
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != r.wantStatus {
+					t.Fatalf("request %d %s status = %d, want %d", i, r.method, w.Code, r.wantStatus)
+				}
+				if _, failing := tt.limiter.(failingRateLimiter); failing {
+					continue
+				}
+				if w.Header().Get("RateLimit-Limit") == "" {
+					t.Errorf("request %d has no RateLimit-Limit header", i)
+				}
+				if r.wantStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d has no Retry-After header", i)
+				}
+			}
+		})
+	}
+}
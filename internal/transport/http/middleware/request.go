@@ -1,12 +1,20 @@
 package middleware
 
 import (
+	"context"
+	"log/slog"
+
+	"github.com/ent1k1377/subscriptions/internal/tracing"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 const RequestIDKey = "X-Request-Id"
 
+// ClientIPKey адрес клиента в контексте запроса, попадает в журнал изменений
+const ClientIPKey = "client_ip"
+
 func RequestID() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		requestID := ctx.GetHeader(RequestIDKey)
@@ -19,3 +27,16 @@ func RequestID() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// RequestContext переносит request id и адрес клиента из gin.Context в контекст запроса
+// для сервисного слоя
+func RequestContext(c *gin.Context) context.Context {
+	ctx := context.WithValue(c.Request.Context(), RequestIDKey, c.MustGet(RequestIDKey).(string))
+	return context.WithValue(ctx, ClientIPKey, c.ClientIP())
+}
+
+// Logger логгер обработчика с request id и идентификаторами трассы запроса
+func Logger(c *gin.Context, logger *slog.Logger) *slog.Logger {
+	return logger.With(slog.String("request_id", c.GetString(RequestIDKey))).
+		With(tracing.LogAttrs(c.Request.Context())...)
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+const TenantKey = "organization_id"
+
+// Tenant определяет организацию запроса по токену или API-ключу, а без аутентификации
+// по заголовку cfg.Header, и кладет ее в контекст запроса. Должен стоять после Auth,
+// чтобы учитывать организацию вызывающего.
+func Tenant(logger *slog.Logger, cfg config.TenantConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := domain.PrincipalFromContext(c.Request.Context())
+		organizationID, err := domain.ResolveTenant(principal, c.GetHeader(cfg.Header), cfg.Required)
+		if err != nil {
+			logger.Info("Rejected organization",
+				slog.String("request_id", c.GetString(RequestIDKey)),
+				slog.String("error", err.Error()),
+			)
+			switch {
+			case errors.Is(err, domain.ErrForbidden):
+				c.AbortWithStatusJSON(http.StatusForbidden, common.ToErrorResponse("the token belongs to another organization"))
+			case errors.Is(err, domain.ErrTenantUnbound):
+				c.AbortWithStatusJSON(http.StatusForbidden, common.ToErrorResponse("the token is not bound to an organization"))
+			case errors.Is(err, domain.ErrInvalidTenant):
+				c.AbortWithStatusJSON(http.StatusBadRequest, common.ToErrorResponse(cfg.Header+" must be a UUID"))
+			default:
+				c.AbortWithStatusJSON(http.StatusBadRequest, common.ToErrorResponse(cfg.Header+" header is required"))
+			}
+			return
+		}
+
+		c.Set(TenantKey, organizationID)
+		c.Request = c.Request.WithContext(domain.WithTenant(c.Request.Context(), organizationID))
+		c.Next()
+	}
+}
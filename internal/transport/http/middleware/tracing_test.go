@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTracing спан запроса продолжает трассу из traceparent, называется по шаблону
+// маршрута, попадает в контекст запроса и отмечается ошибкой на ответах 5xx
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var handlerSpan trace.SpanContext
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Tracing())
+	router.GET("/api/subscriptions/:uuid", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	router.POST("/api/subscriptions", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		traceparent string
+		wantName    string
+		wantStatus  codes.Code
+	}{
+		{name: "continues the caller trace", method: http.MethodGet, path: "/api/subscriptions/6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			traceparent: "00-4bf92f3577b34da6a3ce929b0e0e4736-00f067aa0ba902b7-01", wantName: "GET /api/subscriptions/:uuid", wantStatus: codes.Unset},
+		{name: "server error", method: http.MethodPost, path: "/api/subscriptions", wantName: "POST /api/subscriptions", wantStatus: codes.Error},
+		{name: "unknown route", method: http.MethodGet, path: "/wp-admin/setup.php", wantName: "GET", wantStatus: codes.Unset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.wantName || span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span = %q (%s), want server span %q", span.Name(), span.SpanKind(), tt.wantName)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+			if !hasAttribute(span.Attributes(), "request_id") || !hasAttribute(span.Attributes(), "http.response.status_code") {
+				t.Errorf("span attributes = %v, want request_id and response status", span.Attributes())
+			}
+
+			if tt.traceparent == "" {
+				return
+			}
+			if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929b0e0e4736" {
+				t.Errorf("trace id = %s, want the caller trace", got)
+			}
+			if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" || !span.Parent().IsRemote() {
+				t.Errorf("parent span = %s, want the remote caller span", got)
+			}
+			if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("handler context span = %s, want the request span %s", handlerSpan.SpanID(), span.SpanContext().SpanID())
+			}
+		})
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, key attribute.Key) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
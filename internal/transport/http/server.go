@@ -6,7 +6,17 @@ import (
 	"net/http"
 
 	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/apikey"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/audit"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/calendar"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/events"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/graphql"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/health"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/role"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/statement"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/subscription"
+	"github.com/ent1k1377/subscriptions/internal/transport/http/handler/webhook"
 	"github.com/ent1k1377/subscriptions/internal/transport/http/middleware"
 
 	"github.com/ent1k1377/subscriptions/docs"
@@ -19,10 +29,44 @@ type Server struct {
 	httpServer          *http.Server
 	engine              *gin.Engine
 	logger              *slog.Logger
+	tenantCfg           config.TenantConfig
+	rateLimitCfg        config.RateLimitConfig
+	rateLimiter         domain.RateLimiter
+	metricsHandler      http.Handler
+	healthHandler       *health.Handler
+	authenticator       domain.Authenticator
+	apiKeys             domain.Authenticator
+	apiKeyHandler       *apikey.Handler
+	roleHandler         *role.Handler
+	auditHandler        *audit.Handler
 	subscriptionHandler *subscription.Handler
+	calendarHandler     *calendar.Handler
+	statementHandler    *statement.Handler
+	webhookHandler      *webhook.Handler
+	eventsHandler       *events.Handler
+	graphqlHandler      *graphql.Handler
 }
 
-func NewServer(cfg config.ServerConfig, baseLogger *slog.Logger, subscriptionHandler *subscription.Handler) *Server {
+func NewServer(
+	cfg config.ServerConfig,
+	tenantCfg config.TenantConfig,
+	rateLimitCfg config.RateLimitConfig,
+	baseLogger *slog.Logger,
+	rateLimiter domain.RateLimiter,
+	metricsHandler http.Handler,
+	healthHandler *health.Handler,
+	authenticator domain.Authenticator,
+	apiKeys domain.Authenticator,
+	apiKeyHandler *apikey.Handler,
+	roleHandler *role.Handler,
+	auditHandler *audit.Handler,
+	subscriptionHandler *subscription.Handler,
+	calendarHandler *calendar.Handler,
+	statementHandler *statement.Handler,
+	webhookHandler *webhook.Handler,
+	eventsHandler *events.Handler,
+	graphqlHandler *graphql.Handler,
+) *Server {
 	engine := gin.Default()
 	httpServer := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -35,33 +79,150 @@ func NewServer(cfg config.ServerConfig, baseLogger *slog.Logger, subscriptionHan
 		httpServer:          httpServer,
 		engine:              engine,
 		logger:              logger,
+		tenantCfg:           tenantCfg,
+		rateLimitCfg:        rateLimitCfg,
+		rateLimiter:         rateLimiter,
+		metricsHandler:      metricsHandler,
+		healthHandler:       healthHandler,
+		authenticator:       authenticator,
+		apiKeys:             apiKeys,
+		apiKeyHandler:       apiKeyHandler,
+		roleHandler:         roleHandler,
+		auditHandler:        auditHandler,
 		subscriptionHandler: subscriptionHandler,
+		calendarHandler:     calendarHandler,
+		statementHandler:    statementHandler,
+		webhookHandler:      webhookHandler,
+		eventsHandler:       eventsHandler,
+		graphqlHandler:      graphqlHandler,
 	}
 }
 
 func (s *Server) Start() error {
 	s.SetRoutes()
 
+	s.logger.Info("Starting HTTP server", slog.String("addr", s.httpServer.Addr))
 	return s.httpServer.ListenAndServe()
 }
 
+// Close перестает принимать соединения и дожидается завершения текущих запросов,
+// а по истечении ctx обрывает оставшиеся
 func (s *Server) Close(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		_ = s.httpServer.Close()
+		return err
+	}
+
+	return nil
 }
 
 func (s *Server) SetRoutes() {
-	s.engine.Use(middleware.RequestID())
+	// пробы оркестратора объявлены до общих middleware, чтобы не засорять метрики и трассы
+	s.engine.GET("/healthz", s.healthHandler.Liveness)
+	s.engine.GET("/readyz", s.healthHandler.Readiness)
+	s.engine.GET("/health", s.healthHandler.Health)
+
+	s.engine.Use(middleware.Metrics(), middleware.RequestID(), middleware.Tracing())
+
+	// метрики собирает Prometheus внутри периметра, поэтому без аутентификации
+	s.engine.GET("/metrics", gin.WrapH(s.metricsHandler))
 
 	docs.SwaggerInfo.BasePath = "/api/"
 	s.engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	api := s.engine.Group("/api/subscriptions")
+	auth := s.authMiddleware()
+	// каждый обработчик объявляет нужное право; чьи данные доступны по нему, проверяет
+	// сервисный слой, см. middleware.RequirePermission
+	read := middleware.RequirePermission(domain.PermissionSubscriptionsRead)
+	write := middleware.RequirePermission(domain.PermissionSubscriptionsWrite)
+	remove := middleware.RequirePermission(domain.PermissionSubscriptionsDelete)
+	export := middleware.RequirePermission(domain.PermissionSubscriptionsExport)
+	reports := middleware.RequirePermission(domain.PermissionReportsRead)
+
+	api := s.engine.Group("/api/subscriptions", auth...)
+	{
+		api.POST("/", write, s.subscriptionHandler.Create)
+		api.GET("/:uuid", read, s.subscriptionHandler.GetSubscription)
+		api.PUT("/:uuid", write, s.subscriptionHandler.UpdateSubscription)
+		api.DELETE("/:uuid", remove, s.subscriptionHandler.DeleteSubscription)
+		api.POST("/:uuid/restore", write, s.subscriptionHandler.RestoreSubscription)
+		api.GET("/:uuid/history", read, s.auditHandler.History)
+		api.GET("/list", read, s.subscriptionHandler.ListSubscriptions)
+		api.GET("/search", read, s.subscriptionHandler.SearchSubscriptions)
+		api.GET("/events", read, s.eventsHandler.Stream)
+		// пакет может удалять подписки, поэтому требует оба права
+		api.POST("/batch", write, remove, s.subscriptionHandler.Batch)
+		api.POST("/import", write, s.subscriptionHandler.Import)
+		api.GET("/export", export, s.subscriptionHandler.Export)
+		api.POST("/total", reports, s.subscriptionHandler.TotalCostSubscriptions)
+	}
+
+	// календарь проверяет собственный токен из ссылки: календарные клиенты не передают заголовки
+	s.engine.GET("/api/users/:user_id/calendar.ics", append(s.rateLimitMiddleware(), s.calendarHandler.Feed)...)
+
+	users := s.engine.Group("/api/users/:user_id", auth...)
+	{
+		users.POST("/calendar-token", write, s.calendarHandler.IssueToken)
+		users.DELETE("/calendar-token", write, s.calendarHandler.RevokeToken)
+		users.POST("/statements", write, s.statementHandler.Upload)
+		users.GET("/subscription-candidates", read, s.statementHandler.ListCandidates)
+		users.POST("/subscription-candidates/confirm", write, s.statementHandler.ConfirmCandidates)
+		users.DELETE("/subscription-candidates/:candidate_id", write, s.statementHandler.DismissCandidate)
+	}
+
+	webhooks := s.engine.Group("/api/webhooks", append(auth, middleware.RequirePermission(domain.PermissionWebhooksManage))...)
+	{
+		webhooks.POST("", s.webhookHandler.Create)
+		webhooks.GET("", s.webhookHandler.List)
+		webhooks.GET("/:uuid", s.webhookHandler.Get)
+		webhooks.PUT("/:uuid", s.webhookHandler.Update)
+		webhooks.DELETE("/:uuid", s.webhookHandler.Delete)
+		webhooks.GET("/:uuid/deliveries", s.webhookHandler.ListDeliveries)
+		webhooks.POST("/:uuid/deliveries/:delivery_id/redeliver", s.webhookHandler.Redeliver)
+	}
+
+	apiKeys := s.engine.Group("/api/admin/api-keys", append(auth, middleware.RequirePermission(domain.PermissionAPIKeysManage))...)
 	{
-		api.POST("/", s.subscriptionHandler.Create)
-		api.GET("/:uuid", s.subscriptionHandler.GetSubscription)
-		api.PUT("/:uuid", s.subscriptionHandler.UpdateSubscription)
-		api.DELETE("/:uuid", s.subscriptionHandler.DeleteSubscription)
-		api.GET("/list", s.subscriptionHandler.ListSubscriptions)
-		api.POST("/total", s.subscriptionHandler.TotalCostSubscriptions)
+		apiKeys.POST("", s.apiKeyHandler.Issue)
+		apiKeys.GET("", s.apiKeyHandler.List)
+		apiKeys.GET("/:uuid", s.apiKeyHandler.Get)
+		apiKeys.POST("/:uuid/rotate", s.apiKeyHandler.Rotate)
+		apiKeys.DELETE("/:uuid", s.apiKeyHandler.Revoke)
 	}
+
+	roles := s.engine.Group("/api/admin/users/:user_id/roles", append(auth, middleware.RequirePermission(domain.PermissionRolesManage))...)
+	{
+		roles.GET("", s.roleHandler.List)
+		roles.PUT("/:role", s.roleHandler.Assign)
+		roles.DELETE("/:role", s.roleHandler.Revoke)
+	}
+
+	auditLog := s.engine.Group("/api/admin/audit", append(auth, middleware.RequirePermission(domain.PermissionAuditRead))...)
+	{
+		auditLog.GET("", s.auditHandler.Search)
+	}
+
+	// мутации и отчеты GraphQL объявляют свои права в резолверах
+	s.engine.POST("/api/graphql", append(auth, read, s.graphqlHandler.Query)...)
+	s.engine.GET("/api/graphql/playground", s.graphqlHandler.Playground)
+}
+
+// authMiddleware возвращает проверку JWT и API-ключей, если аутентификация включена,
+// выбор организации запроса и ограничение частоты запросов
+func (s *Server) authMiddleware() []gin.HandlerFunc {
+	handlers := []gin.HandlerFunc{middleware.Tenant(s.logger, s.tenantCfg)}
+	if s.authenticator != nil {
+		handlers = append([]gin.HandlerFunc{middleware.Auth(s.logger, s.authenticator, s.apiKeys)}, handlers...)
+	}
+
+	return append(handlers, s.rateLimitMiddleware()...)
+}
+
+// rateLimitMiddleware пуст, если ограничение частоты запросов выключено
+func (s *Server) rateLimitMiddleware() []gin.HandlerFunc {
+	if s.rateLimiter == nil {
+		return nil
+	}
+
+	return []gin.HandlerFunc{middleware.RateLimit(s.logger, s.rateLimitCfg, s.rateLimiter)}
 }
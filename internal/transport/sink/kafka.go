@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka публикует события в топик с ключом id подписки, поэтому события одной
+// подписки попадают в одну партицию и читаются по порядку. Идентификатор события
+// передается в заголовке event_id для дедупликации на стороне потребителя.
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+func NewKafka(cfg config.KafkaConfig) *Kafka {
+	return &Kafka{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// relay отправляет события по одному и ждет подтверждения каждого
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (k *Kafka) Name() string {
+	return "kafka"
+}
+
+func (k *Kafka) Send(ctx context.Context, event *domain.OutboxEvent) error {
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateID.String()),
+		Value: event.Payload,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(event.EventID.String())},
+			{Key: "event_type", Value: []byte(event.Type)},
+		},
+		Time: event.CreatedAt,
+	})
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
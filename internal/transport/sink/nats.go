@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ent1k1377/subscriptions/internal/config"
+	"github.com/ent1k1377/subscriptions/internal/domain"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS публикует события в subject <prefix>.<тип события>. В режиме JetStream
+// публикация подтверждается сервером, а повтор с тем же Nats-Msg-Id
+// отбрасывается в пределах окна дедупликации стрима.
+type NATS struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	prefix string
+}
+
+func NewNATS(cfg config.NATSConfig) (*NATS, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("subscriptions-outbox"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to nats: %w", err)
+	}
+
+	sink := &NATS{conn: conn, prefix: cfg.SubjectPrefix}
+	if cfg.JetStream {
+		sink.js, err = jetstream.New(conn)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error creating jetstream context: %w", err)
+		}
+	}
+
+	return sink, nil
+}
+
+func (n *NATS) Name() string {
+	return "nats"
+}
+
+func (n *NATS) Send(ctx context.Context, event *domain.OutboxEvent) error {
+	msg := nats.NewMsg(n.prefix + "." + string(event.Type))
+	msg.Data = event.Payload
+	msg.Header.Set(nats.MsgIdHdr, event.EventID.String())
+	msg.Header.Set("Event-Type", string(event.Type))
+	msg.Header.Set("Aggregate-Id", event.AggregateID.String())
+
+	if n.js != nil {
+		_, err := n.js.PublishMsg(ctx, msg)
+		return err
+	}
+
+	if err := n.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	// без JetStream подтверждения нет, но Flush гарантирует, что сообщение дошло до сервера
+	return n.conn.FlushWithContext(ctx)
+}
+
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
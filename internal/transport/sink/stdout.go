@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/ent1k1377/subscriptions/internal/domain"
+)
+
+// Stdout пишет события построчно в JSON. Подходит для локальной отладки и
+// для сбора логов контейнера внешним агентом.
+type Stdout struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdout(w io.Writer) *Stdout {
+	return &Stdout{w: w}
+}
+
+func (s *Stdout) Name() string {
+	return "stdout"
+}
+
+func (s *Stdout) Send(_ context.Context, event *domain.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := make([]byte, 0, len(event.Payload)+1)
+	line = append(line, event.Payload...)
+	line = append(line, '\n')
+	_, err := s.w.Write(line)
+
+	return err
+}
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE subscriptions SET created_at = NOW() WHERE created_at IS NULL;
+UPDATE subscriptions SET update_at = NOW() WHERE update_at IS NULL;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN update_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS subscriptions_created_at_id_idx ON subscriptions (created_at, id);
+CREATE INDEX IF NOT EXISTS subscriptions_user_id_created_at_id_idx ON subscriptions (user_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subscriptions_user_id_created_at_id_idx;
+DROP INDEX IF EXISTS subscriptions_created_at_id_idx;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN update_at DROP NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', service_name), 'A') ||
+        setweight(to_tsvector('simple', notes), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS subscriptions_search_vector_idx ON subscriptions USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS subscriptions_service_name_trgm_idx ON subscriptions USING GIN (service_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS subscriptions_notes_trgm_idx ON subscriptions USING GIN (notes gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subscriptions_notes_trgm_idx;
+DROP INDEX IF EXISTS subscriptions_service_name_trgm_idx;
+DROP INDEX IF EXISTS subscriptions_search_vector_idx;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS notes;
+
+DROP EXTENSION IF EXISTS pg_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS subscription_candidates (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    merchant TEXT NOT NULL,
+    service_name TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    charge_amount INTEGER NOT NULL,
+    period TEXT NOT NULL CHECK (period IN ('monthly', 'yearly')),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    occurrences INTEGER NOT NULL,
+    last_charge_date DATE NOT NULL,
+    confidence DOUBLE PRECISION NOT NULL,
+    subscription_id UUID REFERENCES subscriptions (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS subscription_candidates_user_pending_idx
+    ON subscription_candidates (user_id) WHERE subscription_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_candidates;
+-- +goose StatementEnd
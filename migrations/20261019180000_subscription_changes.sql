@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS subscription_changes (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    subscription_id UUID NOT NULL,
+    service_name TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    notes TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    update_at TIMESTAMPTZ NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS subscription_changes_occurred_at_idx ON subscription_changes (occurred_at);
+
+-- снимок строки сохраняется для возобновления SSE-потока по Last-Event-ID,
+-- уведомление будит слушателей, которые сами дочитывают новые изменения
+CREATE OR REPLACE FUNCTION subscription_changes_capture() RETURNS trigger AS $$
+DECLARE
+    rec subscriptions%ROWTYPE;
+    event_type TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+        event_type := 'subscription.deleted';
+    ELSIF TG_OP = 'INSERT' THEN
+        rec := NEW;
+        event_type := 'subscription.created';
+    ELSE
+        rec := NEW;
+        event_type := 'subscription.updated';
+    END IF;
+
+    INSERT INTO subscription_changes (event_type, subscription_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at)
+    VALUES (event_type, rec.id, rec.service_name, rec.price, rec.user_id, rec.start_date, rec.end_date, rec.notes, COALESCE(rec.created_at, NOW()), COALESCE(rec.update_at, NOW()));
+
+    PERFORM pg_notify('subscription_changes', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscription_changes_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION subscription_changes_capture();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS subscription_changes_trigger ON subscriptions;
+DROP FUNCTION IF EXISTS subscription_changes_capture();
+DROP TABLE IF EXISTS subscription_changes;
+-- +goose StatementEnd
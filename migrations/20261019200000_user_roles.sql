@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('user', 'support', 'admin')),
+    -- granted_by администратор, назначивший роль; NULL, если роль назначена без аутентификации
+    granted_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_roles;
+-- +goose StatementEnd
@@ -0,0 +1,249 @@
+-- +goose Up
+-- +goose StatementBegin
+-- существующие данные относятся к организации по умолчанию (нулевой UUID), после заполнения
+-- значение по умолчанию снимается, чтобы приложение всегда указывало организацию явно
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE subscriptions ALTER COLUMN organization_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS subscriptions_organization_user_idx ON subscriptions (organization_id, user_id);
+
+ALTER TABLE subscription_candidates ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE subscription_candidates ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE calendar_feed_tokens ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE calendar_feed_tokens ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE subscription_changes ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE subscription_changes ALTER COLUMN organization_id DROP DEFAULT;
+
+CREATE OR REPLACE FUNCTION subscription_changes_capture() RETURNS trigger AS $$
+DECLARE
+    rec subscriptions%ROWTYPE;
+    event_type TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+        event_type := 'subscription.deleted';
+    ELSIF TG_OP = 'INSERT' THEN
+        rec := NEW;
+        event_type := 'subscription.created';
+    ELSE
+        rec := NEW;
+        event_type := 'subscription.updated';
+    END IF;
+
+    INSERT INTO subscription_changes (event_type, subscription_id, organization_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at)
+    VALUES (event_type, rec.id, rec.organization_id, rec.service_name, rec.price, rec.user_id, rec.start_date, rec.end_date, rec.notes, COALESCE(rec.created_at, NOW()), COALESCE(rec.update_at, NOW()));
+
+    PERFORM pg_notify('subscription_changes', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- приложение задает организацию в каждой транзакции через SET LOCAL app.tenant_id,
+-- без нее строки не видны. Фоновые задачи по всем организациям задают app.all_tenants.
+-- FORCE распространяет политики и на владельца таблиц; суперпользователь их все равно обходит,
+-- поэтому приложение должно подключаться обычной ролью.
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscriptions_tenant_isolation ON subscriptions
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE subscription_candidates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_candidates FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_candidates_tenant_isolation ON subscription_candidates
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+-- ключи и назначенные роли действуют только в своей организации: вызывающий, у которого нет
+-- организации, не может выбрать ее заголовком. Существующие ключи и роли относятся к
+-- организации по умолчанию.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE api_keys ALTER COLUMN organization_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS api_keys_organization_idx ON api_keys (organization_id, created_at);
+
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE user_roles ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_pkey;
+ALTER TABLE user_roles ADD PRIMARY KEY (organization_id, user_id, role);
+
+-- ключ ищется по хешу до того, как известна организация запроса, поэтому аутентификация
+-- читает api_keys с app.all_tenants
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY api_keys_tenant_isolation ON api_keys
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE user_roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_roles FORCE ROW LEVEL SECURITY;
+CREATE POLICY user_roles_tenant_isolation ON user_roles
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+-- вебхук получает события только своей организации, отправки и события outbox хранят
+-- организацию подписки. Существующие вебхуки относятся к организации по умолчанию.
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE webhooks ALTER COLUMN organization_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS webhooks_organization_idx ON webhooks (organization_id, created_at);
+
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS organization_id UUID;
+UPDATE webhook_deliveries d SET organization_id = wh.organization_id FROM webhooks wh WHERE wh.id = d.webhook_id;
+ALTER TABLE webhook_deliveries ALTER COLUMN organization_id SET NOT NULL;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS organization_id UUID;
+UPDATE outbox SET organization_id = COALESCE(NULLIF(payload -> 'data' ->> 'organization_id', '')::uuid,
+    '00000000-0000-0000-0000-000000000000');
+ALTER TABLE outbox ALTER COLUMN organization_id SET NOT NULL;
+
+-- relay и рассылка вебхуков обрабатывают события всех организаций с app.all_tenants,
+-- управление вебхуками и журнал отправок видны только в организации запроса
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox FORCE ROW LEVEL SECURITY;
+CREATE POLICY outbox_tenant_isolation ON outbox
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+-- у пользователя свой токен календаря в каждой организации: выпуск токена в одной
+-- организации не заменяет и не отзывает токен в другой
+ALTER TABLE calendar_feed_tokens DROP CONSTRAINT IF EXISTS calendar_feed_tokens_pkey;
+ALTER TABLE calendar_feed_tokens ADD PRIMARY KEY (organization_id, user_id);
+
+-- лента календаря находит организацию по токену с app.all_tenants, выпуск и отзыв
+-- токена видят только организацию запроса
+ALTER TABLE calendar_feed_tokens ENABLE ROW LEVEL SECURITY;
+ALTER TABLE calendar_feed_tokens FORCE ROW LEVEL SECURITY;
+CREATE POLICY calendar_feed_tokens_tenant_isolation ON calendar_feed_tokens
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+
+-- изменения пишет триггер в транзакции изменения подписки, поэтому организация
+-- изменения совпадает с организацией транзакции. Поток изменений читает все организации
+-- и фильтрует их сам.
+ALTER TABLE subscription_changes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_changes FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_changes_tenant_isolation ON subscription_changes
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY IF EXISTS subscription_changes_tenant_isolation ON subscription_changes;
+ALTER TABLE subscription_changes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_changes DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS calendar_feed_tokens_tenant_isolation ON calendar_feed_tokens;
+ALTER TABLE calendar_feed_tokens NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE calendar_feed_tokens DISABLE ROW LEVEL SECURITY;
+
+-- до этой миграции у пользователя один токен: остается последний выпущенный
+DELETE FROM calendar_feed_tokens t USING calendar_feed_tokens newer
+WHERE t.user_id = newer.user_id
+    AND (t.created_at, t.organization_id) < (newer.created_at, newer.organization_id);
+ALTER TABLE calendar_feed_tokens DROP CONSTRAINT IF EXISTS calendar_feed_tokens_pkey;
+ALTER TABLE calendar_feed_tokens ADD PRIMARY KEY (user_id);
+
+DROP POLICY IF EXISTS outbox_tenant_isolation ON outbox;
+ALTER TABLE outbox NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhook_deliveries_tenant_isolation ON webhook_deliveries;
+ALTER TABLE webhook_deliveries NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhooks_tenant_isolation ON webhooks;
+ALTER TABLE webhooks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhooks DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS organization_id;
+DROP INDEX IF EXISTS webhooks_organization_idx;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS organization_id;
+
+DROP POLICY IF EXISTS user_roles_tenant_isolation ON user_roles;
+ALTER TABLE user_roles NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE user_roles DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS api_keys_tenant_isolation ON api_keys;
+ALTER TABLE api_keys NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE api_keys DISABLE ROW LEVEL SECURITY;
+
+-- роли, назначенные в нескольких организациях, схлопываются в одну запись
+DELETE FROM user_roles a USING user_roles b
+WHERE a.user_id = b.user_id AND a.role = b.role AND a.organization_id > b.organization_id;
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_pkey;
+ALTER TABLE user_roles ADD PRIMARY KEY (user_id, role);
+ALTER TABLE user_roles DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS api_keys_organization_idx;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+
+DROP POLICY IF EXISTS subscription_candidates_tenant_isolation ON subscription_candidates;
+ALTER TABLE subscription_candidates NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_candidates DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subscriptions_tenant_isolation ON subscriptions;
+ALTER TABLE subscriptions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+
+CREATE OR REPLACE FUNCTION subscription_changes_capture() RETURNS trigger AS $$
+DECLARE
+    rec subscriptions%ROWTYPE;
+    event_type TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+        event_type := 'subscription.deleted';
+    ELSIF TG_OP = 'INSERT' THEN
+        rec := NEW;
+        event_type := 'subscription.created';
+    ELSE
+        rec := NEW;
+        event_type := 'subscription.updated';
+    END IF;
+
+    INSERT INTO subscription_changes (event_type, subscription_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at)
+    VALUES (event_type, rec.id, rec.service_name, rec.price, rec.user_id, rec.start_date, rec.end_date, rec.notes, COALESCE(rec.created_at, NOW()), COALESCE(rec.update_at, NOW()));
+
+    PERFORM pg_notify('subscription_changes', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE subscription_changes DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE calendar_feed_tokens DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE subscription_candidates DROP COLUMN IF EXISTS organization_id;
+DROP INDEX IF EXISTS subscriptions_organization_user_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS organization_id;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    actor_user_id UUID,
+    actor_api_key_id UUID,
+    request_id TEXT NOT NULL DEFAULT '',
+    source_ip TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    diff JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_subscription_idx ON audit_log (subscription_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_organization_idx ON audit_log (organization_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_user_id, id) WHERE actor_user_id IS NOT NULL;
+
+-- журнал только дополняется: изменение и удаление записей запрещены на уровне базы
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_immutable_trigger
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+CREATE TRIGGER audit_log_no_truncate_trigger
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY audit_log_tenant_isolation ON audit_log
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
+-- +goose StatementEnd
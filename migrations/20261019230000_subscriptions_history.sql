@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+-- каждая версия подписки с интервалом действия [valid_from, valid_to); у текущей версии valid_to пуст,
+-- у удаленной подписки закрыты все версии. Колонки повторяют subscriptions, чтобы запросы на момент
+-- времени читали те же поля.
+CREATE TABLE IF NOT EXISTS subscriptions_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL,
+    organization_id UUID NOT NULL,
+    service_name VARCHAR(64) NOT NULL,
+    price INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    update_at TIMESTAMPTZ NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ,
+    CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_history_id_idx ON subscriptions_history (id, valid_from);
+CREATE INDEX IF NOT EXISTS subscriptions_history_period_idx ON subscriptions_history (organization_id, valid_from, valid_to);
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_history_current_idx ON subscriptions_history (id) WHERE valid_to IS NULL;
+
+-- версии ведет триггер, поэтому в историю попадают изменения из любого источника.
+-- Время версии — начало транзакции: все изменения одной транзакции видны на момент одновременно.
+CREATE OR REPLACE FUNCTION subscriptions_history_capture() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD IS NOT DISTINCT FROM NEW THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE subscriptions_history SET valid_to = NOW()
+        WHERE id = OLD.id AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO subscriptions_history (id, organization_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at, valid_from)
+        VALUES (NEW.id, NEW.organization_id, NEW.service_name, NEW.price, NEW.user_id, NEW.start_date, NEW.end_date, NEW.notes,
+            COALESCE(NEW.created_at, NOW()), COALESCE(NEW.update_at, NOW()), NOW());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_history_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION subscriptions_history_capture();
+
+-- прежние версии существующих подписок неизвестны: их история начинается с текущего состояния на момент создания
+SELECT set_config('app.all_tenants', 'on', true);
+INSERT INTO subscriptions_history (id, organization_id, service_name, price, user_id, start_date, end_date, notes, created_at, update_at, valid_from)
+SELECT id, organization_id, service_name, price, user_id, start_date, end_date, notes,
+    COALESCE(created_at, NOW()), COALESCE(update_at, NOW()), COALESCE(created_at, NOW())
+FROM subscriptions;
+
+ALTER TABLE subscriptions_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions_history FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscriptions_history_tenant_isolation ON subscriptions_history
+    USING (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (organization_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid
+        OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS subscriptions_history_trigger ON subscriptions;
+DROP FUNCTION IF EXISTS subscriptions_history_capture();
+DROP TABLE IF EXISTS subscriptions_history;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- состояние корзин token bucket, общее для экземпляров сервиса. Таблица не журналируется:
+-- после сбоя базы корзины просто начинают заново полными.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    -- allowed удалось ли забрать токен последним запросом
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    -- full_at когда корзина наполнится; после этого строку можно удалить
+    full_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_full_at_idx ON rate_limit_buckets (full_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_limit_buckets;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+// Package migrations встраивает миграции goose в бинарник, чтобы сервис знал,
+// до какой версии должна быть обновлена база
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion версия последней миграции: число до первого "_" в имени файла
+func LatestVersion() (int64, error) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(file, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse version of migration %s: %w", file, err)
+		}
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}